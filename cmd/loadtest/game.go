@@ -0,0 +1,314 @@
+// cmd/loadtest/game.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The server's plain-text announcements that bots act on. They are not
+// part of a versioned API, so a change to the room's wording must be
+// mirrored here.
+const (
+	gameStartedPrefix = "Game started! It's "
+	lineAddedInfix    = " added a line to the story."
+)
+
+// game is one room played from creation to END_GAME by simulated players.
+type game struct {
+	opts   *options
+	stats  *recorder
+	client *http.Client
+	roomID string
+	bots   []*bot
+
+	mu    sync.Mutex
+	lines []*lineTiming // by line, in the order they were submitted
+}
+
+// lineTiming follows one line from its submission to every player seeing it.
+type lineTiming struct {
+	submitted   time.Time
+	first, last time.Time
+	received    int
+}
+
+// bot is a simulated player with its own WebSocket connection.
+type bot struct {
+	game      *game
+	name      string
+	token     string // the player token from creating or joining the room
+	hostToken string // set for the host only
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+
+	linesSeen int           // read by the bot's reader goroutine only
+	ended     chan struct{} // closed when the bot receives END_GAME
+	endOnce   sync.Once
+}
+
+// play runs one game in a new room with the given players, the first of
+// whom hosts it. Failures are recorded; the error only says the game could
+// not be played to the end.
+func play(ctx context.Context, opts *options, stats *recorder, client *http.Client, names []string) error {
+	g := &game{opts: opts, stats: stats, client: client}
+	for _, name := range names {
+		g.bots = append(g.bots, &bot{game: g, name: name, ended: make(chan struct{})})
+	}
+	defer g.close()
+
+	if err := g.createRoom(g.bots[0]); err != nil {
+		return err
+	}
+	for _, b := range g.bots[1:] {
+		if err := g.join(b); err != nil {
+			return err
+		}
+	}
+	for _, b := range g.bots {
+		if err := b.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	if err := g.bots[0].send(map[string]string{"type": "START_GAME"}); err != nil {
+		stats.fail(opGame, err)
+		return err
+	}
+	timeout := time.NewTimer(opts.gameTimeout)
+	defer timeout.Stop()
+	for _, b := range g.bots {
+		select {
+		case <-b.ended:
+		case <-timeout.C:
+			err := fmt.Errorf("game did not end within %s", opts.gameTimeout)
+			stats.fail(opGame, err)
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	stats.observe(opGame, time.Since(start))
+	return nil
+}
+
+// close hangs up every bot's connection.
+func (g *game) close() {
+	for _, b := range g.bots {
+		if b.conn != nil {
+			b.writeMu.Lock()
+			b.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			b.writeMu.Unlock()
+			b.conn.Close()
+		}
+	}
+}
+
+func (g *game) createRoom(host *bot) error {
+	body := map[string]interface{}{"player_name": host.name, "story_name": "Load test", "turn_seconds": g.opts.turnSeconds}
+	var resp struct {
+		RoomID      string `json:"room_id"`
+		HostToken   string `json:"host_token"`
+		PlayerToken string `json:"player_token"`
+	}
+	start := time.Now()
+	if err := g.post("/api/v1/rooms", body, &resp); err != nil {
+		g.stats.fail(opCreateRoom, err)
+		return err
+	}
+	g.stats.observe(opCreateRoom, time.Since(start))
+	g.roomID = resp.RoomID
+	host.token, host.hostToken = resp.PlayerToken, resp.HostToken
+	return nil
+}
+
+func (g *game) join(b *bot) error {
+	var resp struct {
+		PlayerToken string `json:"player_token"`
+	}
+	start := time.Now()
+	if err := g.post("/api/v1/rooms/"+url.PathEscape(g.roomID)+"/players", map[string]string{"player_name": b.name}, &resp); err != nil {
+		g.stats.fail(opJoinRoom, err)
+		return err
+	}
+	g.stats.observe(opJoinRoom, time.Since(start))
+	b.token = resp.PlayerToken
+	return nil
+}
+
+// post sends body as JSON and decodes the response into out, if not nil.
+func (g *game) post(path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Post(g.opts.server+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&problem)
+		return fmt.Errorf("%s: %s", resp.Status, problem.Detail)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// thinkTime returns how long a bot takes to write its line.
+func (g *game) thinkTime() time.Duration {
+	think := g.opts.think
+	if g.opts.jitter > 0 {
+		think += time.Duration(rand.Int64N(int64(2*g.opts.jitter))) - g.opts.jitter
+	}
+	return max(think, 0)
+}
+
+// connect opens the bot's WebSocket and starts reading from it.
+func (b *bot) connect(ctx context.Context) error {
+	u, err := url.Parse(b.game.opts.server)
+	if err != nil {
+		return err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = "/ws"
+	query := url.Values{"room_id": {b.game.roomID}, "player_name": {b.name}, "player_token": {b.token}}
+	if b.hostToken != "" {
+		query.Set("host_token", b.hostToken)
+	}
+	u.RawQuery = query.Encode()
+
+	start := time.Now()
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		if resp != nil {
+			err = fmt.Errorf("%w: %s", err, resp.Status)
+		}
+		b.game.stats.fail(opConnect, err)
+		return err
+	}
+	b.game.stats.observe(opConnect, time.Since(start))
+	b.conn = conn
+	go b.read()
+	return nil
+}
+
+// read handles the server's messages until the connection closes.
+func (b *bot) read() {
+	for {
+		_, data, err := b.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-b.ended:
+			default:
+				// The game was still going, unless it is being torn down after a failure.
+				if !errors.Is(err, net.ErrClosed) && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					b.game.stats.fail(opDisconnect, err)
+				}
+			}
+			return
+		}
+		b.handle(time.Now(), data)
+	}
+}
+
+// handle reacts to one message received at now. Server messages are JSON;
+// announcements are plain text.
+func (b *bot) handle(now time.Time, data []byte) {
+	var msg struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Type == "" {
+		text := string(data)
+		if player, ok := strings.CutPrefix(text, gameStartedPrefix); ok {
+			b.turn(strings.TrimSuffix(player, "'s turn."))
+		} else if author, _, ok := strings.Cut(text, lineAddedInfix); ok {
+			b.lineReceived(now, author)
+		}
+		return
+	}
+	switch msg.Type {
+	case "TURN":
+		b.turn(strings.TrimSuffix(msg.Content, "'s turn"))
+	case "END_GAME":
+		b.endOnce.Do(func() { close(b.ended) })
+	case "ERROR":
+		b.game.stats.fail(opServerError, errors.New(msg.Content))
+	}
+}
+
+// turn writes a line after thinking, if it is the bot's turn.
+func (b *bot) turn(player string) {
+	if player != b.name {
+		return
+	}
+	time.AfterFunc(b.game.thinkTime(), b.submit)
+}
+
+func (b *bot) submit() {
+	g := b.game
+	g.mu.Lock()
+	g.lines = append(g.lines, &lineTiming{submitted: time.Now()})
+	n := len(g.lines)
+	g.mu.Unlock()
+
+	line := fmt.Sprintf("Line %d, written by %s.", n, b.name)
+	if err := b.send(map[string]string{"type": "SUBMIT_LINE", "content": line}); err != nil {
+		g.stats.fail(opSubmit, err)
+	}
+}
+
+// lineReceived times the delivery of the next line the bot has not yet seen.
+func (b *bot) lineReceived(now time.Time, author string) {
+	g := b.game
+	i := b.linesSeen
+	b.linesSeen++
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if i >= len(g.lines) {
+		return // a line the bots did not submit
+	}
+	line := g.lines[i]
+	latency := now.Sub(line.submitted)
+	if line.received == 0 || now.Before(line.first) {
+		line.first = now
+	}
+	if now.After(line.last) {
+		line.last = now
+	}
+	line.received++
+	g.stats.observe(opFanout, latency)
+	if author == b.name {
+		g.stats.observe(opSubmit, latency)
+	}
+	if line.received == len(g.bots) {
+		g.stats.observe(opFanoutSpread, line.last.Sub(line.first))
+	}
+}
+
+func (b *bot) send(v interface{}) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	return b.conn.WriteJSON(v)
+}
@@ -0,0 +1,128 @@
+// cmd/loadtest/main.go
+
+// Loadtest measures how much play a server sustains. It simulates players
+// who create and join rooms over the HTTP API, connect to /ws and play full
+// games, then reports latency percentiles, error rates and how long each
+// line takes to reach every player in the room.
+//
+//	go run ./cmd/loadtest -players 200 -room-size 4 -games 3 -think 500ms
+//
+// Every bot connects from the same address, so the server's per-IP rate
+// limits must be raised or turned off for the test; see the Readme.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// options configure a load test.
+type options struct {
+	server      string
+	players     int
+	roomSize    int
+	games       int
+	think       time.Duration
+	jitter      time.Duration
+	ramp        time.Duration
+	gameTimeout time.Duration
+	turnSeconds int
+	json        bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.server, "server", "http://localhost:8080", "`URL` of the server under test")
+	flag.IntVar(&opts.players, "players", 40, "simulated players connected at once")
+	flag.IntVar(&opts.roomSize, "room-size", 4, "players per room")
+	flag.IntVar(&opts.games, "games", 1, "games each room plays, one after another")
+	flag.DurationVar(&opts.think, "think", 500*time.Millisecond, "time a player takes to write a line")
+	flag.DurationVar(&opts.jitter, "jitter", 250*time.Millisecond, "randomly vary think times by up to this much either way")
+	flag.DurationVar(&opts.ramp, "ramp", 5*time.Second, "spread the rooms' first games over this long")
+	flag.DurationVar(&opts.gameTimeout, "game-timeout", 2*time.Minute, "give up on a game that has not ended after this long")
+	flag.IntVar(&opts.turnSeconds, "turn-seconds", 0, "create rooms with this turn timer; 0 for none")
+	flag.BoolVar(&opts.json, "json", false, "write the report as JSON")
+	flag.Parse()
+	opts.server = strings.TrimRight(opts.server, "/")
+
+	switch {
+	case opts.players < 1 || opts.roomSize < 1:
+		fatalf("-players and -room-size must be at least 1")
+	case opts.games < 1:
+		fatalf("-games must be at least 1")
+	case opts.think < 0 || opts.jitter < 0 || opts.ramp < 0 || opts.gameTimeout <= 0:
+		fatalf("durations must not be negative, and -game-timeout must be positive")
+	}
+
+	// Ctrl-C stops the test early; the report covers what was measured.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rooms := (opts.players + opts.roomSize - 1) / opts.roomSize
+	fmt.Fprintf(os.Stderr, "Playing %d games in each of %d rooms against %s\n", opts.games, rooms, opts.server)
+	stats := newRecorder()
+	start := time.Now()
+	run(ctx, &opts, stats, rooms)
+	report := stats.report(opts.players, rooms, time.Since(start))
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if err := report.WriteText(os.Stdout); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// run plays opts.games games in each of rooms rooms at once. Players are
+// dealt into rooms of opts.roomSize; the last room takes whoever is left.
+func run(ctx context.Context, opts *options, stats *recorder, rooms int) {
+	// One connection pool for every bot, large enough that HTTP calls do not
+	// queue behind each other in the client.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = rooms
+	client := &http.Client{Transport: transport, Timeout: 30 * time.Second}
+
+	var wg sync.WaitGroup
+	for room := 0; room < rooms; room++ {
+		size := min(opts.roomSize, opts.players-room*opts.roomSize)
+		delay := time.Duration(0)
+		if rooms > 1 {
+			delay = opts.ramp * time.Duration(room) / time.Duration(rooms-1)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			for n := 0; n < opts.games && ctx.Err() == nil; n++ {
+				names := make([]string, size)
+				for i := range names {
+					names[i] = fmt.Sprintf("bot-%d-%d", room, i)
+				}
+				play(ctx, opts, stats, client, names)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "loadtest: "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -0,0 +1,62 @@
+// cmd/loadtest/main_test.go
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"storytelling-backend/config"
+	"storytelling-backend/server"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A small load test against an in-process server plays its game through and
+// reports latencies for every operation it measured.
+func TestSmoke(t *testing.T) {
+	cfg := config.Default()
+	cfg.Metrics.Enabled = false
+	srv, err := server.New(&cfg, server.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err != nil {
+		t.Fatalf("server.New: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	opts := options{server: ts.URL, players: 2, roomSize: 2, games: 1, gameTimeout: 30 * time.Second}
+	stats := newRecorder()
+	run(context.Background(), &opts, stats, 1)
+	rep := stats.report(opts.players, 1, time.Second)
+
+	ops := make(map[string]OperationReport)
+	for _, op := range rep.Operations {
+		ops[op.Name] = op
+	}
+	for _, name := range []string{opCreateRoom, opJoinRoom, opConnect, opSubmit, opFanout, opGame} {
+		op, ok := ops[name]
+		switch {
+		case !ok:
+			t.Errorf("%s: not in the report", name)
+		case op.Count == 0 || op.Errors > 0:
+			t.Errorf("%s: %d measured with %d errors %v, want some without errors", name, op.Count, op.Errors, op.Reasons)
+		case op.P50 <= 0 || op.P90 < op.P50 || op.P99 < op.P90 || op.Max < op.P99:
+			t.Errorf("%s: percentiles p50 %v, p90 %v, p99 %v, max %v; want positive and increasing", name, op.P50, op.P90, op.P99, op.Max)
+		}
+	}
+	for _, name := range []string{opServerError, opDisconnect} {
+		if op, ok := ops[name]; ok {
+			t.Errorf("%s: %d recorded: %v", name, op.Count, op.Reasons)
+		}
+	}
+
+	var text strings.Builder
+	if err := rep.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "2 players in 1 rooms") || !strings.Contains(text.String(), opGame) {
+		t.Errorf("text report:\n%s", text.String())
+	}
+}
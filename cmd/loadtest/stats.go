@@ -0,0 +1,185 @@
+// cmd/loadtest/stats.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Operations measured, in the order they are reported.
+const (
+	opCreateRoom   = "create_room"   // POST /api/v1/rooms
+	opJoinRoom     = "join_room"     // POST /api/v1/rooms/{room_id}/players
+	opConnect      = "ws_connect"    // WebSocket dial and upgrade of /ws
+	opSubmit       = "submit"        // SUBMIT_LINE until the submitter sees its line broadcast
+	opFanout       = "fanout"        // SUBMIT_LINE until each player in the room sees the line
+	opFanoutSpread = "fanout_spread" // first to last player seeing the same line
+	opGame         = "game"          // START_GAME until every player has END_GAME
+	opServerError  = "server_error"  // ERROR messages from the server
+	opDisconnect   = "disconnect"    // WebSocket connections lost mid-game
+)
+
+var reportOrder = []string{opCreateRoom, opJoinRoom, opConnect, opSubmit, opFanout, opFanoutSpread, opGame, opServerError, opDisconnect}
+
+// maxReasonLength truncates error reasons, which may quote the server's response.
+const maxReasonLength = 80
+
+// recorder collects the latencies and errors of every operation. It is safe
+// for concurrent use.
+type recorder struct {
+	mu  sync.Mutex
+	ops map[string]*opStats
+}
+
+type opStats struct {
+	latencies []time.Duration
+	errors    int
+	reasons   map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{ops: make(map[string]*opStats)}
+}
+
+func (r *recorder) op(name string) *opStats {
+	s := r.ops[name]
+	if s == nil {
+		s = &opStats{reasons: make(map[string]int)}
+		r.ops[name] = s
+	}
+	return s
+}
+
+// observe records a successful operation that took d.
+func (r *recorder) observe(op string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.op(op)
+	s.latencies = append(s.latencies, d)
+}
+
+// fail records a failed operation.
+func (r *recorder) fail(op string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.op(op)
+	s.errors++
+	reason := err.Error()
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength] + "..."
+	}
+	s.reasons[reason]++
+}
+
+// Report is the outcome of a load test.
+type Report struct {
+	Players    int               `json:"players"`
+	Rooms      int               `json:"rooms"`
+	Elapsed    float64           `json:"elapsed_seconds"`
+	Operations []OperationReport `json:"operations"`
+}
+
+// OperationReport summarises one operation. Latencies are in milliseconds
+// and only cover the operations that succeeded.
+type OperationReport struct {
+	Name      string         `json:"name"`
+	Count     int            `json:"count"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"error_rate"`
+	P50       float64        `json:"p50_ms"`
+	P90       float64        `json:"p90_ms"`
+	P99       float64        `json:"p99_ms"`
+	Max       float64        `json:"max_ms"`
+	Reasons   map[string]int `json:"error_reasons,omitempty"`
+}
+
+// report summarises what has been recorded so far.
+func (r *recorder) report(players, rooms int, elapsed time.Duration) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := Report{Players: players, Rooms: rooms, Elapsed: elapsed.Seconds()}
+	for _, name := range reportOrder {
+		s := r.ops[name]
+		if s == nil {
+			continue
+		}
+		latencies := append([]time.Duration{}, s.latencies...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		count := len(latencies) + s.errors
+		op := OperationReport{
+			Name:   name,
+			Count:  count,
+			Errors: s.errors,
+			P50:    millis(percentile(latencies, 50)),
+			P90:    millis(percentile(latencies, 90)),
+			P99:    millis(percentile(latencies, 99)),
+			Max:    millis(percentile(latencies, 100)),
+		}
+		if count > 0 {
+			op.ErrorRate = float64(s.errors) / float64(count)
+		}
+		if len(s.reasons) > 0 {
+			op.Reasons = s.reasons
+		}
+		rep.Operations = append(rep.Operations, op)
+	}
+	return rep
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// WriteText writes the report as a table followed by the error reasons.
+func (rep Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%d players in %d rooms, %.1fs\n\n", rep.Players, rep.Rooms, rep.Elapsed)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tCOUNT\tERRORS\tERROR%\tP50\tP90\tP99\tMAX\t")
+	for _, op := range rep.Operations {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%s\t%s\t%s\t%s\t\n", op.Name, op.Count, op.Errors, op.ErrorRate*100,
+			formatMillis(op.P50), formatMillis(op.P90), formatMillis(op.P99), formatMillis(op.Max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, op := range rep.Operations {
+		if len(op.Reasons) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s errors:\n", op.Name)
+		reasons := make([]string, 0, len(op.Reasons))
+		for reason := range op.Reasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool { return op.Reasons[reasons[i]] > op.Reasons[reasons[j]] })
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "  %6d  %s\n", op.Reasons[reason], strings.TrimSpace(reason))
+		}
+	}
+	return nil
+}
+
+func formatMillis(ms float64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.Duration(ms * float64(time.Millisecond)).Round(10 * time.Microsecond).String()
+}
@@ -2,30 +2,80 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"storytelling-backend/config"
-	"storytelling-backend/internal/game"
-
-	"github.com/gorilla/mux"
+	"storytelling-backend/internal/logging"
+	"storytelling-backend/server"
+	"strconv"
+	"syscall"
 )
 
 func main() {
-	// Load configuration
-	config.LoadConfig()
+	// Load configuration; an invalid setting stops the server before it starts.
+	// The logger is configured by it, so until then errors go to the standard logger.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger := logging.New(cfg.Log, os.Stderr)
+	// Packages that log through the default logger follow the configuration too.
+	slog.SetDefault(logger)
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		logger.Info("Loaded configuration", "file", path)
+	}
 
-	// Create a new router
-	router := mux.NewRouter()
+	srv, err := server.New(cfg, server.Options{Logger: logger})
+	if err != nil {
+		logger.Error("Error creating server", "error", err)
+		os.Exit(1)
+	}
 
-	// Setup routes
-	SetupRoutes(router)
-	game.RoomManagerInstance = game.NewRoomManager()
 	// Start the server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	logger.Info("Server is running", "port", cfg.Server.Port)
+	// Accept HTTP/2 without TLS as well so gRPC clients can connect directly.
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	// There is no WriteTimeout: WebSocket, SSE and RPC streams stay open.
+	httpServer := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           srv.Handler(),
+		Protocols:         protocols,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	// Shutdown tells clients the server is restarting before it waits for requests.
+	httpServer.RegisterOnShutdown(srv.Drain)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error serving HTTP", "error", err)
+			os.Exit(1)
+		}
+	}()
+	<-ctx.Done()
+	stop()
+
+	// Stop accepting connections, let requests finish and save the rooms
+	// that are still being played, all within the shutdown timeout.
+	logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Error draining HTTP connections", "error", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Error shutting down", "error", err)
 	}
-	log.Printf("Server is running on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	logger.Info("Server stopped")
 }
@@ -0,0 +1,96 @@
+// cmd/storyctl/client.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client calls a running server's admin API.
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func newClient(base, token string) *client {
+	return &client{
+		base:  strings.TrimRight(base, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request to path and decodes the JSON response into out, if
+// out is not nil. Error responses become errors carrying the server's reason.
+func (c *client) do(method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, reason(resp))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *client) get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+// running reports whether a server answers at the client's base URL.
+func (c *client) running() bool {
+	probe := &http.Client{Timeout: 2 * time.Second}
+	resp, err := probe.Get(c.base + "/healthz")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// reason extracts why the server refused a request: the detail of a problem
+// response, or the plain-text body of the legacy routes.
+func reason(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(data, &problem) == nil && problem.Detail != "" {
+			return problem.Detail
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// roomPath returns the admin path of a room, escaping its ID.
+func roomPath(roomID string, rest ...string) string {
+	path := "/admin/rooms/" + url.PathEscape(roomID)
+	for _, part := range rest {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
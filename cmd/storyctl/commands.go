@@ -0,0 +1,450 @@
+// cmd/storyctl/commands.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func roomsCmd(e *env, args []string) error {
+	fs := e.flags()
+	status := fs.String("status", "", "only list rooms with this `status`, such as in_progress")
+	if err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	var rooms []models.RoomDetails
+	if err := e.client.get("/admin/rooms", &rooms); err != nil {
+		return err
+	}
+	if *status != "" {
+		filtered := rooms[:0]
+		for _, room := range rooms {
+			if string(room.Status) == *status {
+				filtered = append(filtered, room)
+			}
+		}
+		rooms = filtered
+	}
+	if e.out.json {
+		return e.out.JSON(rooms)
+	}
+	rows := make([][]string, 0, len(rooms))
+	for _, room := range rooms {
+		rows = append(rows, []string{
+			room.ID, string(room.Status), room.Host,
+			fmt.Sprintf("%d/%d", connected(room.Players), len(room.Players)),
+			strconv.Itoa(len(room.Spectators)),
+			strconv.Itoa(room.Lines),
+			yesNo(room.Private),
+		})
+	}
+	return e.out.Table([]string{"ROOM", "STATUS", "HOST", "CONNECTED", "SPECTATORS", "LINES", "PRIVATE"}, rows)
+}
+
+func roomCmd(e *env, args []string) error {
+	fs := e.flags()
+	if err := e.parse(fs, args, 1); err != nil {
+		return err
+	}
+	var room models.RoomDetails
+	if err := e.client.get(roomPath(fs.Arg(0)), &room); err != nil {
+		return err
+	}
+	if e.out.json {
+		return e.out.JSON(room)
+	}
+	turn := "-"
+	if (room.Status == models.StatusInProgress || room.Status == models.StatusPaused) && room.CurrentTurn < len(room.TurnOrder) {
+		turn = fmt.Sprintf("%d of %d (%s)", room.CurrentTurn+1, len(room.TurnOrder), room.TurnOrder[room.CurrentTurn])
+	}
+	if err := e.out.Fields(
+		"Room", room.ID,
+		"Story", orDash(room.StoryName),
+		"Status", string(room.Status),
+		"Host", room.Host,
+		"Private", yesNo(room.Private),
+		"Turn", turn,
+		"Lines", strconv.Itoa(room.Lines),
+		"Join requests", strconv.Itoa(room.JoinRequests),
+	); err != nil {
+		return err
+	}
+	e.out.Printf("\n")
+	var rows [][]string
+	for _, group := range []struct {
+		role  string
+		conns []models.ConnectionDetails
+	}{{"player", room.Players}, {"spectator", room.Spectators}} {
+		for _, c := range group.conns {
+			role := group.role
+			if role == "player" && c.Name == room.Host {
+				role = "host"
+			}
+			rows = append(rows, []string{c.Name, role, yesNo(c.Connected), orDash(c.Transport), orDash(c.ConnectionID), orDash(c.UserID)})
+		}
+	}
+	if len(rows) == 0 {
+		e.out.Printf("Nobody is in the room\n")
+		return nil
+	}
+	return e.out.Table([]string{"NAME", "ROLE", "CONNECTED", "TRANSPORT", "CONNECTION", "ACCOUNT"}, rows)
+}
+
+func storyCmd(e *env, args []string) error {
+	fs := e.flags()
+	if err := e.parse(fs, args, 1); err != nil {
+		return err
+	}
+	var story models.StoryRecord
+	if err := e.client.get("/api/v1/rooms/"+url.PathEscape(fs.Arg(0))+"/record", &story); err != nil {
+		return err
+	}
+	if e.out.json {
+		return e.out.JSON(story)
+	}
+	if err := e.out.Fields(
+		"Room", story.RoomID,
+		"Title", orDash(story.Title),
+		"Status", string(story.Status),
+		"Host", story.Host,
+		"Players", strings.Join(story.Players, ", "),
+		"Completed", story.CompletedAt.Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+	e.out.Printf("\n")
+	width := len(strconv.Itoa(len(story.Lines)))
+	for _, line := range story.Lines {
+		e.out.Printf("%*d. %s: %s\n", width, line.ID, line.Author, line.Text)
+	}
+	if len(story.Awards) > 0 {
+		e.out.Printf("\nAwards:\n")
+		for _, award := range story.Awards {
+			e.out.Printf("  %s: %s (%d)\n", award.Award, award.Player, award.Count)
+		}
+	}
+	return nil
+}
+
+// exportResult is the JSON summary of an export to a directory.
+type exportResult struct {
+	Dir     string `json:"dir"`
+	Stories int    `json:"stories"`
+}
+
+func exportCmd(e *env, args []string) error {
+	fs := e.flags()
+	dir := fs.String("dir", "", "write each story to `DIR`/<room_id>.json instead of JSON Lines on standard output")
+	if err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	var stories []json.RawMessage
+	if err := e.client.get("/admin/stories", &stories); err != nil {
+		return err
+	}
+	if *dir == "" {
+		// JSON Lines whatever the output format, so exports can be streamed into other tools.
+		for _, story := range stories {
+			if _, err := fmt.Fprintf(e.out.w, "%s\n", compact(story)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	for _, story := range stories {
+		var id struct {
+			RoomID string `json:"room_id"`
+		}
+		if err := json.Unmarshal(story, &id); err != nil {
+			return err
+		}
+		// Room IDs are letters and digits, but the file name must not escape dir whatever they are.
+		name := filepath.Join(*dir, filepath.Base(filepath.Clean("/"+id.RoomID))+".json")
+		if err := os.WriteFile(name, append(story, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	if e.out.json {
+		return e.out.JSON(exportResult{Dir: *dir, Stories: len(stories)})
+	}
+	e.out.Printf("Exported %d stories to %s\n", len(stories), *dir)
+	return nil
+}
+
+// purgeResult is one room removed, or to be removed, by purge.
+type purgeResult struct {
+	RoomID  string            `json:"room_id"`
+	Status  models.RoomStatus `json:"status"`
+	SavedAt *time.Time        `json:"saved_at,omitempty"`
+	Purged  bool              `json:"purged"`
+}
+
+func purgeCmd(e *env, args []string) error {
+	fs := e.flags()
+	dryRun := fs.Bool("dry-run", false, "list what would be purged without purging it")
+	snapshots := fs.Bool("snapshots", false, "purge the snapshot file instead of the running server; stop the server first")
+	olderThan := fs.Duration("older-than", 24*time.Hour, "with -snapshots, purge rooms saved longer ago than `DURATION`")
+	force := fs.Bool("force", false, "with -snapshots, purge even though the server is running")
+	if err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	var results []purgeResult
+	var err error
+	if *snapshots {
+		if !*dryRun {
+			if err := e.checkStopped(*force); err != nil {
+				return err
+			}
+		}
+		results, err = purgeSnapshots(e.snapshotFile, *olderThan, *dryRun)
+	} else {
+		results, err = purgeFinished(e.client, *dryRun)
+	}
+	if err != nil {
+		return err
+	}
+	if e.out.json {
+		return e.out.JSON(results)
+	}
+	if len(results) == 0 {
+		e.out.Printf("Nothing to purge\n")
+		return nil
+	}
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		saved := "-"
+		if r.SavedAt != nil {
+			saved = r.SavedAt.Format(time.RFC3339)
+		}
+		action := "purged"
+		if !r.Purged {
+			action = "would purge"
+		}
+		rows = append(rows, []string{r.RoomID, string(r.Status), saved, action})
+	}
+	return e.out.Table([]string{"ROOM", "STATUS", "SAVED", "ACTION"}, rows)
+}
+
+// purgeFinished deletes the running server's completed and aborted rooms.
+func purgeFinished(c *client, dryRun bool) ([]purgeResult, error) {
+	var rooms []models.RoomDetails
+	if err := c.get("/admin/rooms", &rooms); err != nil {
+		return nil, err
+	}
+	var results []purgeResult
+	for _, room := range rooms {
+		if !room.Status.IsFinished() {
+			continue
+		}
+		if !dryRun {
+			if err := c.do(http.MethodDelete, roomPath(room.ID), nil, nil); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, purgeResult{RoomID: room.ID, Status: room.Status, Purged: !dryRun})
+	}
+	return results, nil
+}
+
+// purgeSnapshots drops the rooms saved in the snapshot file more than
+// olderThan ago, whose players are unlikely to come back.
+func purgeSnapshots(path string, olderThan time.Duration, dryRun bool) ([]purgeResult, error) {
+	if path == "" {
+		return nil, errors.New("no snapshot file configured; set -snapshot-file")
+	}
+	rooms, _, err := storage.ReadSnapshotFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var results []purgeResult
+	kept := rooms[:0]
+	for _, room := range rooms {
+		if !room.SavedAt.Before(cutoff) {
+			kept = append(kept, room)
+			continue
+		}
+		saved := room.SavedAt
+		results = append(results, purgeResult{RoomID: room.ID, Status: room.Status, SavedAt: &saved, Purged: !dryRun})
+	}
+	if dryRun || len(results) == 0 {
+		return results, nil
+	}
+	return results, storage.WriteSnapshotFile(path, kept)
+}
+
+// checkStopped refuses to rewrite the snapshot file while the server answers
+// at -server: the server writes the file itself on shutdown, undoing the
+// change. With force it only warns.
+func (e *env) checkStopped(force bool) error {
+	if !e.client.running() {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("the server at %s is running and would overwrite %s when it stops; stop it first, or pass -force", e.client.base, e.snapshotFile)
+	}
+	fmt.Fprintf(e.stderr, "storyctl: warning: the server at %s is running and may overwrite %s when it stops\n", e.client.base, e.snapshotFile)
+	return nil
+}
+
+// migrateResult reports the migration of the snapshot file.
+type migrateResult struct {
+	File     string `json:"file"`
+	From     int    `json:"from_version"`
+	To       int    `json:"to_version"`
+	Rooms    int    `json:"rooms"`
+	Migrated bool   `json:"migrated"`
+}
+
+func migrateCmd(e *env, args []string) error {
+	fs := e.flags()
+	dryRun := fs.Bool("dry-run", false, "report the file's version without rewriting it")
+	force := fs.Bool("force", false, "migrate even though the server is running")
+	if err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if e.snapshotFile == "" {
+		return errors.New("no snapshot file configured; set -snapshot-file")
+	}
+	if !*dryRun {
+		if err := e.checkStopped(*force); err != nil {
+			return err
+		}
+	}
+	rooms, version, err := storage.ReadSnapshotFile(e.snapshotFile)
+	if err != nil {
+		return err
+	}
+	result := migrateResult{File: e.snapshotFile, From: version, To: storage.SnapshotFileVersion, Rooms: len(rooms)}
+	if version < storage.SnapshotFileVersion && !*dryRun {
+		if err := storage.WriteSnapshotFile(e.snapshotFile, rooms); err != nil {
+			return err
+		}
+		result.Migrated = true
+	}
+	if e.out.json {
+		return e.out.JSON(result)
+	}
+	switch {
+	case version == storage.SnapshotFileVersion:
+		e.out.Printf("%s is already at version %d (%d rooms)\n", result.File, version, result.Rooms)
+	case result.Migrated:
+		e.out.Printf("Migrated %s from version %d to %d (%d rooms)\n", result.File, result.From, result.To, result.Rooms)
+	default:
+		e.out.Printf("%s is at version %d and would be migrated to %d (%d rooms)\n", result.File, result.From, result.To, result.Rooms)
+	}
+	return nil
+}
+
+// loggedEvent is an entry of a room's event log, with its data left encoded
+// until replay knows its type.
+type loggedEvent struct {
+	Seq    int               `json:"seq"`
+	Type   string            `json:"type"`
+	Status models.RoomStatus `json:"status"`
+	At     time.Time         `json:"at"`
+	Data   json.RawMessage   `json:"data,omitempty"`
+}
+
+func replayCmd(e *env, args []string) error {
+	fs := e.flags()
+	speed := fs.Float64("speed", 0, "replay at `N` times the original pace; 0 prints everything at once")
+	if err := e.parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *speed < 0 {
+		return errors.New("-speed must not be negative")
+	}
+	var events []loggedEvent
+	if err := e.client.get(roomPath(fs.Arg(0), "events"), &events); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(e.out.w)
+	for i, ev := range events {
+		if i > 0 && *speed > 0 {
+			time.Sleep(time.Duration(float64(ev.At.Sub(events[i-1].At)) / *speed))
+		}
+		if e.out.json {
+			// One event per line, so the replay can be piped as it happens.
+			if err := enc.Encode(ev); err != nil {
+				return err
+			}
+			continue
+		}
+		offset := ev.At.Sub(events[0].At).Round(time.Millisecond)
+		line := fmt.Sprintf("%5d  +%-10s  %-15s  %-11s  %s", ev.Seq, offset, ev.Type, ev.Status, describe(ev))
+		e.out.Printf("%s\n", strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// describe summarises the data of an event for the table output.
+func describe(ev loggedEvent) string {
+	switch ev.Type {
+	case models.EventLineAdded:
+		var line models.StoryLine
+		if json.Unmarshal(ev.Data, &line) == nil {
+			return fmt.Sprintf("%s: %s", line.Author, line.Text)
+		}
+	case models.EventTurnChanged:
+		var turn models.TurnChange
+		if json.Unmarshal(ev.Data, &turn) == nil {
+			return fmt.Sprintf("%s's turn (%d lines so far)", turn.Player, turn.LineCount)
+		}
+	case models.EventStoryCompleted:
+		var story models.StoryRecord
+		if json.Unmarshal(ev.Data, &story) == nil {
+			return fmt.Sprintf("%d lines by %s", len(story.Lines), strings.Join(story.Players, ", "))
+		}
+	}
+	return ""
+}
+
+func connected(conns []models.ConnectionDetails) int {
+	n := 0
+	for _, c := range conns {
+		if c.Connected {
+			n++
+		}
+	}
+	return n
+}
+
+func compact(data json.RawMessage) []byte {
+	var b bytes.Buffer
+	if err := json.Compact(&b, data); err != nil {
+		return data
+	}
+	return b.Bytes()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+// cmd/storyctl/main.go
+
+// Storyctl operates a storytelling server. It inspects and manages rooms
+// and exports stories through the server's admin API, and maintains the room
+// snapshot file directly.
+//
+//	storyctl [flags] <command> [command flags] [arguments]
+//
+// It reads the server's configuration (CONFIG_FILE, .env and the
+// environment) for the server's port, ADMIN_TOKEN and SNAPSHOT_FILE, so run
+// from the server's directory it needs no flags.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"storytelling-backend/config"
+	"strconv"
+)
+
+// errUsage reports a command line that does not parse; the usage has
+// already been printed.
+var errUsage = errors.New("usage")
+
+// env is what commands run with.
+type env struct {
+	client       *client
+	out          printer
+	stderr       io.Writer
+	snapshotFile string
+	// cmd is the command being run.
+	cmd command
+}
+
+// command is a storyctl subcommand.
+type command struct {
+	name    string
+	args    string // synopsis of the command's flags and arguments
+	summary string
+	run     func(e *env, args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"rooms", "[-status STATUS]", "List every room with its players and connections", roomsCmd},
+		{"room", "ROOM_ID", "Show a room's state, players and spectators", roomCmd},
+		{"story", "ROOM_ID", "Print a finished story with its authors and awards", storyCmd},
+		{"export", "[-dir DIR]", "Export every stored story as JSON Lines, or one file per story", exportCmd},
+		{"purge", "[-dry-run] [-snapshots [-older-than DURATION] [-force]]", "Delete finished rooms, or snapshots too old to resume", purgeCmd},
+		{"migrate", "[-dry-run] [-force]", "Rewrite the snapshot file in the current format", migrateCmd},
+		{"replay", "[-speed N] ROOM_ID", "Print a room's event log, optionally at its original pace", replayCmd},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line in args and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, "storyctl:", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("storyctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	defaultServer := os.Getenv("STORYCTL_SERVER")
+	if defaultServer == "" {
+		defaultServer = "http://localhost:" + strconv.Itoa(cfg.Server.Port)
+	}
+	server := fs.String("server", defaultServer, "`URL` of the server; also STORYCTL_SERVER")
+	// The token's default is not shown in the usage, which may be printed to a shared terminal.
+	token := fs.String("token", "", "admin `token`; defaults to the server's ADMIN_TOKEN")
+	snapshotFile := fs.String("snapshot-file", cfg.Storage.SnapshotFile, "room snapshot `file` for purge -snapshots and migrate")
+	output := fs.String("o", "table", "output `format`: table or json")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "storyctl: unknown output format %q\n", *output)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *token == "" {
+		*token = cfg.Auth.AdminToken
+	}
+	e := &env{
+		client:       newClient(*server, *token),
+		out:          printer{w: stdout, json: *output == "json"},
+		stderr:       stderr,
+		snapshotFile: *snapshotFile,
+	}
+	name := fs.Arg(0)
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			e.cmd = cmd
+			if err := cmd.run(e, fs.Args()[1:]); err != nil {
+				if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+					fmt.Fprintln(stderr, "storyctl:", err)
+				}
+				return exitStatus(err)
+			}
+			return 0
+		}
+	}
+	fmt.Fprintf(stderr, "storyctl: unknown command %q\n", name)
+	fs.Usage()
+	return 2
+}
+
+func exitStatus(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "Usage: storyctl [flags] <command> [command flags] [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+}
+
+// flags returns the flag set of the command being run.
+func (e *env) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("storyctl "+e.cmd.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: storyctl %s %s\n%s.\n", e.cmd.name, e.cmd.args, e.cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses a command's flags and checks it was given want arguments.
+func (e *env) parse(fs *flag.FlagSet, args []string, want int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != want {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
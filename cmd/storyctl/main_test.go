@@ -0,0 +1,273 @@
+// cmd/storyctl/main_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "admin-secret"
+
+// newAdminAPI serves the admin endpoints storyctl uses with two rooms: TAPES
+// in progress and DONE completed. It returns the rooms deleted through it.
+func newAdminAPI(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	rooms := []models.RoomDetails{
+		{
+			ID: "TAPES", Host: "Alice", Status: models.StatusInProgress, TurnOrder: []string{"Alice", "Bob"}, Lines: 1,
+			Players: []models.ConnectionDetails{
+				{Name: "Alice", Connected: true, Transport: "websocket", ConnectionID: "c3c92097"},
+				{Name: "Bob", UserID: "usr-1"},
+			},
+			Spectators: []models.ConnectionDetails{{Name: "Carol", Connected: true, Transport: "sse"}},
+		},
+		{ID: "DONE", Host: "Dana", Status: models.StatusCompleted, TurnOrder: []string{"Dana"}, Lines: 2},
+	}
+	lines := []models.StoryLine{
+		{ID: 1, Author: "Dana", Text: "Once upon a time.", WrittenAt: start},
+		{ID: 2, Author: "Dana", Text: "The end.", WrittenAt: start.Add(time.Minute)},
+	}
+	record := models.StoryRecord{
+		RoomID: "DONE", Title: "The Lighthouse", Host: "Dana", Status: models.StatusCompleted,
+		Players: []string{"Dana"}, Lines: lines, CompletedAt: start.Add(time.Minute),
+		Awards: []models.Award{{Award: "most_prolific_writer", Player: "Dana", Count: 2}},
+	}
+	events := []models.LoggedEvent{
+		{Seq: 1, Type: models.EventLineAdded, Status: models.StatusInProgress, At: start, Data: lines[0]},
+		{Seq: 2, Type: models.EventTurnChanged, Status: models.StatusInProgress, At: start.Add(1500 * time.Millisecond),
+			Data: models.TurnChange{Player: "Dana", Turn: 1, LineCount: 1}},
+	}
+
+	var deleted []string
+	mux := http.NewServeMux()
+	reply := func(v interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(v)
+		}
+	}
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /admin/rooms", reply(rooms))
+	mux.HandleFunc("GET /admin/rooms/TAPES", reply(rooms[0]))
+	mux.HandleFunc("GET /admin/rooms/{room_id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Room not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /admin/rooms/DONE/events", reply(events))
+	mux.HandleFunc("DELETE /admin/rooms/{room_id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.PathValue("room_id"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /admin/stories", reply([]models.StoryRecord{record}))
+	mux.HandleFunc("GET /api/v1/rooms/DONE/record", reply(record))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" && r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "admin token required", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &deleted
+}
+
+// stoppedServer returns the URL of a server that is no longer running.
+func stoppedServer() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func runStoryctl(server, snapshotFile string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	args = append([]string{"-server", server, "-token", testToken, "-snapshot-file", snapshotFile}, args...)
+	code = run(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestAdminCommands(t *testing.T) {
+	srv, deleted := newAdminAPI(t)
+	tests := []struct {
+		name        string
+		args        []string
+		want        []string // in the output, in order
+		wantJSON    bool     // the output is one JSON value
+		wantLines   bool     // the output is JSON Lines
+		wantDeleted []string
+	}{
+		{name: "rooms", args: []string{"rooms"},
+			want: []string{"ROOM", "STATUS", "HOST", "CONNECTED", "TAPES", "in_progress", "Alice", "1/2", "DONE", "completed"}},
+		{name: "rooms filtered", args: []string{"rooms", "-status", "completed"}, want: []string{"DONE"}},
+		{name: "rooms json", args: []string{"-o", "json", "rooms"}, want: []string{`"id": "TAPES"`, `"id": "DONE"`}, wantJSON: true},
+		{name: "room", args: []string{"room", "TAPES"},
+			want: []string{"Room:", "TAPES", "Turn:", "1 of 2 (Alice)", "Alice", "host", "websocket", "Bob", "player", "usr-1", "Carol", "spectator", "sse"}},
+		{name: "room json", args: []string{"-o", "json", "room", "TAPES"}, want: []string{`"id": "TAPES"`, `"connection_id": "c3c92097"`}, wantJSON: true},
+		{name: "story", args: []string{"story", "DONE"},
+			want: []string{"Title:", "The Lighthouse", "1. Dana: Once upon a time.", "2. Dana: The end.", "Awards:", "most_prolific_writer: Dana (2)"}},
+		{name: "story json", args: []string{"-o", "json", "story", "DONE"}, want: []string{`"room_id": "DONE"`, `"text": "The end."`}, wantJSON: true},
+		{name: "export", args: []string{"export"}, want: []string{`{"room_id":"DONE"`}, wantLines: true},
+		{name: "export json", args: []string{"-o", "json", "export"}, want: []string{`{"room_id":"DONE"`}, wantLines: true},
+		{name: "replay", args: []string{"replay", "DONE"},
+			want: []string{"1", "+0s", models.EventLineAdded, "Dana: Once upon a time.", "2", "+1.5s", models.EventTurnChanged, "Dana's turn (1 lines so far)"}},
+		{name: "replay json", args: []string{"-o", "json", "replay", "DONE"}, want: []string{`"seq":1`, `"seq":2`}, wantLines: true},
+		{name: "purge dry run", args: []string{"purge", "-dry-run"}, want: []string{"ROOM", "ACTION", "DONE", "completed", "would purge"}},
+		{name: "purge", args: []string{"purge"}, want: []string{"DONE", "purged"}, wantDeleted: []string{"DONE"}},
+		{name: "purge json", args: []string{"-o", "json", "purge"}, want: []string{`"room_id": "DONE"`, `"purged": true`}, wantJSON: true, wantDeleted: []string{"DONE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*deleted = nil
+			code, stdout, stderr := runStoryctl(srv.URL, "", tt.args...)
+			if code != 0 {
+				t.Fatalf("exit status %d: %s", code, stderr)
+			}
+			rest := stdout
+			for _, want := range tt.want {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("output has no %q after the earlier fields:\n%s", want, stdout)
+				}
+				rest = rest[i+len(want):]
+			}
+			if tt.wantJSON && !json.Valid([]byte(stdout)) {
+				t.Errorf("output is not JSON:\n%s", stdout)
+			}
+			if tt.wantLines {
+				for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+					if !json.Valid([]byte(line)) {
+						t.Errorf("line is not JSON: %s", line)
+					}
+				}
+			}
+			if strings.Join(*deleted, ",") != strings.Join(tt.wantDeleted, ",") {
+				t.Errorf("deleted rooms %v, want %v", *deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestAdminCommandErrors(t *testing.T) {
+	srv, _ := newAdminAPI(t)
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"unknown command", []string{"rooms-list"}, 2, `unknown command "rooms-list"`},
+		{"missing argument", []string{"room"}, 2, "Usage: storyctl room ROOM_ID"},
+		{"unknown room", []string{"room", "NOPE"}, 1, "404 Not Found: Room not found"},
+		{"negative speed", []string{"replay", "-speed", "-1", "DONE"}, 1, "-speed must not be negative"},
+		{"unknown output", []string{"-o", "yaml", "rooms"}, 2, `unknown output format "yaml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runStoryctl(srv.URL, "", tt.args...)
+			if code != tt.code || !strings.Contains(stderr, tt.want) {
+				t.Errorf("exit status %d, stderr %q; want %d and %q", code, stderr, tt.code, tt.want)
+			}
+		})
+	}
+
+	var out, errOut bytes.Buffer
+	if code := run([]string{"-server", srv.URL, "-token", "wrong", "rooms"}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "admin token required") {
+		t.Errorf("wrong token: exit status %d, stderr %q", code, errOut.String())
+	}
+}
+
+// writeVersion0 writes rooms in the unversioned format of older servers.
+func writeVersion0(t *testing.T, path string, rooms []*models.RoomSnapshot) {
+	t.Helper()
+	data, err := json.Marshal(rooms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotCommands(t *testing.T) {
+	running, _ := newAdminAPI(t)
+	stopped := stoppedServer()
+	rooms := func() []*models.RoomSnapshot {
+		return []*models.RoomSnapshot{
+			{ID: "NEWER", Host: "Alice", Status: models.StatusInProgress, SavedAt: time.Now().Add(-time.Hour)},
+			{ID: "OLDER", Host: "Bob", Status: models.StatusWaiting, SavedAt: time.Now().Add(-48 * time.Hour)},
+		}
+	}
+	tests := []struct {
+		name        string
+		server      string
+		version0    bool
+		args        []string
+		code        int
+		want        string // in the output
+		wantStderr  string
+		wantRooms   []string // left in the file
+		wantVersion int
+	}{
+		{name: "purge", server: stopped, args: []string{"purge", "-snapshots", "-older-than", "24h"},
+			want: "OLDER", wantRooms: []string{"NEWER"}, wantVersion: 1},
+		{name: "purge json", server: stopped, args: []string{"-o", "json", "purge", "-snapshots"},
+			want: `"purged": true`, wantRooms: []string{"NEWER"}, wantVersion: 1},
+		{name: "purge dry run while running", server: running.URL, args: []string{"-o", "json", "purge", "-snapshots", "-dry-run"},
+			want: `"purged": false`, wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+		{name: "purge while running", server: running.URL, args: []string{"purge", "-snapshots"},
+			code: 1, wantStderr: "is running", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+		{name: "purge forced while running", server: running.URL, args: []string{"purge", "-snapshots", "-force"},
+			want: "purged", wantStderr: "warning", wantRooms: []string{"NEWER"}, wantVersion: 1},
+		{name: "migrate", server: stopped, version0: true, args: []string{"migrate"},
+			want: "from version 0 to 1 (2 rooms)", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+		{name: "migrate json", server: stopped, version0: true, args: []string{"-o", "json", "migrate"},
+			want: `"migrated": true`, wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+		{name: "migrate current", server: stopped, args: []string{"migrate"},
+			want: "already at version 1", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+		{name: "migrate dry run while running", server: running.URL, version0: true, args: []string{"migrate", "-dry-run"},
+			want: "would be migrated", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 0},
+		{name: "migrate while running", server: running.URL, version0: true, args: []string{"migrate"},
+			code: 1, wantStderr: "is running", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 0},
+		{name: "migrate forced while running", server: running.URL, version0: true, args: []string{"migrate", "-force"},
+			want: "Migrated", wantStderr: "warning", wantRooms: []string{"NEWER", "OLDER"}, wantVersion: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "room-snapshots.json")
+			if tt.version0 {
+				writeVersion0(t, path, rooms())
+			} else if err := storage.WriteSnapshotFile(path, rooms()); err != nil {
+				t.Fatal(err)
+			}
+			code, stdout, stderr := runStoryctl(tt.server, path, tt.args...)
+			if code != tt.code {
+				t.Fatalf("exit status %d, want %d: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("output has no %q:\n%s", tt.want, stdout)
+			}
+			if tt.wantStderr != "" && !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr has no %q: %s", tt.wantStderr, stderr)
+			}
+			saved, version, err := storage.ReadSnapshotFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, room := range saved {
+				ids = append(ids, room.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantRooms, ",") || version != tt.wantVersion {
+				t.Errorf("file has rooms %v at version %d, want %v at version %d", ids, version, tt.wantRooms, tt.wantVersion)
+			}
+		})
+	}
+}
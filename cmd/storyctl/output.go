@@ -0,0 +1,49 @@
+// cmd/storyctl/output.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer writes command results as aligned tables or as JSON.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// JSON writes v as indented JSON.
+func (p printer) JSON(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Table writes rows under header with aligned columns.
+func (p printer) Table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// Fields writes label: value pairs with the values aligned.
+func (p printer) Fields(pairs ...string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 1, ' ', 0)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fmt.Fprintf(tw, "%s:\t%s\n", pairs[i], pairs[i+1])
+	}
+	return tw.Flush()
+}
+
+// Printf writes a line of text; JSON output has no room for it.
+func (p printer) Printf(format string, args ...interface{}) {
+	if !p.json {
+		fmt.Fprintf(p.w, format, args...)
+	}
+}
@@ -2,13 +2,425 @@
 package config
 
 import (
-	"log"
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"storytelling-backend/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
-func LoadConfig() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found. Loading default configuration.")
+// Config is the server's complete configuration.
+//
+// Values are layered, each source overriding the ones before it:
+//  1. the defaults from Default,
+//  2. the YAML or TOML file named by CONFIG_FILE, if any,
+//  3. variables in a .env file in the working directory,
+//  4. real environment variables.
+//
+// The env tags give each setting's variable name.
+type Config struct {
+	Server   ServerConfig     `yaml:"server" toml:"server"`
+	CORS     CORSConfig       `yaml:"cors" toml:"cors"`
+	Storage  StorageConfig    `yaml:"storage" toml:"storage"`
+	Auth     AuthConfig       `yaml:"auth" toml:"auth"`
+	Game     GameConfig       `yaml:"game" toml:"game"`
+	Webhooks WebhookConfig    `yaml:"webhooks" toml:"webhooks"`
+	Limits   ratelimit.Config `yaml:"limits" toml:"limits"`
+	Metrics  MetricsConfig    `yaml:"metrics" toml:"metrics"`
+	Log      LogConfig        `yaml:"log" toml:"log"`
+	Tracing  TracingConfig    `yaml:"tracing" toml:"tracing"`
+}
+
+type ServerConfig struct {
+	Port              int           `yaml:"port" toml:"port" env:"PORT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"READ_HEADER_TIMEOUT"`
+	// ReadTimeout bounds reading a whole request; zero means no limit.
+	ReadTimeout time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"READ_TIMEOUT"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds how long a shutdown waits for requests to finish
+	// and rooms to be saved.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// CORSConfig is the origin policy for browsers, applied to HTTP requests and
+// WebSocket upgrades alike.
+type CORSConfig struct {
+	// AllowedOrigins lists the browser origins allowed to call the API, such
+	// as https://example.com. https://*.example.com allows any subdomain and
+	// "*" allows any origin. Empty, the default, allows same-origin pages only.
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// AllowCredentials lets browsers send cookies and read responses to
+	// credentialed requests. It cannot be combined with "*".
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	// MaxAge is how long browsers may cache a preflight response; zero omits the header.
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE"`
+	// Routes overrides the methods and headers allowed for some paths. It can
+	// only be set in the config file.
+	Routes []CORSRoute `yaml:"routes" toml:"routes"`
+}
+
+// CORSRoute overrides the CORS methods and headers for routes whose path
+// starts with PathPrefix. Empty lists keep the defaults: the methods the
+// route serves and CORSConfig.AllowedHeaders.
+type CORSRoute struct {
+	PathPrefix string   `yaml:"path_prefix" toml:"path_prefix"`
+	Methods    []string `yaml:"methods" toml:"methods"`
+	Headers    []string `yaml:"headers" toml:"headers"`
+}
+
+type StorageConfig struct {
+	// Backend selects where rooms, stories and accounts are kept. Only "memory" is available.
+	Backend string `yaml:"backend" toml:"backend" env:"STORAGE_BACKEND"`
+	// SnapshotFile is where unfinished rooms are saved on shutdown and
+	// restored from on startup; empty keeps them in memory only.
+	SnapshotFile string `yaml:"snapshot_file" toml:"snapshot_file" env:"SNAPSHOT_FILE"`
+	// DataFile is where accounts, sessions, finished stories, the gallery
+	// and webhook subscriptions are kept across restarts; empty keeps them in
+	// memory only.
+	DataFile string `yaml:"data_file" toml:"data_file" env:"DATA_FILE"`
+}
+
+type AuthConfig struct {
+	// AdminToken is the bearer token for admin-only endpoints; admin access is disabled if empty.
+	AdminToken      string        `yaml:"admin_token" toml:"admin_token" env:"ADMIN_TOKEN"`
+	SessionDuration time.Duration `yaml:"session_duration" toml:"session_duration" env:"SESSION_DURATION"`
+}
+
+type GameConfig struct {
+	// DefaultTurnSeconds is the turn limit for rooms created without one; zero means no limit.
+	DefaultTurnSeconds int `yaml:"default_turn_seconds" toml:"default_turn_seconds" env:"DEFAULT_TURN_SECONDS"`
+	// MaxTurnSeconds is the longest turn limit a room may ask for.
+	MaxTurnSeconds int `yaml:"max_turn_seconds" toml:"max_turn_seconds" env:"MAX_TURN_SECONDS"`
+	// MaxPlayers caps the players in a room; zero means no cap.
+	MaxPlayers int `yaml:"max_players" toml:"max_players" env:"MAX_PLAYERS_PER_ROOM"`
+	// Rounds is how many lines each player writes before the story ends.
+	Rounds int `yaml:"rounds" toml:"rounds" env:"STORY_ROUNDS"`
+	// MaxLines ends the story once it has this many lines, even mid-round;
+	// zero means no cap.
+	MaxLines int `yaml:"max_lines" toml:"max_lines" env:"STORY_MAX_LINES"`
+	// InviteTTL is how long invites last by default, and at most.
+	InviteTTL time.Duration `yaml:"invite_ttl" toml:"invite_ttl" env:"INVITE_TTL"`
+	// RoomCodeLength is the length of generated room codes. Longer codes are
+	// harder to guess.
+	RoomCodeLength int `yaml:"room_code_length" toml:"room_code_length" env:"ROOM_CODE_LENGTH"`
+	// ReconnectGrace is how long players of a restored room have to
+	// reconnect before they are dropped from the game.
+	ReconnectGrace time.Duration `yaml:"reconnect_grace" toml:"reconnect_grace" env:"RECONNECT_GRACE"`
+	// SearchMaxLines caps the lines held in the search index; the oldest
+	// are dropped first.
+	SearchMaxLines int `yaml:"search_max_lines" toml:"search_max_lines" env:"SEARCH_MAX_LINES"`
+}
+
+type MetricsConfig struct {
+	// Enabled serves Prometheus metrics at /metrics.
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+}
+
+type LogConfig struct {
+	// Level is the least severe level logged: debug, info, warn or error.
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format is "text" for key=value lines or "json" for one object per line.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type TracingConfig struct {
+	// Exporter is where spans go: "none", "stdout" or "otlp".
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint is the OTLP/HTTP collector URL for the otlp exporter.
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"TRACING_ENDPOINT"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1.
+	// Requests that arrive with a sampled trace are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+type WebhookConfig struct {
+	Timeout     time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	MaxAttempts int           `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	BaseBackoff time.Duration `yaml:"base_backoff" toml:"base_backoff" env:"WEBHOOK_BASE_BACKOFF"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF"`
+	// AllowPrivateTargets lets webhooks reach loopback, private and
+	// link-local addresses. Off by default so subscribers cannot use the
+	// server to probe its own network.
+	AllowPrivateTargets bool `yaml:"allow_private_targets" toml:"allow_private_targets" env:"WEBHOOK_ALLOW_PRIVATE_TARGETS"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedHeaders: []string{"Content-Type", "Authorization", "Last-Event-ID", "Connect-Protocol-Version",
+				"Connect-Timeout-Ms", "Grpc-Timeout", "X-Grpc-Web", "X-User-Agent", "X-Request-ID", "X-Host-Token", "X-Player-Token"},
+			ExposedHeaders: []string{"Location", "Retry-After", "Deprecation", "Link",
+				"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin", "X-Request-ID"},
+			MaxAge: 10 * time.Minute,
+		},
+		Storage: StorageConfig{Backend: "memory"},
+		Auth:    AuthConfig{SessionDuration: 30 * 24 * time.Hour},
+		Game: GameConfig{
+			MaxTurnSeconds: 3600,
+			Rounds:         1,
+			InviteTTL:      24 * time.Hour,
+			RoomCodeLength: 8,
+			ReconnectGrace: 2 * time.Minute,
+			SearchMaxLines: 100000,
+		},
+		Webhooks: WebhookConfig{
+			Timeout:     10 * time.Second,
+			MaxAttempts: 6,
+			BaseBackoff: time.Second,
+			MaxBackoff:  5 * time.Minute,
+		},
+		Limits:  ratelimit.DefaultConfig(),
+		Metrics: MetricsConfig{Enabled: true},
+		Log:     LogConfig{Level: "info", Format: "text"},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "storytelling-backend",
+		},
+	}
+}
+
+// Load builds the configuration from its sources and validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	// godotenv never overrides variables that are already set, so the real
+	// environment wins over .env.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+	if err := loadEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile reads a YAML or TOML file, chosen by extension, over cfg. Unknown
+// keys are errors so that typos do not go unnoticed.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown setting %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml or .toml)", path, ext)
+	}
+	return nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// loadEnv sets every field with an env tag whose variable is set.
+func loadEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				if err := loadEnv(value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromString(value, strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(v reflect.Value, s string) error {
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (use e.g. 30s or 5m)", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Validate checks the configuration, reporting every problem at once.
+func (c *Config) Validate() error {
+	var errs ValidationError
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port (PORT): must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout: must not be negative")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout: must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout (SHUTDOWN_TIMEOUT): must be positive")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins: %q is not an origin like https://example.com or https://*.example.com", origin)
+	}
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"),
+		"cors.allow_credentials (CORS_ALLOW_CREDENTIALS): cannot be used with the \"*\" origin; list the allowed origins instead")
+	check(c.CORS.MaxAge >= 0, "cors.max_age (CORS_MAX_AGE): must not be negative")
+	for i, route := range c.CORS.Routes {
+		check(strings.HasPrefix(route.PathPrefix, "/"), "cors.routes[%d].path_prefix: must start with /, got %q", i, route.PathPrefix)
+		for _, method := range route.Methods {
+			check(method != "" && strings.ToUpper(method) == method, "cors.routes[%d].methods: %q must be an upper-case HTTP method", i, method)
+		}
+	}
+
+	check(c.Storage.Backend == "memory", "storage.backend (STORAGE_BACKEND): unknown backend %q; the only backend is \"memory\"", c.Storage.Backend)
+
+	check(c.Auth.SessionDuration > 0, "auth.session_duration (SESSION_DURATION): must be positive")
+
+	check(c.Game.MaxTurnSeconds >= 0, "game.max_turn_seconds (MAX_TURN_SECONDS): must not be negative")
+	check(c.Game.DefaultTurnSeconds >= 0 && c.Game.DefaultTurnSeconds <= c.Game.MaxTurnSeconds,
+		"game.default_turn_seconds (DEFAULT_TURN_SECONDS): must be between 0 and game.max_turn_seconds (%d), got %d", c.Game.MaxTurnSeconds, c.Game.DefaultTurnSeconds)
+	check(c.Game.MaxPlayers >= 0, "game.max_players (MAX_PLAYERS_PER_ROOM): must not be negative")
+	check(c.Game.Rounds > 0, "game.rounds (STORY_ROUNDS): must be positive")
+	check(c.Game.MaxLines >= 0, "game.max_lines (STORY_MAX_LINES): must not be negative")
+	check(c.Game.InviteTTL > 0, "game.invite_ttl (INVITE_TTL): must be positive")
+	check(c.Game.RoomCodeLength >= 6 && c.Game.RoomCodeLength <= 16, "game.room_code_length (ROOM_CODE_LENGTH): must be between 6 and 16, got %d", c.Game.RoomCodeLength)
+	check(c.Game.ReconnectGrace > 0, "game.reconnect_grace (RECONNECT_GRACE): must be positive")
+	check(c.Game.SearchMaxLines > 0, "game.search_max_lines (SEARCH_MAX_LINES): must be positive")
+
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout (WEBHOOK_TIMEOUT): must be positive")
+	check(c.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts (WEBHOOK_MAX_ATTEMPTS): must be at least 1")
+	check(c.Webhooks.BaseBackoff > 0, "webhooks.base_backoff (WEBHOOK_BASE_BACKOFF): must be positive")
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.BaseBackoff, "webhooks.max_backoff (WEBHOOK_MAX_BACKOFF): must be at least webhooks.base_backoff")
+
+	check(c.Limits.WSMaxFrameBytes == 0 || c.Limits.WSMaxFrameBytes >= 1024,
+		"limits.ws_max_frame_bytes (WS_MAX_FRAME_BYTES): must be 0 (no limit) or at least 1024, got %d", c.Limits.WSMaxFrameBytes)
+	check(c.Limits.MaxConnsPerIP >= 0, "limits.max_conns_per_ip (MAX_CONNS_PER_IP): must not be negative")
+
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level),
+		"log.level (LOG_LEVEL): must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format (LOG_FORMAT): must be text or json, got %q", c.Log.Format)
+
+	check(slices.Contains([]string{"none", "stdout", "otlp"}, c.Tracing.Exporter),
+		"tracing.exporter (TRACING_EXPORTER): must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	if c.Tracing.Exporter == "otlp" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"tracing.endpoint (TRACING_ENDPOINT): must be an http or https URL like http://localhost:4318, got %q", c.Tracing.Endpoint)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio (TRACING_SAMPLE_RATIO): must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "tracing.service_name (TRACING_SERVICE_NAME): must not be empty")
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	// A wildcard may only stand for the leftmost labels of the host.
+	if scheme, host, ok := strings.Cut(origin, "://*."); ok {
+		origin = scheme + "://wildcard." + host
+	}
+	if strings.Contains(origin, "*") {
+		return false
 	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		(u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.User == nil
 }
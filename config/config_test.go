@@ -0,0 +1,202 @@
+// config/config_test.go
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The example file documents the defaults, so it must decode to exactly them.
+func TestExampleFileMatchesDefaults(t *testing.T) {
+	cfg := Default()
+	if err := loadFile(filepath.Join("..", "config.example.yaml"), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	// The file spells out empty lists, which the defaults leave nil.
+	if len(cfg.CORS.AllowedOrigins) == 0 && len(cfg.CORS.Routes) == 0 {
+		cfg.CORS.AllowedOrigins, cfg.CORS.Routes = nil, nil
+	}
+	if want := Default(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("config.example.yaml decodes to\n%+v\nwant the defaults\n%+v", cfg, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("defaults are invalid: %v", err)
+	}
+}
+
+// setEnv sets environment variables for the test. Unset ones are unset for
+// the test and restored afterwards, including ones godotenv sets from .env.
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for name, value := range vars {
+		t.Setenv(name, value)
+		if value == "" {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+// Settings are layered: defaults, then CONFIG_FILE, then .env, then the
+// environment.
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("server:\n  port: 9000\nlog:\n  level: debug\n  format: json\ngame:\n  max_players: 8\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=9100\nLOG_LEVEL=warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	setEnv(t, map[string]string{
+		"CONFIG_FILE":          file,
+		"PORT":                 "9200",
+		"LOG_LEVEL":            "",
+		"LOG_FORMAT":           "",
+		"MAX_PLAYERS_PER_ROOM": "",
+		"RECONNECT_GRACE":      "45s",
+	})
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tests := []struct {
+		setting string
+		got     interface{}
+		want    interface{}
+	}{
+		{"port: environment over .env and file", cfg.Server.Port, 9200},
+		{"log level: .env over file", cfg.Log.Level, "warn"},
+		{"log format: file over default", cfg.Log.Format, "json"},
+		{"max players: file over default", cfg.Game.MaxPlayers, 8},
+		{"reconnect grace: environment over default", cfg.Game.ReconnectGrace, 45 * time.Second},
+		{"invite TTL: default", cfg.Game.InviteTTL, Default().Game.InviteTTL},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"config.toml", "[server]\nport = 9000\n[limits]\nhttp = \"5/1s\"\n", ""},
+		{"config.yml", "server:\n  port: 9000\nlimits:\n  http: 5/1s\n", ""},
+		{"typo.yaml", "server:\n  prot: 9000\n", "prot"},
+		{"typo.toml", "[server]\nprot = 9000\n", "prot"},
+		{"config.json", "{}", "unsupported format"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg := Default()
+		err := loadFile(path, &cfg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if cfg.Server.Port != 9000 || cfg.Limits.HTTP.Burst != 5 || cfg.Limits.HTTP.Per != time.Second {
+			t.Errorf("%s: port %d, http limit %v; want 9000 and 5/1s", tt.name, cfg.Server.Port, cfg.Limits.HTTP)
+		}
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	tests := []struct{ name, value string }{
+		{"PORT", "eighty"},
+		{"RECONNECT_GRACE", "2"},
+		{"METRICS_ENABLED", "sometimes"},
+		{"RATE_LIMIT_HTTP", "lots"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			cfg := Default()
+			err := loadEnv(reflect.ValueOf(&cfg).Elem())
+			if err == nil || !strings.HasPrefix(err.Error(), tt.name+": ") {
+				t.Errorf("%s=%q: error = %v, want one naming the variable", tt.name, tt.value, err)
+			}
+		})
+	}
+}
+
+func TestEnvLists(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example , ,https://*.b.example")
+	cfg := Default()
+	if err := loadEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example", "https://*.b.example"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("allowed origins = %q, want %q", cfg.CORS.AllowedOrigins, want)
+	}
+}
+
+// Validate reports every problem at once, naming the setting and its variable.
+func TestValidateListsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.CORS.AllowCredentials = true
+	cfg.Game.DefaultTurnSeconds = cfg.Game.MaxTurnSeconds + 1
+	cfg.Game.Rounds = 0
+	cfg.Log.Level = "loud"
+
+	err := cfg.Validate()
+	var problems ValidationError
+	if !errors.As(err, &problems) {
+		t.Fatalf("Validate = %v, want a ValidationError", err)
+	}
+	for _, want := range []string{"(PORT)", "(CORS_ALLOW_CREDENTIALS)", "(DEFAULT_TURN_SECONDS)", "(STORY_ROUNDS)", "(LOG_LEVEL)"} {
+		found := false
+		for _, p := range problems {
+			found = found || strings.Contains(p, want)
+		}
+		if !found {
+			t.Errorf("no problem reported for %s in %q", want, problems)
+		}
+	}
+	if len(problems) != 5 {
+		t.Errorf("got %d problems, want 5: %q", len(problems), problems)
+	}
+}
+
+func TestValidOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"*", true},
+		{"https://example.com", true},
+		{"http://localhost:3000", true},
+		{"https://*.example.com", true},
+		{"https://a.*.example.com", false},
+		{"https://example.com/path", false},
+		{"ftp://example.com", false},
+		{"example.com", false},
+	}
+	for _, tt := range tests {
+		if got := validOrigin(tt.origin); got != tt.want {
+			t.Errorf("validOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1034 @@
+// proto/storytelling/v1/storytelling.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: storytelling/v1/storytelling.proto
+
+package storytellingv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Room struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StoryName   string                 `protobuf:"bytes,2,opt,name=story_name,json=storyName,proto3" json:"story_name,omitempty"`
+	Host        string                 `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
+	Players     []string               `protobuf:"bytes,4,rep,name=players,proto3" json:"players,omitempty"`
+	Story       []string               `protobuf:"bytes,5,rep,name=story,proto3" json:"story,omitempty"`
+	TurnOrder   []string               `protobuf:"bytes,6,rep,name=turn_order,json=turnOrder,proto3" json:"turn_order,omitempty"`
+	CurrentTurn int32                  `protobuf:"varint,7,opt,name=current_turn,json=currentTurn,proto3" json:"current_turn,omitempty"`
+	// One of waiting, in_progress, paused, completed or aborted.
+	Status       string `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	TotalPlayers int32  `protobuf:"varint,9,opt,name=total_players,json=totalPlayers,proto3" json:"total_players,omitempty"`
+	// Seconds left on the current turn when the turn timer is enabled.
+	TurnRemaining float64 `protobuf:"fixed64,10,opt,name=turn_remaining,json=turnRemaining,proto3" json:"turn_remaining,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Room) Reset() {
+	*x = Room{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Room) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Room) ProtoMessage() {}
+
+func (x *Room) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Room.ProtoReflect.Descriptor instead.
+func (*Room) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Room) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Room) GetStoryName() string {
+	if x != nil {
+		return x.StoryName
+	}
+	return ""
+}
+
+func (x *Room) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Room) GetPlayers() []string {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+func (x *Room) GetStory() []string {
+	if x != nil {
+		return x.Story
+	}
+	return nil
+}
+
+func (x *Room) GetTurnOrder() []string {
+	if x != nil {
+		return x.TurnOrder
+	}
+	return nil
+}
+
+func (x *Room) GetCurrentTurn() int32 {
+	if x != nil {
+		return x.CurrentTurn
+	}
+	return 0
+}
+
+func (x *Room) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Room) GetTotalPlayers() int32 {
+	if x != nil {
+		return x.TotalPlayers
+	}
+	return 0
+}
+
+func (x *Room) GetTurnRemaining() float64 {
+	if x != nil {
+		return x.TurnRemaining
+	}
+	return 0
+}
+
+type CreateRoomRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StoryName string                 `protobuf:"bytes,1,opt,name=story_name,json=storyName,proto3" json:"story_name,omitempty"`
+	// Defaults to the account's display name for registered players.
+	PlayerName string `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	// Time limit per turn; zero means no limit.
+	TurnSeconds int32 `protobuf:"varint,3,opt,name=turn_seconds,json=turnSeconds,proto3" json:"turn_seconds,omitempty"`
+	// Vanity code to use as the room ID, 4 to 16 letters or digits; a code is
+	// generated if empty.
+	RoomCode string `protobuf:"bytes,4,opt,name=room_code,json=roomCode,proto3" json:"room_code,omitempty"`
+	// Private rooms can only be joined with an invite or the host's approval.
+	Private       bool `protobuf:"varint,5,opt,name=private,proto3" json:"private,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRoomRequest) Reset() {
+	*x = CreateRoomRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRoomRequest) ProtoMessage() {}
+
+func (x *CreateRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRoomRequest.ProtoReflect.Descriptor instead.
+func (*CreateRoomRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateRoomRequest) GetStoryName() string {
+	if x != nil {
+		return x.StoryName
+	}
+	return ""
+}
+
+func (x *CreateRoomRequest) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *CreateRoomRequest) GetTurnSeconds() int32 {
+	if x != nil {
+		return x.TurnSeconds
+	}
+	return 0
+}
+
+func (x *CreateRoomRequest) GetRoomCode() string {
+	if x != nil {
+		return x.RoomCode
+	}
+	return ""
+}
+
+func (x *CreateRoomRequest) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+type CreateRoomResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RoomId     string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	PlayerName string                 `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	// Proves a guest host is the host; send it as X-Host-Token on host-only requests and StartGame.
+	HostToken string `protobuf:"bytes,3,opt,name=host_token,json=hostToken,proto3" json:"host_token,omitempty"`
+	// Proves a guest is this player; send it as X-Player-Token on their requests and RPCs.
+	PlayerToken   string `protobuf:"bytes,4,opt,name=player_token,json=playerToken,proto3" json:"player_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRoomResponse) Reset() {
+	*x = CreateRoomResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRoomResponse) ProtoMessage() {}
+
+func (x *CreateRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRoomResponse.ProtoReflect.Descriptor instead.
+func (*CreateRoomResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateRoomResponse) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *CreateRoomResponse) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *CreateRoomResponse) GetHostToken() string {
+	if x != nil {
+		return x.HostToken
+	}
+	return ""
+}
+
+func (x *CreateRoomResponse) GetPlayerToken() string {
+	if x != nil {
+		return x.PlayerToken
+	}
+	return ""
+}
+
+type JoinRoomRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RoomId     string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	PlayerName string                 `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	// Invite token from the host; required for private rooms, which cannot be
+	// knocked on over RPC.
+	Invite        string `protobuf:"bytes,3,opt,name=invite,proto3" json:"invite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRoomRequest) Reset() {
+	*x = JoinRoomRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRoomRequest) ProtoMessage() {}
+
+func (x *JoinRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRoomRequest.ProtoReflect.Descriptor instead.
+func (*JoinRoomRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *JoinRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *JoinRoomRequest) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *JoinRoomRequest) GetInvite() string {
+	if x != nil {
+		return x.Invite
+	}
+	return ""
+}
+
+type JoinRoomResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Room  *Room                  `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	// The name to play under; registered players may be given a suffixed name.
+	PlayerName string `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	// Proves a guest is this player; send it as X-Player-Token on their requests and RPCs.
+	PlayerToken   string `protobuf:"bytes,3,opt,name=player_token,json=playerToken,proto3" json:"player_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRoomResponse) Reset() {
+	*x = JoinRoomResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRoomResponse) ProtoMessage() {}
+
+func (x *JoinRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRoomResponse.ProtoReflect.Descriptor instead.
+func (*JoinRoomResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *JoinRoomResponse) GetRoom() *Room {
+	if x != nil {
+		return x.Room
+	}
+	return nil
+}
+
+func (x *JoinRoomResponse) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *JoinRoomResponse) GetPlayerToken() string {
+	if x != nil {
+		return x.PlayerToken
+	}
+	return ""
+}
+
+type GetRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomRequest) Reset() {
+	*x = GetRoomRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomRequest) ProtoMessage() {}
+
+func (x *GetRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomRequest.ProtoReflect.Descriptor instead.
+func (*GetRoomRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+type GetRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          *Room                  `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomResponse) Reset() {
+	*x = GetRoomResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomResponse) ProtoMessage() {}
+
+func (x *GetRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomResponse.ProtoReflect.Descriptor instead.
+func (*GetRoomResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetRoomResponse) GetRoom() *Room {
+	if x != nil {
+		return x.Room
+	}
+	return nil
+}
+
+type StartGameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	PlayerName    string                 `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartGameRequest) Reset() {
+	*x = StartGameRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartGameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartGameRequest) ProtoMessage() {}
+
+func (x *StartGameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartGameRequest.ProtoReflect.Descriptor instead.
+func (*StartGameRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StartGameRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *StartGameRequest) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+type StartGameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartGameResponse) Reset() {
+	*x = StartGameResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartGameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartGameResponse) ProtoMessage() {}
+
+func (x *StartGameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartGameResponse.ProtoReflect.Descriptor instead.
+func (*StartGameResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{8}
+}
+
+type SubmitLineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	PlayerName    string                 `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	Line          string                 `protobuf:"bytes,3,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitLineRequest) Reset() {
+	*x = SubmitLineRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitLineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitLineRequest) ProtoMessage() {}
+
+func (x *SubmitLineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitLineRequest.ProtoReflect.Descriptor instead.
+func (*SubmitLineRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubmitLineRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *SubmitLineRequest) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *SubmitLineRequest) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+type SubmitLineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitLineResponse) Reset() {
+	*x = SubmitLineResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitLineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitLineResponse) ProtoMessage() {}
+
+func (x *SubmitLineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitLineResponse.ProtoReflect.Descriptor instead.
+func (*SubmitLineResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{10}
+}
+
+type WatchRoomRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RoomId     string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	PlayerName string                 `protobuf:"bytes,2,opt,name=player_name,json=playerName,proto3" json:"player_name,omitempty"`
+	// Watch as a spectator; spectators need not have joined the room.
+	Spectator     bool `protobuf:"varint,3,opt,name=spectator,proto3" json:"spectator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRoomRequest) Reset() {
+	*x = WatchRoomRequest{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRoomRequest) ProtoMessage() {}
+
+func (x *WatchRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRoomRequest.ProtoReflect.Descriptor instead.
+func (*WatchRoomRequest) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchRoomRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *WatchRoomRequest) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *WatchRoomRequest) GetSpectator() bool {
+	if x != nil {
+		return x.Spectator
+	}
+	return false
+}
+
+type WatchRoomResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *RoomEvent             `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRoomResponse) Reset() {
+	*x = WatchRoomResponse{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRoomResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRoomResponse) ProtoMessage() {}
+
+func (x *WatchRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRoomResponse.ProtoReflect.Descriptor instead.
+func (*WatchRoomResponse) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchRoomResponse) GetEvent() *RoomEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// RoomEvent is one message from the room. Structured messages keep their
+// WebSocket type (TURN, CHAT, END_GAME, ...); plain text announcements have
+// type TEXT with the text in content.
+type RoomEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Id            int32                  `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Emoji         string                 `protobuf:"bytes,4,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	Data          *structpb.Value        `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomEvent) Reset() {
+	*x = RoomEvent{}
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomEvent) ProtoMessage() {}
+
+func (x *RoomEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_storytelling_v1_storytelling_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomEvent.ProtoReflect.Descriptor instead.
+func (*RoomEvent) Descriptor() ([]byte, []int) {
+	return file_storytelling_v1_storytelling_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RoomEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RoomEvent) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetData() *structpb.Value {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_storytelling_v1_storytelling_proto protoreflect.FileDescriptor
+
+const file_storytelling_v1_storytelling_proto_rawDesc = "" +
+	"\n" +
+	"\"storytelling/v1/storytelling.proto\x12\x0fstorytelling.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x9f\x02\n" +
+	"\x04Room\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"story_name\x18\x02 \x01(\tR\tstoryName\x12\x12\n" +
+	"\x04host\x18\x03 \x01(\tR\x04host\x12\x18\n" +
+	"\aplayers\x18\x04 \x03(\tR\aplayers\x12\x14\n" +
+	"\x05story\x18\x05 \x03(\tR\x05story\x12\x1d\n" +
+	"\n" +
+	"turn_order\x18\x06 \x03(\tR\tturnOrder\x12!\n" +
+	"\fcurrent_turn\x18\a \x01(\x05R\vcurrentTurn\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x12#\n" +
+	"\rtotal_players\x18\t \x01(\x05R\ftotalPlayers\x12%\n" +
+	"\x0eturn_remaining\x18\n" +
+	" \x01(\x01R\rturnRemaining\"\xad\x01\n" +
+	"\x11CreateRoomRequest\x12\x1d\n" +
+	"\n" +
+	"story_name\x18\x01 \x01(\tR\tstoryName\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12!\n" +
+	"\fturn_seconds\x18\x03 \x01(\x05R\vturnSeconds\x12\x1b\n" +
+	"\troom_code\x18\x04 \x01(\tR\broomCode\x12\x18\n" +
+	"\aprivate\x18\x05 \x01(\bR\aprivate\"\x90\x01\n" +
+	"\x12CreateRoomResponse\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12\x1d\n" +
+	"\n" +
+	"host_token\x18\x03 \x01(\tR\thostToken\x12!\n" +
+	"\fplayer_token\x18\x04 \x01(\tR\vplayerToken\"c\n" +
+	"\x0fJoinRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12\x16\n" +
+	"\x06invite\x18\x03 \x01(\tR\x06invite\"\x81\x01\n" +
+	"\x10JoinRoomResponse\x12)\n" +
+	"\x04room\x18\x01 \x01(\v2\x15.storytelling.v1.RoomR\x04room\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12!\n" +
+	"\fplayer_token\x18\x03 \x01(\tR\vplayerToken\")\n" +
+	"\x0eGetRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"<\n" +
+	"\x0fGetRoomResponse\x12)\n" +
+	"\x04room\x18\x01 \x01(\v2\x15.storytelling.v1.RoomR\x04room\"L\n" +
+	"\x10StartGameRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\"\x13\n" +
+	"\x11StartGameResponse\"a\n" +
+	"\x11SubmitLineRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\tR\x04line\"\x14\n" +
+	"\x12SubmitLineResponse\"j\n" +
+	"\x10WatchRoomRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vplayer_name\x18\x02 \x01(\tR\n" +
+	"playerName\x12\x1c\n" +
+	"\tspectator\x18\x03 \x01(\bR\tspectator\"E\n" +
+	"\x11WatchRoomResponse\x120\n" +
+	"\x05event\x18\x01 \x01(\v2\x1a.storytelling.v1.RoomEventR\x05event\"\x8b\x01\n" +
+	"\tRoomEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05emoji\x18\x04 \x01(\tR\x05emoji\x12*\n" +
+	"\x04data\x18\x05 \x01(\v2\x16.google.protobuf.ValueR\x04data2\x85\x04\n" +
+	"\fStoryService\x12U\n" +
+	"\n" +
+	"CreateRoom\x12\".storytelling.v1.CreateRoomRequest\x1a#.storytelling.v1.CreateRoomResponse\x12O\n" +
+	"\bJoinRoom\x12 .storytelling.v1.JoinRoomRequest\x1a!.storytelling.v1.JoinRoomResponse\x12L\n" +
+	"\aGetRoom\x12\x1f.storytelling.v1.GetRoomRequest\x1a .storytelling.v1.GetRoomResponse\x12R\n" +
+	"\tStartGame\x12!.storytelling.v1.StartGameRequest\x1a\".storytelling.v1.StartGameResponse\x12U\n" +
+	"\n" +
+	"SubmitLine\x12\".storytelling.v1.SubmitLineRequest\x1a#.storytelling.v1.SubmitLineResponse\x12T\n" +
+	"\tWatchRoom\x12!.storytelling.v1.WatchRoomRequest\x1a\".storytelling.v1.WatchRoomResponse0\x01B9Z7storytelling-backend/gen/storytelling/v1;storytellingv1b\x06proto3"
+
+var (
+	file_storytelling_v1_storytelling_proto_rawDescOnce sync.Once
+	file_storytelling_v1_storytelling_proto_rawDescData []byte
+)
+
+func file_storytelling_v1_storytelling_proto_rawDescGZIP() []byte {
+	file_storytelling_v1_storytelling_proto_rawDescOnce.Do(func() {
+		file_storytelling_v1_storytelling_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_storytelling_v1_storytelling_proto_rawDesc), len(file_storytelling_v1_storytelling_proto_rawDesc)))
+	})
+	return file_storytelling_v1_storytelling_proto_rawDescData
+}
+
+var file_storytelling_v1_storytelling_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_storytelling_v1_storytelling_proto_goTypes = []any{
+	(*Room)(nil),               // 0: storytelling.v1.Room
+	(*CreateRoomRequest)(nil),  // 1: storytelling.v1.CreateRoomRequest
+	(*CreateRoomResponse)(nil), // 2: storytelling.v1.CreateRoomResponse
+	(*JoinRoomRequest)(nil),    // 3: storytelling.v1.JoinRoomRequest
+	(*JoinRoomResponse)(nil),   // 4: storytelling.v1.JoinRoomResponse
+	(*GetRoomRequest)(nil),     // 5: storytelling.v1.GetRoomRequest
+	(*GetRoomResponse)(nil),    // 6: storytelling.v1.GetRoomResponse
+	(*StartGameRequest)(nil),   // 7: storytelling.v1.StartGameRequest
+	(*StartGameResponse)(nil),  // 8: storytelling.v1.StartGameResponse
+	(*SubmitLineRequest)(nil),  // 9: storytelling.v1.SubmitLineRequest
+	(*SubmitLineResponse)(nil), // 10: storytelling.v1.SubmitLineResponse
+	(*WatchRoomRequest)(nil),   // 11: storytelling.v1.WatchRoomRequest
+	(*WatchRoomResponse)(nil),  // 12: storytelling.v1.WatchRoomResponse
+	(*RoomEvent)(nil),          // 13: storytelling.v1.RoomEvent
+	(*structpb.Value)(nil),     // 14: google.protobuf.Value
+}
+var file_storytelling_v1_storytelling_proto_depIdxs = []int32{
+	0,  // 0: storytelling.v1.JoinRoomResponse.room:type_name -> storytelling.v1.Room
+	0,  // 1: storytelling.v1.GetRoomResponse.room:type_name -> storytelling.v1.Room
+	13, // 2: storytelling.v1.WatchRoomResponse.event:type_name -> storytelling.v1.RoomEvent
+	14, // 3: storytelling.v1.RoomEvent.data:type_name -> google.protobuf.Value
+	1,  // 4: storytelling.v1.StoryService.CreateRoom:input_type -> storytelling.v1.CreateRoomRequest
+	3,  // 5: storytelling.v1.StoryService.JoinRoom:input_type -> storytelling.v1.JoinRoomRequest
+	5,  // 6: storytelling.v1.StoryService.GetRoom:input_type -> storytelling.v1.GetRoomRequest
+	7,  // 7: storytelling.v1.StoryService.StartGame:input_type -> storytelling.v1.StartGameRequest
+	9,  // 8: storytelling.v1.StoryService.SubmitLine:input_type -> storytelling.v1.SubmitLineRequest
+	11, // 9: storytelling.v1.StoryService.WatchRoom:input_type -> storytelling.v1.WatchRoomRequest
+	2,  // 10: storytelling.v1.StoryService.CreateRoom:output_type -> storytelling.v1.CreateRoomResponse
+	4,  // 11: storytelling.v1.StoryService.JoinRoom:output_type -> storytelling.v1.JoinRoomResponse
+	6,  // 12: storytelling.v1.StoryService.GetRoom:output_type -> storytelling.v1.GetRoomResponse
+	8,  // 13: storytelling.v1.StoryService.StartGame:output_type -> storytelling.v1.StartGameResponse
+	10, // 14: storytelling.v1.StoryService.SubmitLine:output_type -> storytelling.v1.SubmitLineResponse
+	12, // 15: storytelling.v1.StoryService.WatchRoom:output_type -> storytelling.v1.WatchRoomResponse
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_storytelling_v1_storytelling_proto_init() }
+func file_storytelling_v1_storytelling_proto_init() {
+	if File_storytelling_v1_storytelling_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_storytelling_v1_storytelling_proto_rawDesc), len(file_storytelling_v1_storytelling_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_storytelling_v1_storytelling_proto_goTypes,
+		DependencyIndexes: file_storytelling_v1_storytelling_proto_depIdxs,
+		MessageInfos:      file_storytelling_v1_storytelling_proto_msgTypes,
+	}.Build()
+	File_storytelling_v1_storytelling_proto = out.File
+	file_storytelling_v1_storytelling_proto_goTypes = nil
+	file_storytelling_v1_storytelling_proto_depIdxs = nil
+}
@@ -0,0 +1,266 @@
+// proto/storytelling/v1/storytelling.proto
+
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: storytelling/v1/storytelling.proto
+
+package storytellingv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	v1 "storytelling-backend/gen/storytelling/v1"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// StoryServiceName is the fully-qualified name of the StoryService service.
+	StoryServiceName = "storytelling.v1.StoryService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// StoryServiceCreateRoomProcedure is the fully-qualified name of the StoryService's CreateRoom RPC.
+	StoryServiceCreateRoomProcedure = "/storytelling.v1.StoryService/CreateRoom"
+	// StoryServiceJoinRoomProcedure is the fully-qualified name of the StoryService's JoinRoom RPC.
+	StoryServiceJoinRoomProcedure = "/storytelling.v1.StoryService/JoinRoom"
+	// StoryServiceGetRoomProcedure is the fully-qualified name of the StoryService's GetRoom RPC.
+	StoryServiceGetRoomProcedure = "/storytelling.v1.StoryService/GetRoom"
+	// StoryServiceStartGameProcedure is the fully-qualified name of the StoryService's StartGame RPC.
+	StoryServiceStartGameProcedure = "/storytelling.v1.StoryService/StartGame"
+	// StoryServiceSubmitLineProcedure is the fully-qualified name of the StoryService's SubmitLine RPC.
+	StoryServiceSubmitLineProcedure = "/storytelling.v1.StoryService/SubmitLine"
+	// StoryServiceWatchRoomProcedure is the fully-qualified name of the StoryService's WatchRoom RPC.
+	StoryServiceWatchRoomProcedure = "/storytelling.v1.StoryService/WatchRoom"
+)
+
+// StoryServiceClient is a client for the storytelling.v1.StoryService service.
+type StoryServiceClient interface {
+	// CreateRoom creates a room with the caller as host.
+	CreateRoom(context.Context, *connect.Request[v1.CreateRoomRequest]) (*connect.Response[v1.CreateRoomResponse], error)
+	// JoinRoom adds a player to a waiting room.
+	JoinRoom(context.Context, *connect.Request[v1.JoinRoomRequest]) (*connect.Response[v1.JoinRoomResponse], error)
+	// GetRoom returns a room's current state.
+	GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error)
+	// StartGame starts the game; only the host may call it.
+	StartGame(context.Context, *connect.Request[v1.StartGameRequest]) (*connect.Response[v1.StartGameResponse], error)
+	// SubmitLine adds the player's line on their turn.
+	SubmitLine(context.Context, *connect.Request[v1.SubmitLineRequest]) (*connect.Response[v1.SubmitLineResponse], error)
+	// WatchRoom connects a player or spectator to the room and streams every
+	// message the room sends, as the WebSocket would. The player leaves the
+	// room when the stream ends.
+	WatchRoom(context.Context, *connect.Request[v1.WatchRoomRequest]) (*connect.ServerStreamForClient[v1.WatchRoomResponse], error)
+}
+
+// NewStoryServiceClient constructs a client for the storytelling.v1.StoryService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewStoryServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) StoryServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	storyServiceMethods := v1.File_storytelling_v1_storytelling_proto.Services().ByName("StoryService").Methods()
+	return &storyServiceClient{
+		createRoom: connect.NewClient[v1.CreateRoomRequest, v1.CreateRoomResponse](
+			httpClient,
+			baseURL+StoryServiceCreateRoomProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("CreateRoom")),
+			connect.WithClientOptions(opts...),
+		),
+		joinRoom: connect.NewClient[v1.JoinRoomRequest, v1.JoinRoomResponse](
+			httpClient,
+			baseURL+StoryServiceJoinRoomProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("JoinRoom")),
+			connect.WithClientOptions(opts...),
+		),
+		getRoom: connect.NewClient[v1.GetRoomRequest, v1.GetRoomResponse](
+			httpClient,
+			baseURL+StoryServiceGetRoomProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("GetRoom")),
+			connect.WithClientOptions(opts...),
+		),
+		startGame: connect.NewClient[v1.StartGameRequest, v1.StartGameResponse](
+			httpClient,
+			baseURL+StoryServiceStartGameProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("StartGame")),
+			connect.WithClientOptions(opts...),
+		),
+		submitLine: connect.NewClient[v1.SubmitLineRequest, v1.SubmitLineResponse](
+			httpClient,
+			baseURL+StoryServiceSubmitLineProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("SubmitLine")),
+			connect.WithClientOptions(opts...),
+		),
+		watchRoom: connect.NewClient[v1.WatchRoomRequest, v1.WatchRoomResponse](
+			httpClient,
+			baseURL+StoryServiceWatchRoomProcedure,
+			connect.WithSchema(storyServiceMethods.ByName("WatchRoom")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// storyServiceClient implements StoryServiceClient.
+type storyServiceClient struct {
+	createRoom *connect.Client[v1.CreateRoomRequest, v1.CreateRoomResponse]
+	joinRoom   *connect.Client[v1.JoinRoomRequest, v1.JoinRoomResponse]
+	getRoom    *connect.Client[v1.GetRoomRequest, v1.GetRoomResponse]
+	startGame  *connect.Client[v1.StartGameRequest, v1.StartGameResponse]
+	submitLine *connect.Client[v1.SubmitLineRequest, v1.SubmitLineResponse]
+	watchRoom  *connect.Client[v1.WatchRoomRequest, v1.WatchRoomResponse]
+}
+
+// CreateRoom calls storytelling.v1.StoryService.CreateRoom.
+func (c *storyServiceClient) CreateRoom(ctx context.Context, req *connect.Request[v1.CreateRoomRequest]) (*connect.Response[v1.CreateRoomResponse], error) {
+	return c.createRoom.CallUnary(ctx, req)
+}
+
+// JoinRoom calls storytelling.v1.StoryService.JoinRoom.
+func (c *storyServiceClient) JoinRoom(ctx context.Context, req *connect.Request[v1.JoinRoomRequest]) (*connect.Response[v1.JoinRoomResponse], error) {
+	return c.joinRoom.CallUnary(ctx, req)
+}
+
+// GetRoom calls storytelling.v1.StoryService.GetRoom.
+func (c *storyServiceClient) GetRoom(ctx context.Context, req *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error) {
+	return c.getRoom.CallUnary(ctx, req)
+}
+
+// StartGame calls storytelling.v1.StoryService.StartGame.
+func (c *storyServiceClient) StartGame(ctx context.Context, req *connect.Request[v1.StartGameRequest]) (*connect.Response[v1.StartGameResponse], error) {
+	return c.startGame.CallUnary(ctx, req)
+}
+
+// SubmitLine calls storytelling.v1.StoryService.SubmitLine.
+func (c *storyServiceClient) SubmitLine(ctx context.Context, req *connect.Request[v1.SubmitLineRequest]) (*connect.Response[v1.SubmitLineResponse], error) {
+	return c.submitLine.CallUnary(ctx, req)
+}
+
+// WatchRoom calls storytelling.v1.StoryService.WatchRoom.
+func (c *storyServiceClient) WatchRoom(ctx context.Context, req *connect.Request[v1.WatchRoomRequest]) (*connect.ServerStreamForClient[v1.WatchRoomResponse], error) {
+	return c.watchRoom.CallServerStream(ctx, req)
+}
+
+// StoryServiceHandler is an implementation of the storytelling.v1.StoryService service.
+type StoryServiceHandler interface {
+	// CreateRoom creates a room with the caller as host.
+	CreateRoom(context.Context, *connect.Request[v1.CreateRoomRequest]) (*connect.Response[v1.CreateRoomResponse], error)
+	// JoinRoom adds a player to a waiting room.
+	JoinRoom(context.Context, *connect.Request[v1.JoinRoomRequest]) (*connect.Response[v1.JoinRoomResponse], error)
+	// GetRoom returns a room's current state.
+	GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error)
+	// StartGame starts the game; only the host may call it.
+	StartGame(context.Context, *connect.Request[v1.StartGameRequest]) (*connect.Response[v1.StartGameResponse], error)
+	// SubmitLine adds the player's line on their turn.
+	SubmitLine(context.Context, *connect.Request[v1.SubmitLineRequest]) (*connect.Response[v1.SubmitLineResponse], error)
+	// WatchRoom connects a player or spectator to the room and streams every
+	// message the room sends, as the WebSocket would. The player leaves the
+	// room when the stream ends.
+	WatchRoom(context.Context, *connect.Request[v1.WatchRoomRequest], *connect.ServerStream[v1.WatchRoomResponse]) error
+}
+
+// NewStoryServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewStoryServiceHandler(svc StoryServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	storyServiceMethods := v1.File_storytelling_v1_storytelling_proto.Services().ByName("StoryService").Methods()
+	storyServiceCreateRoomHandler := connect.NewUnaryHandler(
+		StoryServiceCreateRoomProcedure,
+		svc.CreateRoom,
+		connect.WithSchema(storyServiceMethods.ByName("CreateRoom")),
+		connect.WithHandlerOptions(opts...),
+	)
+	storyServiceJoinRoomHandler := connect.NewUnaryHandler(
+		StoryServiceJoinRoomProcedure,
+		svc.JoinRoom,
+		connect.WithSchema(storyServiceMethods.ByName("JoinRoom")),
+		connect.WithHandlerOptions(opts...),
+	)
+	storyServiceGetRoomHandler := connect.NewUnaryHandler(
+		StoryServiceGetRoomProcedure,
+		svc.GetRoom,
+		connect.WithSchema(storyServiceMethods.ByName("GetRoom")),
+		connect.WithHandlerOptions(opts...),
+	)
+	storyServiceStartGameHandler := connect.NewUnaryHandler(
+		StoryServiceStartGameProcedure,
+		svc.StartGame,
+		connect.WithSchema(storyServiceMethods.ByName("StartGame")),
+		connect.WithHandlerOptions(opts...),
+	)
+	storyServiceSubmitLineHandler := connect.NewUnaryHandler(
+		StoryServiceSubmitLineProcedure,
+		svc.SubmitLine,
+		connect.WithSchema(storyServiceMethods.ByName("SubmitLine")),
+		connect.WithHandlerOptions(opts...),
+	)
+	storyServiceWatchRoomHandler := connect.NewServerStreamHandler(
+		StoryServiceWatchRoomProcedure,
+		svc.WatchRoom,
+		connect.WithSchema(storyServiceMethods.ByName("WatchRoom")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/storytelling.v1.StoryService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case StoryServiceCreateRoomProcedure:
+			storyServiceCreateRoomHandler.ServeHTTP(w, r)
+		case StoryServiceJoinRoomProcedure:
+			storyServiceJoinRoomHandler.ServeHTTP(w, r)
+		case StoryServiceGetRoomProcedure:
+			storyServiceGetRoomHandler.ServeHTTP(w, r)
+		case StoryServiceStartGameProcedure:
+			storyServiceStartGameHandler.ServeHTTP(w, r)
+		case StoryServiceSubmitLineProcedure:
+			storyServiceSubmitLineHandler.ServeHTTP(w, r)
+		case StoryServiceWatchRoomProcedure:
+			storyServiceWatchRoomHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedStoryServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedStoryServiceHandler struct{}
+
+func (UnimplementedStoryServiceHandler) CreateRoom(context.Context, *connect.Request[v1.CreateRoomRequest]) (*connect.Response[v1.CreateRoomResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.CreateRoom is not implemented"))
+}
+
+func (UnimplementedStoryServiceHandler) JoinRoom(context.Context, *connect.Request[v1.JoinRoomRequest]) (*connect.Response[v1.JoinRoomResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.JoinRoom is not implemented"))
+}
+
+func (UnimplementedStoryServiceHandler) GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.GetRoom is not implemented"))
+}
+
+func (UnimplementedStoryServiceHandler) StartGame(context.Context, *connect.Request[v1.StartGameRequest]) (*connect.Response[v1.StartGameResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.StartGame is not implemented"))
+}
+
+func (UnimplementedStoryServiceHandler) SubmitLine(context.Context, *connect.Request[v1.SubmitLineRequest]) (*connect.Response[v1.SubmitLineResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.SubmitLine is not implemented"))
+}
+
+func (UnimplementedStoryServiceHandler) WatchRoom(context.Context, *connect.Request[v1.WatchRoomRequest], *connect.ServerStream[v1.WatchRoomResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("storytelling.v1.StoryService.WatchRoom is not implemented"))
+}
@@ -0,0 +1,117 @@
+// internal/api/account_handler.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"strings"
+)
+
+type RegisterRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token string       `json:"token"`
+	User  *models.User `json:"user"`
+}
+
+// currentUser returns the logged-in user for the request, or nil for guests.
+func (h *Handlers) currentUser(r *http.Request) *models.User {
+	if h.Accounts == nil {
+		return nil
+	}
+	return h.Accounts.UserFromRequest(r)
+}
+
+func (h *Handlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Accounts.Register(req.Username, req.Password, req.DisplayName, req.AvatarURL)
+	if err != nil {
+		requestLog(r).Info("Error registering user", "username", req.Username, "error", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, storage.ErrUsernameTaken) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+	requestLog(r).Info("User registered", "username", user.Username, "user_id", user.ID)
+}
+
+func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	username := strings.ToLower(strings.TrimSpace(req.Username))
+	if !h.limitLogin(w, r, username) {
+		return
+	}
+	token, user, err := h.Accounts.Login(username, req.Password)
+	if err != nil {
+		requestLog(r).Warn("Failed login", "username", req.Username, "error", err)
+		if errors.Is(err, auth.ErrInvalidCredentials) && h.Limits != nil {
+			h.Limits.LoginFailed(username, h.Limits.ClientIP(r.RemoteAddr, r.Header))
+		}
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(LoginResponse{Token: token, User: user}); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
+
+func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	token := auth.TokenFromRequest(r)
+	if token == "" {
+		http.Error(w, auth.ErrNotAuthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := h.Accounts.Logout(token); err != nil {
+		requestLog(r).Error("Error logging out", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MeHandler returns the profile of the logged-in user.
+func (h *Handlers) MeHandler(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r)
+	if user == nil {
+		http.Error(w, auth.ErrNotAuthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+// internal/api/account_handler_test.go
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/ratelimit"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterAvatarURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		avatar string
+		want   int
+	}{
+		{"none", "", http.StatusCreated},
+		{"https", "https://example.com/a.png", http.StatusCreated},
+		{"javascript", "javascript:alert(1)", http.StatusBadRequest},
+		{"relative", "/a.png", http.StatusBadRequest},
+		{"too long", "https://example.com/" + strings.Repeat("a", 2048), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlers(t)
+			body := `{"username": "alice", "password": "correct horse", "avatar_url": "` + tt.avatar + `"}`
+			w := httptest.NewRecorder()
+			h.RegisterHandler(w, httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body)))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+// Once a username has used up its failed logins it is refused, even with
+// the right password, while other usernames can still log in.
+func TestLoginFailuresLimited(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Limits = ratelimit.NewGuard(ratelimit.Config{
+		FailedLogins:   ratelimit.Rule{Burst: 3, Per: time.Hour},
+		FailedLoginsIP: ratelimit.Rule{Burst: 10, Per: time.Hour},
+	})
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := h.Accounts.Register(name, "correct horse", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	login := func(username, password string) int {
+		body := `{"username": "` + username + `", "password": "` + password + `"}`
+		w := httptest.NewRecorder()
+		h.LoginHandler(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := login("alice", "wrong password"); code != http.StatusUnauthorized {
+			t.Fatalf("failed login %d = %d, want 401", i+1, code)
+		}
+	}
+	if code := login("Alice", "correct horse"); code != http.StatusTooManyRequests {
+		t.Errorf("login after 3 failures = %d, want 429", code)
+	}
+	if code := login("bob", "correct horse"); code != http.StatusOK {
+		t.Errorf("another user's login = %d, want 200", code)
+	}
+}
@@ -0,0 +1,131 @@
+// internal/api/admin_handler.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAnnouncementLength is the longest system announcement accepted, in runes.
+const maxAnnouncementLength = 1000
+
+type AnnouncementRequest struct {
+	Message string `json:"message"`
+}
+
+type AnnouncementResponse struct {
+	// Rooms is how many unfinished rooms the announcement was sent to.
+	Rooms int `json:"rooms"`
+}
+
+// AdminRoomsHandler lists every room with its players' and spectators' connections.
+func (h *Handlers) AdminRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, h.Rooms.RoomDetails())
+}
+
+// AdminRoomHandler returns one room with its players' and spectators' connections.
+func (h *Handlers) AdminRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	details, err := h.Rooms.RoomDetail(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, details)
+}
+
+// AdminRoomEventsHandler returns a room's event log, oldest first.
+func (h *Handlers) AdminRoomEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	events, err := h.Rooms.RoomEvents(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, events)
+}
+
+// AdminStoriesHandler returns every stored story, oldest first, for exports.
+func (h *Handlers) AdminStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	stories, err := h.Rooms.Stories()
+	if err != nil {
+		requestLog(r).Error("Error listing stories", "error", err)
+		http.Error(w, "Error listing stories", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, stories)
+}
+
+// AdminEndRoomHandler aborts a room's game, whoever is host.
+func (h *Handlers) AdminEndRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	roomID := mux.Vars(r)["room_id"]
+	if err := h.Rooms.EndRoom(r.Context(), roomID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	requestLog(r).Info("Room ended by an administrator", "room_id", roomID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminDeleteRoomHandler ends a room's game, disconnects everyone and removes the room.
+func (h *Handlers) AdminDeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	if err := h.Rooms.DeleteRoom(r.Context(), mux.Vars(r)["room_id"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminDisconnectPlayerHandler closes a player's or spectator's connection
+// and removes them from the room.
+func (h *Handlers) AdminDisconnectPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	vars := mux.Vars(r)
+	if err := h.Rooms.DisconnectPlayer(r.Context(), vars["room_id"], vars["player_name"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	requestLog(r).Info("Player disconnected by an administrator", "room_id", vars["room_id"], "player", vars["player_name"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminAnnounceHandler sends a system announcement to every unfinished room.
+func (h *Handlers) AdminAnnounceHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var req AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" || len([]rune(message)) > maxAnnouncementLength {
+		http.Error(w, "message must be 1 to 1000 characters", http.StatusBadRequest)
+		return
+	}
+	rooms := h.Rooms.Announce(r.Context(), message)
+	requestLog(r).Info("Announcement sent", "rooms", rooms)
+	writeJSON(w, r, http.StatusOK, AnnouncementResponse{Rooms: rooms})
+}
@@ -0,0 +1,22 @@
+// internal/api/api_test.go
+package api
+
+import (
+	"io"
+	"log/slog"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/storage"
+	"testing"
+)
+
+// newTestHandlers returns handlers over an empty in-memory store, with no
+// limits, origin policy or metrics.
+func newTestHandlers(t *testing.T) *Handlers {
+	t.Helper()
+	cfg := config.Default()
+	store := storage.NewMemoryStorage()
+	rooms := game.NewRoomManager(store, cfg.Game, game.Services{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	return NewHandlers(Services{Rooms: rooms, Accounts: auth.NewAccountManager(store, cfg.Auth, nil)})
+}
@@ -0,0 +1,341 @@
+// internal/api/connect_handler.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	storytellingv1 "storytelling-backend/gen/storytelling/v1"
+	"storytelling-backend/gen/storytelling/v1/storytellingv1connect"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/logging"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/ratelimit"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// watchBufferSize is how many messages a WatchRoom stream may fall behind
+// before the server gives up on the client.
+const watchBufferSize = 256
+
+var errStreamOverflow = errors.New("client is not keeping up with room messages")
+
+// StoryService implements the gRPC / Connect API defined in
+// proto/storytelling/v1 against the same RoomManager as the REST and
+// WebSocket handlers.
+type StoryService struct {
+	*Handlers
+}
+
+var _ storytellingv1connect.StoryServiceHandler = StoryService{}
+
+func (s StoryService) CreateRoom(ctx context.Context, req *connect.Request[storytellingv1.CreateRoomRequest]) (*connect.Response[storytellingv1.CreateRoomResponse], error) {
+	if err := s.limitRPC(req.Peer(), req.Header(), (*ratelimit.Guard).AllowCreateRoom); err != nil {
+		return nil, err
+	}
+	user := s.userFromHeader(req.Header())
+	playerName := req.Msg.PlayerName
+	if user != nil && playerName == "" {
+		playerName = user.DisplayName
+	}
+	if playerName == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("player_name is required"))
+	}
+	turnTimeout, err := s.Rooms.TurnTimeout(int(req.Msg.TurnSeconds))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	settings := game.RoomSettings{
+		Code:        req.Msg.RoomCode,
+		StoryName:   req.Msg.StoryName,
+		TurnTimeout: turnTimeout,
+		Private:     req.Msg.Private,
+	}
+	if user != nil {
+		settings.UserID = user.ID
+	}
+	room, err := s.Rooms.CreateRoom(playerName, settings)
+	switch {
+	case errors.Is(err, game.ErrShuttingDown), errors.Is(err, game.ErrRoomCodeTaken), errors.Is(err, game.ErrInvalidRoomCode):
+		return nil, connectError(err)
+	case err != nil:
+		logging.FromContext(ctx).Error("Error creating room", "player", playerName, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	logging.FromContext(ctx).Info("Room created", "room_id", room.ID, "player", playerName, "story_name", req.Msg.StoryName)
+
+	return connect.NewResponse(&storytellingv1.CreateRoomResponse{RoomId: room.ID, PlayerName: playerName, HostToken: room.HostToken(), PlayerToken: room.PlayerToken(playerName)}), nil
+}
+
+func (s StoryService) JoinRoom(ctx context.Context, req *connect.Request[storytellingv1.JoinRoomRequest]) (*connect.Response[storytellingv1.JoinRoomResponse], error) {
+	if err := s.limitRPC(req.Peer(), req.Header(), (*ratelimit.Guard).AllowJoin); err != nil {
+		return nil, err
+	}
+	userID, playerName := "", req.Msg.PlayerName
+	if user := s.userFromHeader(req.Header()); user != nil {
+		userID = user.ID
+		if playerName == "" {
+			playerName = user.DisplayName
+		}
+	}
+
+	room, playerName, err := s.Rooms.AddPlayerToRoom(req.Msg.RoomId, playerName, userID, req.Msg.Invite)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	logging.FromContext(ctx).Info("Player joined", "room_id", room.ID, "player", playerName)
+	return connect.NewResponse(&storytellingv1.JoinRoomResponse{Room: roomProto(room.Info()), PlayerName: playerName, PlayerToken: room.PlayerToken(playerName)}), nil
+}
+
+func (s StoryService) GetRoom(ctx context.Context, req *connect.Request[storytellingv1.GetRoomRequest]) (*connect.Response[storytellingv1.GetRoomResponse], error) {
+	room, err := s.Rooms.GetRoom(req.Msg.RoomId)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	return connect.NewResponse(&storytellingv1.GetRoomResponse{Room: roomProto(room.Info())}), nil
+}
+
+func (s StoryService) StartGame(ctx context.Context, req *connect.Request[storytellingv1.StartGameRequest]) (*connect.Response[storytellingv1.StartGameResponse], error) {
+	room, err := s.Rooms.GetRoom(req.Msg.RoomId)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	if err := room.AuthenticateHost(req.Msg.PlayerName, s.userIDFromHeader(req.Header()), req.Header().Get(HostTokenHeader)); err != nil {
+		return nil, connectError(err)
+	}
+	if err := room.StartGame(ctx); err != nil {
+		return nil, connectError(err)
+	}
+	return connect.NewResponse(&storytellingv1.StartGameResponse{}), nil
+}
+
+func (s StoryService) SubmitLine(ctx context.Context, req *connect.Request[storytellingv1.SubmitLineRequest]) (*connect.Response[storytellingv1.SubmitLineResponse], error) {
+	room, err := s.playerRoom(req.Header(), req.Msg.RoomId, req.Msg.PlayerName)
+	if err != nil {
+		return nil, err
+	}
+	if err := room.HandleSubmitLine(ctx, req.Msg.PlayerName, req.Msg.Line); err != nil {
+		return nil, connectError(err)
+	}
+	return connect.NewResponse(&storytellingv1.SubmitLineResponse{}), nil
+}
+
+// WatchRoom connects the caller to the room for as long as the stream is open,
+// exactly like a WebSocket connection, and forwards every room message.
+func (s StoryService) WatchRoom(ctx context.Context, req *connect.Request[storytellingv1.WatchRoomRequest], stream *connect.ServerStream[storytellingv1.WatchRoomResponse]) error {
+	playerName := req.Msg.PlayerName
+	if playerName == "" {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("player_name is required"))
+	}
+	var room *models.Room
+	var err error
+	if req.Msg.Spectator {
+		room, err = s.Rooms.GetRoom(req.Msg.RoomId)
+		if err != nil {
+			return connectError(err)
+		}
+	} else if room, err = s.playerRoom(req.Header(), req.Msg.RoomId, playerName); err != nil {
+		return err
+	}
+
+	if guard := s.Limits; guard != nil {
+		release, ok := guard.AcquireConn(guard.ClientIP(req.Peer().Addr, req.Header()))
+		if !ok {
+			return connect.NewError(connect.CodeResourceExhausted, errors.New("too many open connections from this address"))
+		}
+		defer release()
+	}
+
+	transport := newStreamTransport()
+	conn := models.NewTransportConnection(transport, room.ID, playerName, logging.FromContext(ctx))
+	if s.Limits != nil {
+		conn.MessageLimit = s.Limits.MessageBucket()
+	}
+	if req.Msg.Spectator {
+		err = room.AddSpectator(conn)
+	} else {
+		err = room.AddConnection(conn)
+	}
+	if err != nil {
+		return connectError(err)
+	}
+	defer room.HandleDisconnect(conn)
+	defer transport.Close()
+	defer s.Metrics.ConnectionOpened("rpc")()
+
+	if !req.Msg.Spectator {
+		room.BroadcastMessage(ctx, playerName+" joined the room.")
+		if room.PlayerCount() == room.TotalPlayers {
+			room.BroadcastTurn(ctx)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-transport.done:
+			return transport.err
+		case data := <-transport.messages:
+			if err := stream.Send(&storytellingv1.WatchRoomResponse{Event: roomEvent(data)}); err != nil {
+				conn.Logger.Info("WatchRoom stream closed", "error", err)
+				return nil
+			}
+		}
+	}
+}
+
+// playerRoom finds a room and checks the caller is playerName in it: logged
+// in as the player's account, or sending their X-Player-Token.
+func (h *Handlers) playerRoom(header http.Header, roomID, playerName string) (*models.Room, error) {
+	room, err := h.Rooms.GetRoom(roomID)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	if err := room.Authenticate(playerName, h.userIDFromHeader(header), header.Get(PlayerTokenHeader)); err != nil {
+		return nil, connectError(err)
+	}
+	return room, nil
+}
+
+// userIDFromHeader returns the ID of the account whose session header
+// carries, or "" for guests.
+func (h *Handlers) userIDFromHeader(header http.Header) string {
+	if user := h.userFromHeader(header); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+func (h *Handlers) userFromHeader(header http.Header) *models.User {
+	if h.Accounts == nil {
+		return nil
+	}
+	user, err := h.Accounts.Authenticate(auth.TokenFromHeader(header))
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// limitRPC applies a per-client rate limit to an RPC. Like clientKey, the
+// client is the caller's account if they are logged in, otherwise their IP.
+func (h *Handlers) limitRPC(peer connect.Peer, header http.Header, allow func(*ratelimit.Guard, string) (bool, time.Duration)) error {
+	guard := h.Limits
+	if guard == nil {
+		return nil
+	}
+	client := "ip:" + guard.ClientIP(peer.Addr, header)
+	if user := h.userFromHeader(header); user != nil {
+		client = "user:" + user.ID
+	}
+	if ok, _ := allow(guard, client); !ok {
+		return connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded; try again later"))
+	}
+	return nil
+}
+
+// connectError maps game errors onto RPC status codes.
+func connectError(err error) error {
+	code := connect.CodeFailedPrecondition
+	switch {
+	case errors.Is(err, game.ErrRoomNotFound), errors.Is(err, models.ErrPlayerNotInRoom):
+		code = connect.CodeNotFound
+	case errors.Is(err, models.ErrNotHost), errors.Is(err, models.ErrInviteInvalid), errors.Is(err, models.ErrApprovalRequired),
+		errors.Is(err, models.ErrPlayerTokenRequired), errors.Is(err, models.ErrHostTokenRequired):
+		code = connect.CodePermissionDenied
+	case errors.Is(err, models.ErrRateLimited), errors.Is(err, models.ErrChatRateLimited):
+		code = connect.CodeResourceExhausted
+	case errors.Is(err, game.ErrShuttingDown):
+		code = connect.CodeUnavailable
+	case errors.Is(err, game.ErrRoomCodeTaken):
+		code = connect.CodeAlreadyExists
+	case errors.Is(err, game.ErrInvalidRoomCode):
+		code = connect.CodeInvalidArgument
+	}
+	return connect.NewError(code, err)
+}
+
+func roomProto(info models.RoomInfo) *storytellingv1.Room {
+	return &storytellingv1.Room{
+		Id:            info.ID,
+		StoryName:     info.StoryName,
+		Host:          info.Host,
+		Players:       info.Players,
+		Story:         info.Story,
+		TurnOrder:     info.TurnOrder,
+		CurrentTurn:   int32(info.CurrentTurn),
+		Status:        string(info.Status),
+		TotalPlayers:  int32(info.TotalPlayers),
+		TurnRemaining: info.TurnRemaining,
+	}
+}
+
+// roomEvent converts a message as sent to WebSocket clients into a RoomEvent.
+func roomEvent(data []byte) *storytellingv1.RoomEvent {
+	var msg models.Message
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+		return &storytellingv1.RoomEvent{Type: "TEXT", Content: string(data)}
+	}
+	event := &storytellingv1.RoomEvent{
+		Type:    msg.Type,
+		Content: msg.Content,
+		Id:      int32(msg.ID),
+		Emoji:   msg.Emoji,
+	}
+	if msg.Data != nil {
+		// Data was decoded from JSON, so it always fits a protobuf Value.
+		if value, err := structpb.NewValue(msg.Data); err == nil {
+			event.Data = value
+		}
+	}
+	return event
+}
+
+// streamTransport queues room messages for a WatchRoom stream.
+type streamTransport struct {
+	messages chan []byte
+	done     chan struct{}
+	once     sync.Once
+	err      error
+}
+
+func newStreamTransport() *streamTransport {
+	return &streamTransport{
+		messages: make(chan []byte, watchBufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *streamTransport) WriteText(data []byte) error {
+	select {
+	case <-t.done:
+		return net.ErrClosed
+	case t.messages <- append([]byte{}, data...):
+		return nil
+	default:
+		t.closeWith(connect.NewError(connect.CodeResourceExhausted, errStreamOverflow))
+		return errStreamOverflow
+	}
+}
+
+// Close ends the stream, for example when the player connects elsewhere.
+func (t *streamTransport) Close() error {
+	t.closeWith(nil)
+	return nil
+}
+
+func (t *streamTransport) closeWith(err error) {
+	t.once.Do(func() {
+		t.err = err
+		close(t.done)
+	})
+}
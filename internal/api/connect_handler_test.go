@@ -0,0 +1,83 @@
+// internal/api/connect_handler_test.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	storytellingv1 "storytelling-backend/gen/storytelling/v1"
+	"storytelling-backend/gen/storytelling/v1/storytellingv1connect"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+// newTestRPC serves the handlers' StoryService and returns a client for it.
+func newTestRPC(t *testing.T, h *Handlers) storytellingv1connect.StoryServiceClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(storytellingv1connect.NewStoryServiceHandler(h.StoryService()))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return storytellingv1connect.NewStoryServiceClient(srv.Client(), srv.URL)
+}
+
+// withHeader returns an RPC request carrying one header.
+func withHeader[T any](msg *T, key, value string) *connect.Request[T] {
+	req := connect.NewRequest(msg)
+	if value != "" {
+		req.Header().Set(key, value)
+	}
+	return req
+}
+
+// Knowing a player's name is not enough to act as them over RPC: guests send
+// the token they were given, and only the host token starts the game.
+func TestRPCNeedsPlayerAndHostTokens(t *testing.T) {
+	client := newTestRPC(t, newTestHandlers(t))
+	ctx := context.Background()
+	created, err := client.CreateRoom(ctx, connect.NewRequest(&storytellingv1.CreateRoomRequest{PlayerName: "Alice", RoomCode: "TAPES"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined, err := client.JoinRoom(ctx, connect.NewRequest(&storytellingv1.JoinRoomRequest{RoomId: "TAPES", PlayerName: "Bob"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := &storytellingv1.StartGameRequest{RoomId: "TAPES", PlayerName: "Alice"}
+	for _, tt := range []struct {
+		name, token string
+	}{
+		{"no host token", ""},
+		{"Alice's player token", created.Msg.PlayerToken},
+		{"Bob's player token", joined.Msg.PlayerToken},
+	} {
+		_, err := client.StartGame(ctx, withHeader(start, HostTokenHeader, tt.token))
+		if connect.CodeOf(err) != connect.CodePermissionDenied {
+			t.Errorf("StartGame with %s: %v, want permission_denied", tt.name, err)
+		}
+	}
+
+	stream, err := client.WatchRoom(ctx, withHeader(&storytellingv1.WatchRoomRequest{RoomId: "TAPES", PlayerName: "Bob"},
+		PlayerTokenHeader, created.Msg.PlayerToken))
+	if err == nil {
+		stream.Receive()
+		err = stream.Err()
+		stream.Close()
+	}
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("WatchRoom as Bob with Alice's token: %v, want permission_denied", err)
+	}
+
+	if _, err := client.StartGame(ctx, withHeader(start, HostTokenHeader, created.Msg.HostToken)); err != nil {
+		t.Fatalf("StartGame with the host token: %v", err)
+	}
+	submit := &storytellingv1.SubmitLineRequest{RoomId: "TAPES", PlayerName: "Alice", Line: "Once upon a time."}
+	if _, err := client.SubmitLine(ctx, withHeader(submit, PlayerTokenHeader, joined.Msg.PlayerToken)); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("SubmitLine as Alice with Bob's token: %v, want permission_denied", err)
+	}
+	if _, err := client.SubmitLine(ctx, withHeader(submit, PlayerTokenHeader, created.Msg.PlayerToken)); err != nil {
+		t.Errorf("SubmitLine with Alice's token: %v", err)
+	}
+}
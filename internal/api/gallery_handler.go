@@ -0,0 +1,200 @@
+// internal/api/gallery_handler.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"storytelling-backend/internal/gallery"
+	"storytelling-backend/internal/models"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// PlayerTokenHeader carries the player token returned when a guest creates
+// or joins a room; guests must send it to publish or consent.
+const PlayerTokenHeader = "X-Player-Token"
+
+type PublishRequest struct {
+	PlayerName string `json:"player_name"`
+	Title      string `json:"title"`
+	Genre      string `json:"genre"`
+}
+
+type ConsentRequest struct {
+	PlayerName string `json:"player_name"`
+	Approve    bool   `json:"approve"`
+}
+
+// PublishStoryHandler lets the host submit a completed story to the gallery.
+// Players still connected to the room are asked for consent over WebSocket.
+func (h *Handlers) PublishStoryHandler(w http.ResponseWriter, r *http.Request) {
+	roomID := mux.Vars(r)["room_id"]
+	var req PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	record, err := h.Rooms.GetStoryRecord(roomID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	entry, err := h.Gallery.RequestPublish(record, h.actor(r, req.PlayerName), req.Title, req.Genre)
+	if err != nil {
+		requestLog(r).Info("Error publishing story", "room_id", roomID, "error", err)
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+	requestLog(r).Info("Story submitted to the gallery", "room_id", roomID, "slug", entry.Slug)
+	h.notifyRoom(r.Context(), roomID, entry)
+
+	writeJSON(w, r, http.StatusAccepted, entry)
+}
+
+// ConsentHandler records a player's vote on publishing their story.
+func (h *Handlers) ConsentHandler(w http.ResponseWriter, r *http.Request) {
+	roomID := mux.Vars(r)["room_id"]
+	var req ConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.Gallery.Vote(roomID, h.actor(r, req.PlayerName), req.Approve)
+	if err != nil {
+		requestLog(r).Info("Error recording consent", "room_id", roomID, "player", req.PlayerName, "error", err)
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+	h.notifyRoom(r.Context(), roomID, entry)
+
+	writeJSON(w, r, http.StatusOK, entry)
+}
+
+// PublicationHandler returns the publishing status of a room's story.
+func (h *Handlers) PublicationHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.Gallery.GetByRoom(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, entry)
+}
+
+// GalleryHandler lists published stories. Query parameters: sort (newest or
+// most_liked), genre, offset and limit.
+func (h *Handlers) GalleryHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, limit := pageParams(r)
+	stories, err := h.Gallery.List(q.Get("sort"), q.Get("genre"), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, stories)
+}
+
+// GallerySearchHandler searches published stories' titles and lines for ?q=.
+func (h *Handlers) GallerySearchHandler(w http.ResponseWriter, r *http.Request) {
+	offset, limit := pageParams(r)
+	stories, err := h.Gallery.Search(r.URL.Query().Get("q"), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, stories)
+}
+
+// GalleryStoryHandler returns a published story by its permanent slug.
+func (h *Handlers) GalleryStoryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.Gallery.Get(mux.Vars(r)["slug"])
+	if err != nil || entry.Status != models.PublishPublished {
+		http.Error(w, "story not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, entry)
+}
+
+// LikeStoryHandler likes (POST) or unlikes (DELETE) a published story.
+func (h *Handlers) LikeStoryHandler(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	var (
+		entry *models.PublishedStory
+		err   error
+	)
+	if r.Method == http.MethodDelete {
+		entry, err = h.Gallery.Unlike(slug, h.userIDOf(r))
+	} else {
+		entry, err = h.Gallery.Like(slug, h.userIDOf(r))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]int{"likes": entry.Likes})
+}
+
+// notifyRoom tells players still in the room about a change to its gallery entry.
+func (h *Handlers) notifyRoom(ctx context.Context, roomID string, entry *models.PublishedStory) {
+	room, err := h.Rooms.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	msgType := "PUBLISH_RESULT"
+	if entry.Status == models.PublishPending {
+		msgType = "PUBLISH_REQUEST"
+	}
+	room.Broadcast(ctx, models.Message{Type: msgType, Content: string(entry.Status), Data: entry})
+}
+
+func galleryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gallery.ErrNotHost), errors.Is(err, gallery.ErrNotPlayer), errors.Is(err, gallery.ErrWrongAccount),
+		errors.Is(err, gallery.ErrWrongPlayerToken):
+		return http.StatusForbidden
+	case errors.Is(err, gallery.ErrLoginRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, gallery.ErrAlreadyRequested), errors.Is(err, gallery.ErrNotPending),
+		errors.Is(err, gallery.ErrNotCompleted), errors.Is(err, gallery.ErrNotPublished):
+		return http.StatusConflict
+	case errors.Is(err, gallery.ErrUnknownSortOption):
+		return http.StatusBadRequest
+	default:
+		return http.StatusNotFound
+	}
+}
+
+// actor is the caller acting as playerName in the gallery.
+func (h *Handlers) actor(r *http.Request, playerName string) gallery.Actor {
+	return gallery.Actor{PlayerName: playerName, UserID: h.userIDOf(r), PlayerToken: r.Header.Get(PlayerTokenHeader)}
+}
+
+// userIDOf returns the logged-in user's ID, or "" for guests.
+func (h *Handlers) userIDOf(r *http.Request) string {
+	if user := h.currentUser(r); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+// pageParams reads the offset and limit query parameters; limit defaults to 20.
+func pageParams(r *http.Request) (int, int) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return offset, limit
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
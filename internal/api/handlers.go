@@ -0,0 +1,57 @@
+// internal/api/handlers.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/cors"
+	"storytelling-backend/internal/gallery"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/logging"
+	"storytelling-backend/internal/metrics"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/internal/search"
+	"storytelling-backend/internal/webhook"
+
+	"github.com/gorilla/websocket"
+)
+
+// Services are what the handlers serve from. Rooms and Accounts are
+// required; a nil Limits, Origins or Metrics disables rate limiting,
+// cross-origin access or metrics respectively.
+type Services struct {
+	Rooms    *game.RoomManager
+	Accounts *auth.AccountManager
+	Gallery  *gallery.Gallery
+	Search   *search.Index
+	Webhooks *webhook.Dispatcher
+	Limits   *ratelimit.Guard
+	Origins  *cors.Policy
+	Metrics  *metrics.Metrics
+}
+
+// Handlers serves the HTTP, WebSocket, SSE and RPC APIs. Every handler is a
+// method, so several independent servers can run in one process.
+type Handlers struct {
+	Services
+	upgrader websocket.Upgrader
+}
+
+// NewHandlers creates the handlers for services.
+func NewHandlers(services Services) *Handlers {
+	h := &Handlers{Services: services}
+	// WebSocket upgrades are held to the same origin policy as HTTP requests.
+	h.upgrader.CheckOrigin = h.Origins.CheckOrigin
+	return h
+}
+
+// StoryService returns the gRPC / Connect implementation backed by h.
+func (h *Handlers) StoryService() StoryService {
+	return StoryService{h}
+}
+
+// requestLog returns the request's logger, which tags every line with its request ID.
+func requestLog(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context())
+}
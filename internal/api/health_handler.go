@@ -0,0 +1,31 @@
+// internal/api/health_handler.go
+package api
+
+import (
+	"net/http"
+)
+
+// HealthResponse is the body of the health and readiness probes.
+type HealthResponse struct {
+	Status string `json:"status"`
+	// Error says why the server is not ready.
+	Error string `json:"error,omitempty"`
+}
+
+// HealthHandler answers liveness probes: it succeeds whenever the process can
+// serve requests at all.
+func (h *Handlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// ReadyHandler answers readiness probes. It fails with 503 while storage
+// cannot be reached and once the server has begun to shut down, so that the
+// orchestrator stops sending it new players.
+func (h *Handlers) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.Rooms.Ready(); err != nil {
+		requestLog(r).Warn("Not ready", "error", err)
+		writeJSON(w, r, http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	writeJSON(w, r, http.StatusOK, HealthResponse{Status: "ready"})
+}
@@ -3,10 +3,11 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"storytelling-backend/internal/game"
-	"storytelling-backend/pkg/utils"
+	"storytelling-backend/internal/models"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -15,11 +16,20 @@ import (
 type CreateRoomRequest struct {
 	StoryName  string `json:"story_name"`
 	PlayerName string `json:"player_name"`
+	// TurnSeconds limits how long each player has to submit a line; zero means no limit.
+	TurnSeconds int `json:"turn_seconds"`
+	// RoomCode is a vanity code to use as the room ID; one is generated if empty.
+	RoomCode string `json:"room_code"`
+	// Private rooms can only be joined with an invite or the host's approval.
+	Private bool `json:"private"`
 }
 
 type JoinRoomRequest struct {
 	RoomID     string `json:"room_id"`
 	PlayerName string `json:"player_name"`
+	// Invite is an invite token from the host; without one, joining a private
+	// room queues a request for the host to approve.
+	Invite string `json:"invite"`
 }
 
 type AddLineRequest struct {
@@ -28,59 +38,152 @@ type AddLineRequest struct {
 	Line       string `json:"line"`
 }
 
-func CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("CreateRoomHandler called")
+// settings returns the room settings a create request asks for, hosted by
+// user when the request is logged in.
+func (req CreateRoomRequest) settings(turnTimeout time.Duration, user *models.User) game.RoomSettings {
+	settings := game.RoomSettings{
+		Code:        req.RoomCode,
+		StoryName:   req.StoryName,
+		TurnTimeout: turnTimeout,
+		Private:     req.Private,
+	}
+	if user != nil {
+		settings.UserID = user.ID
+	}
+	return settings
+}
+
+func (h *Handlers) CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	logger := requestLog(r)
 	var req CreateRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request: %v", err)
+		logger.Debug("Error decoding request", "error", err)
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
-	log.Printf("Creating room with story name %s by player %s", req.StoryName, req.PlayerName)
-
-	roomID := utils.GenerateRoomID() // Function to generate a unique room ID
-	room, err := game.RoomManagerInstance.CreateRoom(roomID, req.PlayerName)
+	if !h.limitCreateRoom(w, r) {
+		return
+	}
+	user := h.currentUser(r)
+	if user != nil && req.PlayerName == "" {
+		req.PlayerName = user.DisplayName
+	}
+	turnTimeout, err := h.Rooms.TurnTimeout(req.TurnSeconds)
 	if err != nil {
-		log.Printf("Error creating room: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Add the host as a player
-	room.AddPlayer(req.PlayerName)
-	log.Printf("Room %s created successfully with host player %s", roomID, req.PlayerName)
+	room, err := h.Rooms.CreateRoom(req.PlayerName, req.settings(turnTimeout, user))
+	if err != nil {
+		logger.Warn("Error creating room", "player", req.PlayerName, "error", err)
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	logger.Info("Room created", "room_id", room.ID, "player", req.PlayerName, "story_name", req.StoryName)
 
 	// Return the room ID in the response
-	response := map[string]string{"room_id": room.ID}
+	response := map[string]string{"room_id": room.ID, "player_name": req.PlayerName, "host_token": room.HostToken(), "player_token": room.PlayerToken(req.PlayerName)}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		logger.Warn("Error encoding response", "error", err)
 	}
 }
 
-func JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("JoinRoomHandler called")
+func (h *Handlers) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
+	logger := requestLog(r)
 	var req JoinRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request: %v", err)
+		logger.Debug("Error decoding request", "error", err)
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
-	log.Printf("Joining room %s with player %s", req.RoomID, req.PlayerName)
+	if !h.limitJoin(w, r) {
+		return
+	}
+	userID := ""
+	if user := h.currentUser(r); user != nil {
+		userID = user.ID
+		if req.PlayerName == "" {
+			req.PlayerName = user.DisplayName
+		}
+	}
+	room, playerName, err := h.Rooms.AddPlayerToRoom(req.RoomID, req.PlayerName, userID, req.Invite)
+	if errors.Is(err, models.ErrApprovalRequired) {
+		if err = h.knock(w, r, req.RoomID, req.PlayerName, userID); err == nil {
+			return
+		}
+	}
+	if err != nil {
+		logger.Info("Error joining room", "room_id", req.RoomID, "player", req.PlayerName, "error", err)
+		http.Error(w, err.Error(), errorStatus(err, http.StatusNotFound))
+		return
+	}
 
-	room, err := game.RoomManagerInstance.AddPlayerToRoom(req.RoomID, req.PlayerName)
+	// PlayerName is the name to use on the WebSocket; registered users may be
+	// given a suffixed name if theirs is already taken in the room.
+	response := struct {
+		models.RoomInfo
+		PlayerName  string `json:"player_name"`
+		PlayerToken string `json:"player_token"`
+	}{room.Info(), playerName, room.PlayerToken(playerName)}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Warn("Error encoding response", "error", err)
+	}
+	logger.Info("Player joined", "room_id", req.RoomID, "player", playerName)
+}
+
+// GetRoomHandler returns the current state of a room, including its status.
+func (h *Handlers) GetRoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomID := r.URL.Query().Get("room_id")
+	if roomID == "" {
+		http.Error(w, "Room ID is required", http.StatusBadRequest)
+		return
+	}
+
+	room, err := h.Rooms.GetRoom(roomID)
 	if err != nil {
-		log.Printf("Error joining room: %v", err)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(room); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	if err := json.NewEncoder(w).Encode(room.Info()); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
+
+// LobbyEntry summarises a room for the lobby listing.
+type LobbyEntry struct {
+	RoomID      string            `json:"room_id"`
+	Host        string            `json:"host"`
+	Status      models.RoomStatus `json:"status"`
+	PlayerCount int               `json:"player_count"`
+}
+
+// ListRoomsHandler returns the lobby: every room with its status and player count.
+// Pass ?status=waiting (or any other status) to filter.
+func (h *Handlers) ListRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	status := models.RoomStatus(r.URL.Query().Get("status"))
+
+	lobby := []LobbyEntry{}
+	for _, info := range h.Rooms.ListRooms() {
+		if status != "" && info.Status != status {
+			continue
+		}
+		lobby = append(lobby, LobbyEntry{
+			RoomID:      info.ID,
+			Host:        info.Host,
+			Status:      info.Status,
+			PlayerCount: len(info.Players),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lobby); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
 	}
-	log.Printf("Player %s joined room %s successfully", req.PlayerName, req.RoomID)
 }
 
 // // AddLineToStoryHandler allows a player to add a line to the story in a specified room
@@ -94,7 +197,7 @@ func JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
 // 	}
 // 	log.Printf("Adding line to room %s by player %s", req.RoomID, req.PlayerName)
 
-// 	room, err := game.RoomManagerInstance.AddLineToStory(req.RoomID, req.PlayerName, req.Line)
+// 	room, err := h.Rooms.AddLineToStory(req.RoomID, req.PlayerName, req.Line)
 // 	if err != nil {
 // 		log.Printf("Error adding line to story: %v", err)
 // 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -108,51 +211,65 @@ func JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
 // 	log.Printf("Line added to room %s by player %s successfully", req.RoomID, req.PlayerName)
 // }
 
-func GetStoryHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("GetStoryHandler called")
+func (h *Handlers) GetStoryHandler(w http.ResponseWriter, r *http.Request) {
 	roomID := r.URL.Query().Get("room_id")
 	if roomID == "" {
-		log.Println("Room ID is required")
 		http.Error(w, "Room ID is required", http.StatusBadRequest)
 		return
 	}
-	log.Printf("Retrieving story for room %s", roomID)
 
-	story, err := game.RoomManagerInstance.GetStory(roomID)
+	// detail=true returns the stored story with authors, reactions and awards.
+	if r.URL.Query().Get("detail") == "true" {
+		record, err := h.Rooms.GetStoryRecord(roomID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(record); err != nil {
+			requestLog(r).Warn("Error encoding response", "error", err)
+		}
+		return
+	}
+
+	story, err := h.Rooms.GetStory(roomID)
 	if err != nil {
-		log.Printf("Error retrieving story: %v", err)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(story); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		requestLog(r).Warn("Error encoding response", "error", err)
 	}
-	log.Printf("Story for room %s retrieved successfully", roomID)
 }
 
-// Obsoleted by WebSocketHandler
-func StartGameHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("StartGameHandler called")
+// Obsoleted by WebSocketHandler. Only the host may start the game, with the
+// host token in X-Host-Token or logged in as their account.
+func (h *Handlers) StartGameHandler(w http.ResponseWriter, r *http.Request) {
 	roomID := mux.Vars(r)["room_id"]
-	log.Printf("Starting game for room %s", roomID)
 
-	room, err := game.RoomManagerInstance.GetRoom(roomID)
+	room, err := h.Rooms.GetRoom(roomID)
 	if err != nil {
-		log.Printf("Error finding room: %v", err)
 		http.Error(w, "Room not found", http.StatusNotFound)
 		return
 	}
+	if err := room.AuthenticateHost(room.Host, h.userIDOf(r), r.Header.Get(HostTokenHeader)); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
-	room.StartGame()
-	log.Printf("Game started for room %s", roomID)
+	if err := room.StartGame(r.Context()); err != nil {
+		requestLog(r).Info("Error starting game", "room_id", roomID, "error", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
-// Obsoleted by WebSocketHandler
-func SubmitLineHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("SubmitLineHandler called")
+// Obsoleted by WebSocketHandler. The player sends their X-Player-Token or is
+// logged in as their account.
+func (h *Handlers) SubmitLineHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		RoomID     string `json:"room_id"`
 		PlayerName string `json:"player_name"`
@@ -160,25 +277,25 @@ func SubmitLineHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request: %v", err)
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
-	log.Printf("Submitting line to room %s by player %s", req.RoomID, req.PlayerName)
 
-	room, err := game.RoomManagerInstance.GetRoom(req.RoomID)
+	room, err := h.Rooms.GetRoom(req.RoomID)
 	if err != nil {
-		log.Printf("Error finding room: %v", err)
 		http.Error(w, "Room not found", http.StatusNotFound)
 		return
 	}
+	if err := room.Authenticate(req.PlayerName, h.userIDOf(r), r.Header.Get(PlayerTokenHeader)); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusForbidden))
+		return
+	}
 
-	if err := room.AddLine(req.PlayerName, req.Line); err != nil {
-		log.Printf("Error adding line to story: %v", err)
+	if err := room.AddLine(r.Context(), req.PlayerName, req.Line); err != nil {
+		requestLog(r).Info("Error adding line to story", "room_id", req.RoomID, "player", req.PlayerName, "error", err)
 		http.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	log.Printf("Line submitted to room %s by player %s successfully", req.RoomID, req.PlayerName)
 }
@@ -0,0 +1,96 @@
+// internal/api/http_handler_test.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/game"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCreateRoomHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"guest host", `{"player_name": "Alice", "story_name": "The Lighthouse", "turn_seconds": 30}`, http.StatusCreated},
+		{"negative turn", `{"player_name": "Alice", "turn_seconds": -5}`, http.StatusBadRequest},
+		{"invalid room code", `{"player_name": "Alice", "room_code": "B0AT"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlers(t)
+			w := httptest.NewRecorder()
+			h.CreateRoomHandler(w, httptest.NewRequest(http.MethodPost, "/create-room", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+			var resp map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			room, err := h.Rooms.GetRoom(resp["room_id"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info := room.Info(); info.StoryName != "The Lighthouse" || len(info.TurnOrder) != 1 {
+				t.Errorf("room = %+v, want the story name and the host as its only player", info)
+			}
+		})
+	}
+}
+
+// Both ways of starting a game over REST need the host token, not just the
+// host's name.
+func TestStartGameNeedsHostToken(t *testing.T) {
+	starts := []struct {
+		name string
+		req  func() *http.Request
+		call func(h *Handlers, w http.ResponseWriter, r *http.Request)
+	}{
+		{"legacy", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/start-game/TAPES", nil)
+		}, (*Handlers).StartGameHandler},
+		{"v1", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/rooms/TAPES/start", strings.NewReader(`{"player_name": "Alice"}`))
+			r.Header.Set("Content-Type", "application/json")
+			return r
+		}, (*Handlers).StartGameV1Handler},
+	}
+	for _, start := range starts {
+		t.Run(start.name, func(t *testing.T) {
+			h := newTestHandlers(t)
+			room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := h.Rooms.AddPlayerToRoom("TAPES", "Bob", "", ""); err != nil {
+				t.Fatal(err)
+			}
+			for _, tt := range []struct {
+				token string
+				want  int
+			}{
+				{"", http.StatusForbidden},
+				{room.PlayerToken("Bob"), http.StatusForbidden},
+				{room.HostToken(), http.StatusOK},
+			} {
+				r := mux.SetURLVars(start.req(), map[string]string{"room_id": "TAPES"})
+				r.Header.Set(HostTokenHeader, tt.token)
+				w := httptest.NewRecorder()
+				start.call(h, w, r)
+				if w.Code != tt.want {
+					t.Errorf("host token %q: status %d, want %d: %s", tt.token, w.Code, tt.want, w.Body)
+				}
+			}
+		})
+	}
+}
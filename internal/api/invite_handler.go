@@ -0,0 +1,167 @@
+// internal/api/invite_handler.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"storytelling-backend/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HostTokenHeader carries the host token returned when a guest creates a
+// room; host-only requests from a guest host must send it.
+const HostTokenHeader = "X-Host-Token"
+
+// maxJoinWait is the longest a requester may wait for the host's decision in one request.
+const maxJoinWait = 60 * time.Second
+
+type InviteRequest struct {
+	PlayerName string `json:"player_name"`
+	// ExpiresIn is the invite's lifetime in seconds; zero means the server default.
+	ExpiresIn int  `json:"expires_in"`
+	SingleUse bool `json:"single_use"`
+}
+
+type JoinDecisionRequest struct {
+	PlayerName string `json:"player_name"`
+	Approve    bool   `json:"approve"`
+}
+
+// CreateInviteHandler lets the host create an invite token for the room.
+func (h *Handlers) CreateInviteHandler(w http.ResponseWriter, r *http.Request) {
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	room, ok := h.hostRoom(w, r, req.PlayerName)
+	if !ok {
+		return
+	}
+	ttl, err := h.Rooms.InviteTTL(req.ExpiresIn)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	invite, err := room.CreateInvite(req.PlayerName, ttl, req.SingleUse)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusConflict))
+		return
+	}
+	requestLog(r).Info("Invite created", "room_id", room.ID, "expires_at", invite.ExpiresAt, "single_use", invite.SingleUse)
+	writeJSON(w, r, http.StatusCreated, invite)
+}
+
+// JoinRequestsHandler lists the join requests waiting for the host.
+func (h *Handlers) JoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("player_name")
+	room, ok := h.hostRoom(w, r, playerName)
+	if !ok {
+		return
+	}
+	pending, err := room.PendingJoinRequests(playerName)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusForbidden))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string][]models.JoinRequest{"requests": pending})
+}
+
+// JoinRequestHandler returns a join request, so the requester can learn the
+// host's decision. With ?wait=N it waits up to N seconds for the decision.
+func (h *Handlers) JoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	room, err := h.Rooms.GetRoom(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	ctx := r.Context()
+	if wait := r.URL.Query().Get("wait"); wait != "" {
+		seconds, err := strconv.Atoi(wait)
+		if err != nil || seconds < 0 {
+			http.Error(w, "wait must be a number of seconds", http.StatusBadRequest)
+			return
+		}
+		release, ok := h.acquireConn(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, min(time.Duration(seconds)*time.Second, maxJoinWait))
+		defer cancel()
+	} else {
+		// Answer at once with the current state.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	req, err := room.WaitJoinRequest(ctx, mux.Vars(r)["request_id"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, req)
+}
+
+// DecideJoinHandler lets the host approve or deny a join request.
+func (h *Handlers) DecideJoinHandler(w http.ResponseWriter, r *http.Request) {
+	var req JoinDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	room, ok := h.hostRoom(w, r, req.PlayerName)
+	if !ok {
+		return
+	}
+	decided, err := room.DecideJoin(r.Context(), req.PlayerName, mux.Vars(r)["request_id"], req.Approve)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusConflict))
+		return
+	}
+	requestLog(r).Info("Join request decided", "room_id", room.ID, "join_request_id", decided.ID, "status", decided.Status)
+	writeJSON(w, r, http.StatusOK, decided)
+}
+
+// knock queues a join request for a private room and replies 202 with it.
+func (h *Handlers) knock(w http.ResponseWriter, r *http.Request, roomID, playerName, userID string) error {
+	req, err := h.Rooms.Knock(roomID, playerName, userID)
+	if err != nil {
+		return err
+	}
+	requestLog(r).Info("Player asked to join private room", "room_id", roomID, "player", playerName, "join_request_id", req.ID)
+	w.Header().Set("Location", "/rooms/"+roomID+"/join-requests/"+req.ID)
+	writeJSON(w, r, http.StatusAccepted, req)
+	return nil
+}
+
+// hostRoom loads the room in the path and checks the caller may act as its
+// host: logged in as the host's account or, for a guest host, holding the
+// host token.
+func (h *Handlers) hostRoom(w http.ResponseWriter, r *http.Request, playerName string) (*models.Room, bool) {
+	room, err := h.Rooms.GetRoom(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if reason := h.notHost(r, room, playerName); reason != "" {
+		http.Error(w, reason, http.StatusForbidden)
+		return nil, false
+	}
+	return room, true
+}
+
+// notHost returns why the request may not act as the room's host under
+// playerName, or "" if it may: it must come from the host's account or carry
+// the host token in X-Host-Token.
+func (h *Handlers) notHost(r *http.Request, room *models.Room, playerName string) string {
+	if err := room.AuthenticateHost(playerName, h.userIDOf(r), r.Header.Get(HostTokenHeader)); err != nil {
+		return err.Error()
+	}
+	return ""
+}
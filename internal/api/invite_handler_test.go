@@ -0,0 +1,122 @@
+// internal/api/invite_handler_test.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/game"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// A guest host is only known by name, which anyone can claim; host-only
+// requests must carry the host token issued when the room was created.
+func TestGuestHostNeedsHostToken(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES", Private: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no token", "", http.StatusForbidden},
+		{"wrong token", "guess", http.StatusForbidden},
+		{"host token", room.HostToken(), http.StatusCreated},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/invites", strings.NewReader(`{"player_name": "Alice"}`))
+		r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+		if tt.token != "" {
+			r.Header.Set(HostTokenHeader, tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.CreateInviteHandler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestCreateRoomReturnsHostToken(t *testing.T) {
+	h := newTestHandlers(t)
+	w := httptest.NewRecorder()
+	h.CreateRoomV1Handler(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"player_name": "Alice"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	room, err := h.Rooms.GetRoom(resp["room_id"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !room.CheckHostToken(resp["host_token"]) {
+		t.Errorf("response %v does not carry the room's host token", resp)
+	}
+}
+
+func TestLobbyHidesPrivateRooms(t *testing.T) {
+	h := newTestHandlers(t)
+	if _, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "PUBS"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Rooms.CreateRoom("Bob", game.RoomSettings{Code: "SECRT", Private: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ListRoomsHandler(w, httptest.NewRequest(http.MethodGet, "/list-rooms", nil))
+	var legacy []LobbyEntry
+	if err := json.NewDecoder(w.Body).Decode(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	h.ListRoomsV1Handler(w, httptest.NewRequest(http.MethodGet, "/api/v1/rooms", nil))
+	var v1 map[string][]LobbyEntry
+	if err := json.NewDecoder(w.Body).Decode(&v1); err != nil {
+		t.Fatal(err)
+	}
+	for name, lobby := range map[string][]LobbyEntry{"legacy": legacy, "v1": v1["rooms"]} {
+		if len(lobby) != 1 || lobby[0].RoomID != "PUBS" {
+			t.Errorf("%s lobby = %+v, want only the public room", name, lobby)
+		}
+	}
+}
+
+func TestCreateInviteExpiresIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn string
+		want      int
+	}{
+		{"default", "0", http.StatusCreated},
+		{"an hour", "3600", http.StatusCreated},
+		{"negative", "-60", http.StatusBadRequest},
+		{"too long", "99999999", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlers(t)
+			room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/invites", strings.NewReader(`{"player_name": "Alice", "expires_in": `+tt.expiresIn+`}`))
+			r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+			r.Header.Set(HostTokenHeader, room.HostToken())
+			w := httptest.NewRecorder()
+			h.CreateInviteHandler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
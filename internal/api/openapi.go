@@ -0,0 +1,345 @@
+// internal/api/openapi.go
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRequestBody bounds the size of /api/v1 request bodies.
+const maxRequestBody = 1 << 20
+
+// openAPIDocument describes the /api/v1 routes. It is served as-is and is also
+// what requests are validated against, so the two cannot drift apart.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var openAPI = loadOpenAPI(openAPIDocument)
+
+// OpenAPIHandler serves the OpenAPI document.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// ValidateRequest checks path and query parameters and the JSON body of a
+// request against its operation in the OpenAPI document, answering with a 400
+// problem listing every failure instead of calling next.
+func ValidateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := openAPI.operation(r)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var errs []FieldError
+		for _, p := range op.Parameters {
+			var value string
+			var present bool
+			switch p.In {
+			case "path":
+				value, present = mux.Vars(r)[p.Name]
+			case "query":
+				if values, ok := r.URL.Query()[p.Name]; ok {
+					value, present = values[0], true
+				}
+			}
+			if !present {
+				if p.Required {
+					errs = append(errs, FieldError{Field: p.Name, Message: "is required"})
+				}
+				continue
+			}
+			errs = openAPI.validate(p.Schema, p.Name, parseParameter(value, openAPI.resolve(p.Schema)), errs)
+		}
+
+		if body := op.RequestBody; body != nil {
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+			if err != nil {
+				writeProblem(w, r, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			if len(bytes.TrimSpace(data)) == 0 {
+				if body.Required {
+					errs = append(errs, FieldError{Field: "body", Message: "is required"})
+				} else {
+					r.Body = io.NopCloser(strings.NewReader("{}"))
+				}
+			} else if media, ok := body.Content["application/json"]; ok {
+				var v interface{}
+				if err := json.Unmarshal(data, &v); err != nil {
+					writeProblem(w, r, http.StatusBadRequest, "request body is not valid JSON")
+					return
+				}
+				errs = openAPI.validate(media.Schema, "", v, errs)
+			}
+		}
+
+		if len(errs) > 0 {
+			writeProblem(w, r, http.StatusBadRequest, "request does not match the API schema", errs...)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// The subset of OpenAPI 3.1 used by openapi.json.
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas    map[string]*schema    `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+
+	operations map[string]*operation // "METHOD /path/{template}" -> operation
+}
+
+type operation struct {
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Enum                 []interface{}      `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Pattern              string             `json:"pattern"`
+}
+
+func loadOpenAPI(doc []byte) *openAPISpec {
+	var spec openAPISpec
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		panic(fmt.Sprintf("openapi.json: %v", err))
+	}
+	spec.operations = make(map[string]*operation)
+	for path, item := range spec.Paths {
+		var shared []*parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				panic(fmt.Sprintf("openapi.json: %s parameters: %v", path, err))
+			}
+		}
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			op := &operation{}
+			if err := json.Unmarshal(raw, op); err != nil {
+				panic(fmt.Sprintf("openapi.json: %s %s: %v", method, path, err))
+			}
+			op.Parameters = append(append([]*parameter{}, shared...), op.Parameters...)
+			for i, p := range op.Parameters {
+				op.Parameters[i] = spec.resolveParameter(p)
+			}
+			spec.operations[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+	return &spec
+}
+
+// operation finds the operation for the route a request matched.
+func (spec *openAPISpec) operation(r *http.Request) *operation {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	return spec.operations[r.Method+" "+path]
+}
+
+func (spec *openAPISpec) resolveParameter(p *parameter) *parameter {
+	if name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/"); ok {
+		if resolved := spec.Components.Parameters[name]; resolved != nil {
+			return resolved
+		}
+		panic("openapi.json: unknown parameter " + p.Ref)
+	}
+	return p
+}
+
+func (spec *openAPISpec) resolve(s *schema) *schema {
+	for s != nil && s.Ref != "" {
+		name, _ := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		resolved := spec.Components.Schemas[name]
+		if resolved == nil {
+			panic("openapi.json: unknown schema " + s.Ref)
+		}
+		s = resolved
+	}
+	return s
+}
+
+// validate appends a FieldError for each way v fails to match s.
+func (spec *openAPISpec) validate(s *schema, field string, v interface{}, errs []FieldError) []FieldError {
+	s = spec.resolve(s)
+	if s == nil {
+		return errs
+	}
+	fail := func(format string, args ...interface{}) []FieldError {
+		name := field
+		if name == "" {
+			name = "body"
+		}
+		return append(errs, FieldError{Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if v == allowed {
+				return errs
+			}
+		}
+		return fail("must be one of %s", enumList(s.Enum))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, FieldError{Field: joinField(field, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop := s.Properties[name]; prop != nil {
+				errs = spec.validate(prop, joinField(field, name), obj[name], errs)
+				continue
+			}
+			switch extra := bytes.TrimSpace(s.AdditionalProperties); {
+			case string(extra) == "false":
+				errs = append(errs, FieldError{Field: joinField(field, name), Message: "is not a known field"})
+			case len(extra) > 0 && extra[0] == '{':
+				var itemSchema schema
+				if json.Unmarshal(extra, &itemSchema) == nil {
+					errs = spec.validate(&itemSchema, joinField(field, name), obj[name], errs)
+				}
+			}
+		}
+
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		for i, item := range items {
+			errs = spec.validate(s.Items, fmt.Sprintf("%s[%d]", field, i), item, errs)
+		}
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				return fail("must not be empty")
+			}
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+			return fail("must match %s", s.Pattern)
+		}
+
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok || (s.Type == "integer" && n != math.Trunc(n)) {
+			return fail("must be %s", map[string]string{"integer": "an integer", "number": "a number"}[s.Type])
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return fail("must be at most %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+	return errs
+}
+
+// parseParameter converts a path or query string to the JSON type its schema expects.
+func parseParameter(value string, s *schema) interface{} {
+	if s == nil {
+		return value
+	}
+	switch s.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func enumList(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
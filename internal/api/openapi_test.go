@@ -0,0 +1,126 @@
+// internal/api/openapi_test.go
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// Every reference in the document must resolve and every pattern compile;
+// otherwise validation would panic on the first request to use them.
+func TestOpenAPIDocumentResolves(t *testing.T) {
+	var walk func(name string, s *schema)
+	walk = func(name string, s *schema) {
+		defer func() {
+			if err := recover(); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+		s = openAPI.resolve(s)
+		if s == nil {
+			return
+		}
+		if s.Pattern != "" {
+			if _, err := regexp.Compile(s.Pattern); err != nil {
+				t.Errorf("%s: pattern %q: %v", name, s.Pattern, err)
+			}
+		}
+		for prop, p := range s.Properties {
+			walk(name+"."+prop, p)
+		}
+		walk(name+"[]", s.Items)
+	}
+	for name, s := range openAPI.Components.Schemas {
+		walk(name, s)
+	}
+	if len(openAPI.operations) == 0 {
+		t.Fatal("no operations loaded")
+	}
+	for key, op := range openAPI.operations {
+		for _, p := range op.Parameters {
+			walk(key+" "+p.Name, p.Schema)
+		}
+		if op.RequestBody != nil {
+			for media, content := range op.RequestBody.Content {
+				walk(key+" "+media, content.Schema)
+			}
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	var reached string
+	router := mux.NewRouter()
+	ok := ValidateRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reached = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	router.Handle("/api/v1/rooms", ok).Methods("GET", "POST")
+	router.Handle("/api/v1/rooms/{room_id}/lines", ok).Methods("POST")
+	router.Handle("/undocumented", ok).Methods("POST")
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantErrors []FieldError
+	}{
+		{"valid create", "POST", "/api/v1/rooms", `{"player_name": "Alice", "turn_seconds": 60, "room_code": "TAPES", "private": true}`, http.StatusNoContent, nil},
+		{"every problem at once", "POST", "/api/v1/rooms", `{"player_name": "", "turn_seconds": 1.5, "room_code": "B0AT", "private": "yes", "colour": "red"}`, http.StatusBadRequest, []FieldError{
+			{Field: "colour", Message: "is not a known field"},
+			{Field: "player_name", Message: "must not be empty"},
+			{Field: "private", Message: "must be a boolean"},
+			{Field: "room_code", Message: "must match ^[A-HJKMNPR-Za-hjkmnpr-z2-9]{4,16}$"},
+			{Field: "turn_seconds", Message: "must be an integer"},
+		}},
+		{"out of range", "POST", "/api/v1/rooms", `{"turn_seconds": 3601}`, http.StatusBadRequest, []FieldError{{Field: "turn_seconds", Message: "must be at most 3600"}}},
+		{"not an object", "POST", "/api/v1/rooms", `["Alice"]`, http.StatusBadRequest, []FieldError{{Field: "body", Message: "must be an object"}}},
+		{"missing fields", "POST", "/api/v1/rooms/TAPES/lines", `{"player_name": "Alice"}`, http.StatusBadRequest, []FieldError{{Field: "line", Message: "is required"}}},
+		{"required body", "POST", "/api/v1/rooms/TAPES/lines", ``, http.StatusBadRequest, []FieldError{{Field: "body", Message: "is required"}}},
+		{"long path parameter", "POST", "/api/v1/rooms/" + strings.Repeat("A", 65) + "/lines", `{"player_name": "Alice", "line": "Once."}`, http.StatusBadRequest, []FieldError{{Field: "room_id", Message: "must be at most 64 characters"}}},
+		{"query enum", "GET", "/api/v1/rooms?status=sleeping", ``, http.StatusBadRequest, []FieldError{{Field: "status", Message: "must be one of waiting, in_progress, paused, completed, aborted"}}},
+		{"valid query", "GET", "/api/v1/rooms?status=waiting", ``, http.StatusNoContent, nil},
+		{"invalid JSON", "POST", "/api/v1/rooms", `{"player_name":`, http.StatusBadRequest, nil},
+		{"too large", "POST", "/api/v1/rooms", `{"story_name": "` + strings.Repeat("a", maxRequestBody) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{"undocumented route", "POST", "/undocumented", `not even JSON`, http.StatusNoContent, nil},
+	}
+	for _, tt := range tests {
+		reached = ""
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if w.Code == http.StatusNoContent {
+			if tt.method == "POST" && tt.body != "" && reached != tt.body {
+				t.Errorf("%s: handler read body %q, want the original %q", tt.name, reached, tt.body)
+			}
+			continue
+		}
+		if w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: Content-Type %q, want a problem", tt.name, w.Header().Get("Content-Type"))
+		}
+		if tt.wantErrors == nil {
+			continue
+		}
+		var problem Problem
+		if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(problem.Errors, tt.wantErrors) {
+			t.Errorf("%s: errors = %+v, want %+v", tt.name, problem.Errors, tt.wantErrors)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// internal/api/problem.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/models"
+)
+
+// Problem is an RFC 9457 problem details error body, used by the /api/v1 routes.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists individual validation failures, if any.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one request validation failure.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// writeProblem sends a problem details error for the request.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fieldErrors ...FieldError) {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Errors:   fieldErrors,
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
+
+// writeError sends err as a problem, choosing the status from the error.
+// Errors the game does not classify are reported with fallback.
+func writeError(w http.ResponseWriter, r *http.Request, err error, fallback int) {
+	writeProblem(w, r, errorStatus(err, fallback), err.Error())
+}
+
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, game.ErrRoomNotFound), errors.Is(err, models.ErrPlayerNotInRoom),
+		errors.Is(err, models.ErrLineNotFound), errors.Is(err, models.ErrJoinRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrNotHost), errors.Is(err, models.ErrInviteInvalid),
+		errors.Is(err, models.ErrPlayerTokenRequired), errors.Is(err, models.ErrHostTokenRequired):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrRateLimited), errors.Is(err, models.ErrChatRateLimited),
+		errors.Is(err, models.ErrTooManyJoinRequests):
+		return http.StatusTooManyRequests
+	case errors.Is(err, models.ErrGameNotActive), errors.Is(err, models.ErrGamePaused),
+		errors.Is(err, models.ErrGameNotPaused), errors.Is(err, models.ErrGameFinished),
+		errors.Is(err, models.ErrGameStarted),
+		errors.Is(err, models.ErrNotYourTurn), errors.Is(err, game.ErrRoomFull),
+		errors.Is(err, game.ErrRoomCodeTaken), errors.Is(err, models.ErrPlayerNameTaken),
+		errors.Is(err, models.ErrJoinRequestDecided):
+		return http.StatusConflict
+	case errors.Is(err, game.ErrInvalidRoomCode), errors.Is(err, game.ErrInviteTooLong),
+		errors.Is(err, game.ErrInviteNegative),
+		errors.Is(err, game.ErrTurnTooLong), errors.Is(err, game.ErrTurnNegative):
+		return http.StatusBadRequest
+	case errors.Is(err, game.ErrShuttingDown):
+		return http.StatusServiceUnavailable
+	default:
+		return fallback
+	}
+}
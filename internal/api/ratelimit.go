@@ -0,0 +1,91 @@
+// internal/api/ratelimit.go
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit applies the per-IP request limit to every HTTP route.
+func (h *Handlers) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		guard := h.Limits
+		if guard != nil {
+			if ok, wait := guard.AllowRequest(guard.ClientIP(r.RemoteAddr, r.Header)); !ok {
+				tooManyRequests(w, r, wait, "too many requests")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitCreateRoom applies the room creation limit, answering 429 if it is exceeded.
+func (h *Handlers) limitCreateRoom(w http.ResponseWriter, r *http.Request) bool {
+	if guard := h.Limits; guard != nil {
+		if ok, wait := guard.AllowCreateRoom(h.clientKey(r)); !ok {
+			tooManyRequests(w, r, wait, "too many rooms created; try again later")
+			return false
+		}
+	}
+	return true
+}
+
+// limitJoin applies the join limit, answering 429 if it is exceeded.
+func (h *Handlers) limitJoin(w http.ResponseWriter, r *http.Request) bool {
+	if guard := h.Limits; guard != nil {
+		if ok, wait := guard.AllowJoin(h.clientKey(r)); !ok {
+			tooManyRequests(w, r, wait, "too many rooms joined; try again later")
+			return false
+		}
+	}
+	return true
+}
+
+// limitLogin refuses a login for username with 429 once the username or the
+// client IP has had too many failed logins.
+func (h *Handlers) limitLogin(w http.ResponseWriter, r *http.Request, username string) bool {
+	if guard := h.Limits; guard != nil {
+		if ok, wait := guard.AllowLogin(username, guard.ClientIP(r.RemoteAddr, r.Header)); !ok {
+			tooManyRequests(w, r, wait, "too many failed logins; try again later")
+			return false
+		}
+	}
+	return true
+}
+
+// acquireConn reserves one of the client IP's concurrent connection slots,
+// answering 429 if it has too many open. Call release when the connection ends.
+func (h *Handlers) acquireConn(w http.ResponseWriter, r *http.Request) (release func(), ok bool) {
+	guard := h.Limits
+	if guard == nil {
+		return func() {}, true
+	}
+	release, ok = guard.AcquireConn(guard.ClientIP(r.RemoteAddr, r.Header))
+	if !ok {
+		tooManyRequests(w, r, 0, "too many open connections from this address")
+	}
+	return release, ok
+}
+
+// clientKey identifies the caller for per-client limits: their account if
+// they are logged in, otherwise their IP address.
+func (h *Handlers) clientKey(r *http.Request) string {
+	if userID := h.userIDOf(r); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + h.Limits.ClientIP(r.RemoteAddr, r.Header)
+}
+
+// tooManyRequests answers 429 with a Retry-After header; /api routes get a problem body.
+func tooManyRequests(w http.ResponseWriter, r *http.Request, wait time.Duration, detail string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds())))))
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeProblem(w, r, http.StatusTooManyRequests, detail)
+		return
+	}
+	http.Error(w, detail, http.StatusTooManyRequests)
+}
@@ -0,0 +1,261 @@
+// internal/api/rooms_handler.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// The /api/v1 room resource API. Requests are checked against the OpenAPI
+// document (see openapi.go) before they reach these handlers, and every error
+// is returned as application/problem+json.
+
+// RoomResource is the /api/v1 representation of a room.
+type RoomResource struct {
+	ID           string            `json:"id"`
+	StoryName    string            `json:"story_name"`
+	Host         string            `json:"host"`
+	Players      []string          `json:"players"`
+	Story        []string          `json:"story"`
+	TurnOrder    []string          `json:"turn_order"`
+	CurrentTurn  int               `json:"current_turn"`
+	Status       models.RoomStatus `json:"status"`
+	TotalPlayers int               `json:"total_players"`
+	// TurnRemaining is the time left on the current turn in seconds, if the turn timer is enabled.
+	TurnRemaining float64 `json:"turn_remaining,omitempty"`
+}
+
+func roomResource(info models.RoomInfo) RoomResource {
+	return RoomResource{
+		ID:            info.ID,
+		StoryName:     info.StoryName,
+		Host:          info.Host,
+		Players:       info.Players,
+		Story:         info.Story,
+		TurnOrder:     info.TurnOrder,
+		CurrentTurn:   info.CurrentTurn,
+		Status:        info.Status,
+		TotalPlayers:  info.TotalPlayers,
+		TurnRemaining: info.TurnRemaining,
+	}
+}
+
+// PlayerRequest names the player making a request.
+type PlayerRequest struct {
+	PlayerName string `json:"player_name"`
+}
+
+// JoinPlayerRequest joins a room as player_name, with the host's invite
+// token for private rooms.
+type JoinPlayerRequest struct {
+	PlayerName string `json:"player_name"`
+	Invite     string `json:"invite"`
+}
+
+// LineRequest submits a line on the player's turn.
+type LineRequest struct {
+	PlayerName string `json:"player_name"`
+	Line       string `json:"line"`
+}
+
+// CreateRoomV1Handler creates a room with the caller as host.
+func (h *Handlers) CreateRoomV1Handler(w http.ResponseWriter, r *http.Request) {
+	var req CreateRoomRequest
+	if !decodeV1(w, r, &req) || !h.limitCreateRoom(w, r) {
+		return
+	}
+	user := h.currentUser(r)
+	if user != nil && req.PlayerName == "" {
+		req.PlayerName = user.DisplayName
+	}
+	if req.PlayerName == "" {
+		writeProblem(w, r, http.StatusBadRequest, "player_name is required for guests",
+			FieldError{Field: "player_name", Message: "is required"})
+		return
+	}
+	turnTimeout, err := h.Rooms.TurnTimeout(req.TurnSeconds)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error(),
+			FieldError{Field: "turn_seconds", Message: err.Error()})
+		return
+	}
+
+	room, err := h.Rooms.CreateRoom(req.PlayerName, req.settings(turnTimeout, user))
+	if errors.Is(err, game.ErrInvalidRoomCode) || errors.Is(err, game.ErrRoomCodeTaken) {
+		writeProblem(w, r, errorStatus(err, http.StatusBadRequest), err.Error(),
+			FieldError{Field: "room_code", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		requestLog(r).Warn("Error creating room", "player", req.PlayerName, "error", err)
+		writeError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	requestLog(r).Info("Room created", "room_id", room.ID, "player", req.PlayerName, "story_name", req.StoryName)
+
+	w.Header().Set("Location", "/api/v1/rooms/"+room.ID)
+	writeJSON(w, r, http.StatusCreated, map[string]string{"room_id": room.ID, "player_name": req.PlayerName, "host_token": room.HostToken(), "player_token": room.PlayerToken(req.PlayerName)})
+}
+
+// ListRoomsV1Handler returns the lobby, optionally filtered by ?status=.
+func (h *Handlers) ListRoomsV1Handler(w http.ResponseWriter, r *http.Request) {
+	status := models.RoomStatus(r.URL.Query().Get("status"))
+	rooms := []LobbyEntry{}
+	for _, info := range h.Rooms.ListRooms() {
+		if status != "" && info.Status != status {
+			continue
+		}
+		rooms = append(rooms, LobbyEntry{
+			RoomID:      info.ID,
+			Host:        info.Host,
+			Status:      info.Status,
+			PlayerCount: len(info.Players),
+		})
+	}
+	writeJSON(w, r, http.StatusOK, map[string][]LobbyEntry{"rooms": rooms})
+}
+
+// GetRoomV1Handler returns a room's current state.
+func (h *Handlers) GetRoomV1Handler(w http.ResponseWriter, r *http.Request) {
+	room, ok := h.roomFromPath(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, roomResource(room.Info()))
+}
+
+// JoinRoomV1Handler adds a player to a room. Without an invite, joining a
+// private room queues a join request for the host and returns 202.
+func (h *Handlers) JoinRoomV1Handler(w http.ResponseWriter, r *http.Request) {
+	var req JoinPlayerRequest
+	if !decodeV1(w, r, &req) || !h.limitJoin(w, r) {
+		return
+	}
+	userID := ""
+	if user := h.currentUser(r); user != nil {
+		userID = user.ID
+		if req.PlayerName == "" {
+			req.PlayerName = user.DisplayName
+		}
+	}
+	if req.PlayerName == "" {
+		writeProblem(w, r, http.StatusBadRequest, "player_name is required for guests",
+			FieldError{Field: "player_name", Message: "is required"})
+		return
+	}
+
+	roomID := mux.Vars(r)["room_id"]
+	room, playerName, err := h.Rooms.AddPlayerToRoom(roomID, req.PlayerName, userID, req.Invite)
+	if errors.Is(err, models.ErrApprovalRequired) {
+		if err = h.knock(w, r, roomID, req.PlayerName, userID); err == nil {
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, r, err, http.StatusConflict)
+		return
+	}
+	requestLog(r).Info("Player joined", "room_id", roomID, "player", playerName)
+
+	response := struct {
+		Room        RoomResource `json:"room"`
+		PlayerName  string       `json:"player_name"`
+		PlayerToken string       `json:"player_token"`
+	}{roomResource(room.Info()), playerName, room.PlayerToken(playerName)}
+	writeJSON(w, r, http.StatusCreated, response)
+}
+
+// StartGameV1Handler starts the game; only the host may start it, with the
+// host token in X-Host-Token or logged in as their account.
+func (h *Handlers) StartGameV1Handler(w http.ResponseWriter, r *http.Request) {
+	var req PlayerRequest
+	if !decodeV1(w, r, &req) {
+		return
+	}
+	room, ok := h.roomFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := room.AuthenticateHost(req.PlayerName, h.userIDOf(r), r.Header.Get(HostTokenHeader)); err != nil {
+		writeError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err := room.StartGame(r.Context()); err != nil {
+		writeError(w, r, err, http.StatusConflict)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, roomResource(room.Info()))
+}
+
+// SubmitLineV1Handler adds a line on the player's turn. Connected players see
+// it exactly as if it had been sent over the WebSocket.
+func (h *Handlers) SubmitLineV1Handler(w http.ResponseWriter, r *http.Request) {
+	var req LineRequest
+	if !decodeV1(w, r, &req) {
+		return
+	}
+	room, ok := h.roomForPlayer(w, r, req.PlayerName)
+	if !ok {
+		return
+	}
+	if err := room.HandleSubmitLine(r.Context(), req.PlayerName, req.Line); err != nil {
+		writeError(w, r, err, http.StatusConflict)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, roomResource(room.Info()))
+}
+
+// GetStoryV1Handler returns the lines written so far.
+func (h *Handlers) GetStoryV1Handler(w http.ResponseWriter, r *http.Request) {
+	room, ok := h.roomFromPath(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"room_id": room.ID, "lines": room.StoryLines()})
+}
+
+// GetStoryRecordV1Handler returns a finished story with authors, reactions and awards.
+func (h *Handlers) GetStoryRecordV1Handler(w http.ResponseWriter, r *http.Request) {
+	record, err := h.Rooms.GetStoryRecord(mux.Vars(r)["room_id"])
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, "no finished story for this room")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, record)
+}
+
+func decodeV1(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "request body is not valid JSON")
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) roomFromPath(w http.ResponseWriter, r *http.Request) (*models.Room, bool) {
+	room, err := h.Rooms.GetRoom(mux.Vars(r)["room_id"])
+	if err != nil {
+		writeError(w, r, err, http.StatusNotFound)
+		return nil, false
+	}
+	return room, true
+}
+
+// roomForPlayer loads the room and checks the caller is playerName: logged in
+// as the player's account, or sending their X-Player-Token.
+func (h *Handlers) roomForPlayer(w http.ResponseWriter, r *http.Request, playerName string) (*models.Room, bool) {
+	room, ok := h.roomFromPath(w, r)
+	if !ok {
+		return nil, false
+	}
+	if err := room.Authenticate(playerName, h.userIDOf(r), r.Header.Get(PlayerTokenHeader)); err != nil {
+		writeError(w, r, err, http.StatusForbidden)
+		return nil, false
+	}
+	return room, true
+}
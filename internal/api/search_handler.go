@@ -0,0 +1,67 @@
+// internal/api/search_handler.go
+package api
+
+import (
+	"net/http"
+	"storytelling-backend/internal/search"
+	"time"
+)
+
+// SearchHandler searches every story line written on the server. It is for
+// admins only, since it reaches into private rooms. Query parameters: q
+// (words, "phrases" and prefix*), author, from and to (RFC 3339 or
+// YYYY-MM-DD; to is inclusive), offset and limit.
+func (h *Handlers) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	params := r.URL.Query()
+	from, err := parseSearchTime(params.Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+	to, err := parseSearchTime(params.Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+	if params.Get("q") == "" && params.Get("author") == "" && from.IsZero() && to.IsZero() {
+		http.Error(w, "A query or filter is required", http.StatusBadRequest)
+		return
+	}
+
+	offset, limit := pageParams(r)
+	results, err := h.Search.Search(search.Query{
+		Text:   params.Get("q"),
+		Author: params.Get("author"),
+		From:   from,
+		To:     to,
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, results)
+}
+
+// parseSearchTime accepts RFC 3339 timestamps or plain dates. A plain date used
+// as the end of a range covers the whole day.
+func parseSearchTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,44 @@
+// internal/api/search_handler_test.go
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/search"
+	"storytelling-backend/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestSearchHandler(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Accounts = auth.NewAccountManager(storage.NewMemoryStorage(), config.AuthConfig{AdminToken: "admin-secret", SessionDuration: time.Hour}, nil)
+	h.Search = search.NewIndex(0)
+	h.Search.Add(search.Document{RoomID: "SECRT", LineID: 1, Author: "Alice", Text: "A private dragon.", WrittenAt: time.Now()})
+
+	tests := []struct {
+		name  string
+		q     string
+		admin bool
+		want  int
+	}{
+		{"not an admin", "dragon", false, http.StatusUnauthorized},
+		{"admin", "dragon", true, http.StatusOK},
+		{"no words", "!!!", true, http.StatusBadRequest},
+		{"only a wildcard", "*", true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/search?q="+url.QueryEscape(tt.q), nil)
+		if tt.admin {
+			r.Header.Set("Authorization", "Bearer admin-secret")
+		}
+		w := httptest.NewRecorder()
+		h.SearchHandler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
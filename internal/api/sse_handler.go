@@ -0,0 +1,201 @@
+// internal/api/sse_handler.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// sseReconnectGrace is how long an SSE player may stay away before they are
+	// treated as having left the room.
+	sseReconnectGrace = 30 * time.Second
+	sseKeepAlive      = 15 * time.Second
+)
+
+const (
+	// errNoEventStream refuses actions from a player who is not reading the
+	// room's event stream.
+	errNoEventStream = "open the room's event stream before sending actions"
+	// errSpectatorToken refuses a spectator's action without the token from
+	// their SPECTATOR_TOKEN message.
+	errSpectatorToken = "send the token from the SPECTATOR_TOKEN message in " + PlayerTokenHeader
+)
+
+// ActionRequest is a client action sent over HTTP by SSE clients. It carries
+// the same fields as a WebSocket message.
+type ActionRequest struct {
+	PlayerName string `json:"player_name"`
+	models.Message
+}
+
+// EventStreamHandler is the Server-Sent Events alternative to /ws. It sends the
+// same messages as the WebSocket, one per event, and resumes from the
+// Last-Event-ID header (or ?last_event_id=) after a reconnect. Players
+// present their player token as /ws does; a spectator is sent a token of
+// their own, needed to resume and to send actions.
+func (h *Handlers) EventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	roomID := mux.Vars(r)["room_id"]
+	playerName := r.URL.Query().Get("player_name")
+	spectator := r.URL.Query().Get("spectator") == "true"
+	if playerName == "" {
+		http.Error(w, "Player Name is required", http.StatusBadRequest)
+		return
+	}
+	room, err := h.Rooms.GetRoom(roomID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !spectator {
+		if err := room.Authenticate(playerName, h.userIDOf(r), playerToken(r)); err != nil {
+			http.Error(w, err.Error(), errorStatus(err, http.StatusForbidden))
+			return
+		}
+	}
+
+	release, ok := h.acquireConn(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lastID := lastEventID(r)
+	var messageLimit *ratelimit.Bucket
+	if h.Limits != nil {
+		messageLimit = h.Limits.MessageBucket()
+	}
+	conn, stream, err := room.AttachSSE(playerName, spectator, playerToken(r), messageLimit, requestLog(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	stream.Attach()
+	defer h.Metrics.ConnectionOpened("sse")()
+	defer func() {
+		if !stream.Detach() {
+			room.DisconnectAfter(conn, stream, sseReconnectGrace)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// A fresh connection announces itself like a WebSocket join; a resume is silent.
+	if lastID == 0 && !spectator {
+		room.BroadcastMessage(r.Context(), playerName+" joined the room.")
+		if room.PlayerCount() == room.TotalPlayers {
+			room.BroadcastTurn(r.Context())
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, closed := stream.Since(lastID)
+		for _, e := range events {
+			writeSSEEvent(w, e)
+			lastID = e.ID
+		}
+		flusher.Flush()
+		if closed {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-stream.Notify():
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+	}
+}
+
+// RoomActionHandler accepts a client action over HTTP, for clients reading the
+// SSE stream. The body is a WebSocket message plus player_name. The sender
+// proves who they are with X-Player-Token (a player's token, or the token a
+// spectator was sent) or their session, and the host's actions also need
+// X-Host-Token or the host's session.
+func (h *Handlers) RoomActionHandler(w http.ResponseWriter, r *http.Request) {
+	var req ActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	room, err := h.Rooms.GetRoom(mux.Vars(r)["room_id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	conn := room.Connection(req.PlayerName)
+	if conn == nil {
+		http.Error(w, models.ErrPlayerNotInRoom.Error(), http.StatusNotFound)
+		return
+	}
+	// Only a client reading the event stream may act through it; a player
+	// who joined but never connected, or who is connected some other way,
+	// has no stream here and nothing limiting their messages.
+	if stream, ok := conn.Transport.(*models.SSEStream); !ok || !stream.Attached() {
+		http.Error(w, errNoEventStream, http.StatusConflict)
+		return
+	}
+	userID := h.userIDOf(r)
+	if conn.Spectator {
+		if !conn.CheckToken(r.Header.Get(PlayerTokenHeader)) {
+			http.Error(w, errSpectatorToken, http.StatusForbidden)
+			return
+		}
+	} else if err := room.Authenticate(req.PlayerName, userID, r.Header.Get(PlayerTokenHeader)); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusForbidden))
+		return
+	}
+	if ok, wait := conn.TakeMessage(); !ok {
+		room.Metrics.MessageDropped("rate_limited")
+		tooManyRequests(w, r, wait, models.ErrMessageRateLimited.Error())
+		return
+	}
+
+	host := !conn.Spectator && room.AuthenticateHost(req.PlayerName, userID, r.Header.Get(HostTokenHeader)) == nil
+	if err := conn.HandleAction(r.Context(), room, req.Message, host); err != nil {
+		requestLog(r).Info("Action rejected", "room_id", room.ID, "player", req.PlayerName, "type", req.Type, "error", err)
+		http.Error(w, err.Error(), errorStatus(err, http.StatusConflict))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func lastEventID(r *http.Request) int64 {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.ParseInt(value, 10, 64)
+	return id
+}
+
+// writeSSEEvent writes one event; multi-line messages become several data lines.
+func writeSSEEvent(w http.ResponseWriter, e models.SSEEvent) {
+	fmt.Fprintf(w, "id: %d\n", e.ID)
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
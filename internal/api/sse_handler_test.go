@@ -0,0 +1,177 @@
+// internal/api/sse_handler_test.go
+package api
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/ratelimit"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// SSE clients send their actions over plain HTTP, so the per-connection
+// message bucket has to be checked there as it is for WebSocket messages.
+func TestRoomActionUsesMessageBucket(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := ratelimit.NewBucket(1, time.Hour, time.Now())
+	_, stream, err := room.AttachSSE("Alice", false, "", limit, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Attach()
+
+	action := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/actions", strings.NewReader(`{"player_name": "Alice", "type": "PAUSE_GAME"}`))
+		r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+		r.Header.Set(PlayerTokenHeader, room.PlayerToken("Alice"))
+		r.Header.Set(HostTokenHeader, room.HostToken())
+		w := httptest.NewRecorder()
+		h.RoomActionHandler(w, r)
+		return w
+	}
+	if w := action(); w.Code == http.StatusTooManyRequests {
+		t.Fatalf("first action was rate limited: %s", w.Body)
+	}
+	w := action()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second action: status %d, want %d: %s", w.Code, http.StatusTooManyRequests, w.Body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("rate-limited action has no Retry-After header")
+	}
+}
+
+// A player who joined but is not reading the event stream has no message
+// bucket, so their actions are refused rather than left unlimited.
+func TestRoomActionNeedsEventStream(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/actions", strings.NewReader(`{"player_name": "Alice", "type": "CHAT", "content": "hi"}`))
+		r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+		r.Header.Set(PlayerTokenHeader, room.PlayerToken("Alice"))
+		w := httptest.NewRecorder()
+		h.RoomActionHandler(w, r)
+		return w.Code
+	}
+
+	if code := action(); code != http.StatusConflict {
+		t.Errorf("action before connecting: status %d, want %d", code, http.StatusConflict)
+	}
+	_, stream, err := room.AttachSSE("Alice", false, "", nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := action(); code != http.StatusConflict {
+		t.Errorf("action with nobody reading the stream: status %d, want %d", code, http.StatusConflict)
+	}
+	stream.Attach()
+	if code := action(); code != http.StatusNoContent {
+		t.Errorf("action on the stream: status %d, want %d", code, http.StatusNoContent)
+	}
+}
+
+// Knowing the host's name is not enough to act as them: host actions over
+// HTTP need the host's player token and host token, like over /ws.
+func TestRoomActionImpersonatedHostRejected(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := h.Rooms.AddPlayerToRoom("TAPES", "Bob", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	_, stream, err := room.AttachSSE("Alice", false, room.PlayerToken("Alice"), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Attach()
+
+	tests := []struct {
+		name        string
+		playerToken string
+		hostToken   string
+		want        int
+	}{
+		{"no tokens", "", "", http.StatusForbidden},
+		{"another player's token", room.PlayerToken("Bob"), "", http.StatusForbidden},
+		{"another player's token and a guessed host token", room.PlayerToken("Bob"), "guessed", http.StatusForbidden},
+		{"the host's player token only", room.PlayerToken("Alice"), "", http.StatusForbidden},
+		{"the host's player and host tokens", room.PlayerToken("Alice"), room.HostToken(), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/actions", strings.NewReader(`{"player_name": "Alice", "type": "START_GAME"}`))
+			r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+			r.Header.Set(PlayerTokenHeader, tt.playerToken)
+			r.Header.Set(HostTokenHeader, tt.hostToken)
+			w := httptest.NewRecorder()
+			h.RoomActionHandler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+	if room.GetStatus() != models.StatusInProgress {
+		t.Error("the host's own START_GAME did not start the game")
+	}
+}
+
+// A spectator's name proves nothing, so resuming their stream or acting on
+// it needs the token they were sent.
+func TestSpectatorStreamNeedsToken(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conn, stream, err := room.AttachSSE("Carol", true, "", nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Attach()
+	if conn.Token == "" {
+		t.Fatal("new spectator stream has no token")
+	}
+	if _, _, err := room.AttachSSE("Carol", true, "guessed", nil, logger); !errors.Is(err, models.ErrPlayerNameTaken) {
+		t.Errorf("resume with a wrong token: error %v, want %v", err, models.ErrPlayerNameTaken)
+	}
+	if resumed, _, err := room.AttachSSE("Carol", true, conn.Token, nil, logger); err != nil || resumed != conn {
+		t.Errorf("resume with the token: %v, %v; want the same connection", resumed, err)
+	}
+
+	for _, tt := range []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusForbidden},
+		{room.PlayerToken("Alice"), http.StatusForbidden},
+		{conn.Token, http.StatusNotFound}, // accepted, but there is no line 1 to react to
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/rooms/TAPES/actions", strings.NewReader(`{"player_name": "Carol", "type": "REACT", "id": 1, "emoji": "🔥"}`))
+		r = mux.SetURLVars(r, map[string]string{"room_id": "TAPES"})
+		r.Header.Set(PlayerTokenHeader, tt.token)
+		w := httptest.NewRecorder()
+		h.RoomActionHandler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("token %q: status %d, want %d: %s", tt.token, w.Code, tt.want, w.Body)
+		}
+	}
+}
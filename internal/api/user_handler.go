@@ -0,0 +1,51 @@
+// internal/api/user_handler.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// UserStoriesHandler lists the finished stories a user contributed to, newest first.
+func (h *Handlers) UserStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["user_id"]
+	if _, err := h.Accounts.GetUser(userID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	stories, err := h.Rooms.UserStories(userID)
+	if err != nil {
+		requestLog(r).Error("Error listing stories", "user_id", userID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stories); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
+
+// UserStatsHandler returns a summary of a user's play history.
+func (h *Handlers) UserStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["user_id"]
+	if _, err := h.Accounts.GetUser(userID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	stats, err := h.Rooms.UserStats(userID)
+	if err != nil {
+		requestLog(r).Error("Error computing stats", "user_id", userID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		requestLog(r).Warn("Error encoding response", "error", err)
+	}
+}
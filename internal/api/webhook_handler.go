@@ -0,0 +1,132 @@
+// internal/api/webhook_handler.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/webhook"
+	"storytelling-backend/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type SubscribeRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// RoomID limits the subscription to one room; the room's host may create it
+	// by passing PlayerName. Global subscriptions need the admin token.
+	RoomID     string `json:"room_id"`
+	PlayerName string `json:"player_name"`
+}
+
+type SubscribeResponse struct {
+	webhook.Subscription
+	// Secret is only returned when the subscription is created.
+	Secret string `json:"secret"`
+}
+
+// isAdmin reports whether the request carries the admin bearer token.
+func (h *Handlers) isAdmin(r *http.Request) bool {
+	return h.Accounts != nil && h.Accounts.IsAdmin(auth.TokenFromHeader(r.Header))
+}
+
+// requireAdmin writes a 401 and returns false unless the request is from an admin.
+func (h *Handlers) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !h.isAdmin(r) {
+		http.Error(w, "admin token required", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// isRoomHost reports whether the request comes from the host of roomID, as
+// hostRoom checks it: logged in as the host's account or holding the host token.
+func (h *Handlers) isRoomHost(r *http.Request, roomID, playerName string) bool {
+	room, err := h.Rooms.GetRoom(roomID)
+	return err == nil && h.notHost(r, room, playerName) == ""
+}
+
+// CreateWebhookHandler subscribes a URL to room lifecycle events.
+func (h *Handlers) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req SubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	req.RoomID = utils.NormalizeRoomCode(req.RoomID)
+	if !h.isAdmin(r) && (req.RoomID == "" || !h.isRoomHost(r, req.RoomID, req.PlayerName)) {
+		http.Error(w, "only an admin or the room's host can add webhooks", http.StatusForbidden)
+		return
+	}
+
+	sub, secret, err := h.Webhooks.Subscribe(req.URL, req.Secret, req.RoomID, req.Events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	requestLog(r).Info("Webhook subscribed", "webhook_id", sub.ID, "room_id", sub.RoomID, "url", sub.URL)
+	writeJSON(w, r, http.StatusCreated, SubscribeResponse{Subscription: *sub, Secret: secret})
+}
+
+// ListWebhooksHandler lists subscriptions, optionally for one ?room_id=.
+func (h *Handlers) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, h.Webhooks.Subscriptions(utils.NormalizeRoomCode(r.URL.Query().Get("room_id"))))
+}
+
+// DeleteWebhookHandler removes a subscription. Room subscriptions may also be
+// removed by the room's host with ?player_name=.
+func (h *Handlers) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	sub, err := h.Webhooks.Subscription(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !h.isAdmin(r) && (sub.RoomID == "" || !h.isRoomHost(r, sub.RoomID, r.URL.Query().Get("player_name"))) {
+		http.Error(w, "only an admin or the room's host can remove webhooks", http.StatusForbidden)
+		return
+	}
+	if err := h.Webhooks.Unsubscribe(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebhookDeliveriesHandler returns the delivery log, optionally for one ?subscription_id=.
+func (h *Handlers) WebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, h.Webhooks.Deliveries(r.URL.Query().Get("subscription_id")))
+}
+
+// DeadLettersHandler lists deliveries that failed every retry.
+func (h *Handlers) DeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, h.Webhooks.DeadLetters())
+}
+
+// RetryDeadLetterHandler re-queues a dead-lettered delivery.
+func (h *Handlers) RetryDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	err := h.Webhooks.RetryDeadLetter(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, webhook.ErrDeadLetterNotFound), errors.Is(err, webhook.ErrSubscriptionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
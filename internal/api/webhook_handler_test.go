@@ -0,0 +1,48 @@
+// internal/api/webhook_handler_test.go
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/webhook"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateWebhookNeedsAdminOrHost(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Webhooks = webhook.NewDispatcher(webhook.Options{})
+	t.Cleanup(func() { h.Webhooks.Stop(time.Second) })
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "TAPES"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		token string
+		want  int
+	}{
+		{"global, not an admin", `{"url": "https://hooks.example/a"}`, "", http.StatusForbidden},
+		{"host name without the host token", `{"url": "https://hooks.example/a", "room_id": "TAPES", "player_name": "Alice"}`, "", http.StatusForbidden},
+		{"host token for another name", `{"url": "https://hooks.example/a", "room_id": "TAPES", "player_name": "Bob"}`, room.HostToken(), http.StatusForbidden},
+		{"host with the host token", `{"url": "https://hooks.example/a", "room_id": "tapes", "player_name": "Alice"}`, room.HostToken(), http.StatusCreated},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.body))
+		if tt.token != "" {
+			r.Header.Set(HostTokenHeader, tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.CreateWebhookHandler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+	if subs := h.Webhooks.Subscriptions("TAPES"); len(subs) != 1 {
+		t.Errorf("subscriptions for TAPES = %+v, want the host's one", subs)
+	}
+}
@@ -3,47 +3,108 @@ package api
 
 import (
 	"net/http"
-	"storytelling-backend/internal/game"
 	"storytelling-backend/internal/models"
-
-	"github.com/gorilla/websocket"
+	"storytelling-backend/internal/ratelimit"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins; adjust in production for security
-	},
+// playerToken returns the player token the request carries in the
+// X-Player-Token header or, for WebSocket and EventSource clients that
+// cannot set headers, in the player_token query parameter.
+func playerToken(r *http.Request) string {
+	if token := r.Header.Get(PlayerTokenHeader); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("player_token")
+}
+
+// hostToken returns the host token from the X-Host-Token header or the
+// host_token query parameter.
+func hostToken(r *http.Request) string {
+	if token := r.Header.Get(HostTokenHeader); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("host_token")
 }
 
-func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+// WebSocketHandler connects a player, or a spectator, to a room. Players
+// present the player token from joining (or log in as their account), and
+// the host also the host token, which binds the connection to them rather
+// than to whoever knows their name.
+func (h *Handlers) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	// Extract room ID and player name from query parameters. Once the
+	// connection is upgraded, errors can only be sent as ERROR messages.
+	roomID := r.URL.Query().Get("room_id")
+	playerName := r.URL.Query().Get("player_name")
+	if roomID == "" || playerName == "" {
+		writeProblem(w, r, http.StatusBadRequest, "room_id and player_name are required")
+		return
+	}
+
+	release, ok := h.acquireConn(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Upgrade the HTTP connection to a WebSocket connection
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		http.Error(w, "Could not upgrade to WebSocket connection", http.StatusBadRequest)
+		// Upgrade has already replied with the reason, such as a 403 for a
+		// disallowed origin.
 		return
 	}
 	defer conn.Close()
+	defer h.Metrics.ConnectionOpened("websocket")()
+	var messageLimit *ratelimit.Bucket
+	if guard := h.Limits; guard != nil {
+		// Oversized frames are refused with a 1009 close frame by the websocket library.
+		if max := guard.Config().WSMaxFrameBytes; max > 0 {
+			conn.SetReadLimit(max)
+		}
+		messageLimit = guard.MessageBucket()
+	}
 
-	// Extract room ID and player name from query parameters
-	roomID := r.URL.Query().Get("room_id")
-	playerName := r.URL.Query().Get("player_name") // Assuming you're passing the player's name as well
-	if roomID == "" || playerName == "" {
-		http.Error(w, "Room ID and Player Name are required", http.StatusBadRequest)
+	// Spectators watch and react without joining the turn order
+	if r.URL.Query().Get("spectator") == "true" {
+		room, err := h.Rooms.GetRoom(roomID)
+		if err != nil {
+			conn.WriteJSON(models.Message{Type: "ERROR", Content: err.Error()})
+			return
+		}
+		spectator := models.NewPlayerConnection(conn, roomID, playerName, requestLog(r))
+		spectator.MessageLimit = messageLimit
+		if err := room.AddSpectator(spectator); err != nil {
+			conn.WriteJSON(models.Message{Type: "ERROR", Content: err.Error()})
+			return
+		}
+		spectator.Listen(r.Context(), room)
+		return
+	}
+
+	room, err := h.Rooms.GetRoom(roomID)
+	if err != nil {
+		conn.WriteJSON(models.Message{Type: "ERROR", Content: err.Error()})
+		return
+	}
+	userID := h.userIDOf(r)
+	if err := room.Authenticate(playerName, userID, playerToken(r)); err != nil {
+		conn.WriteJSON(models.Message{Type: "ERROR", Content: err.Error()})
 		return
 	}
 
 	// Register the WebSocket connection with the room
-	playerConn := models.NewPlayerConnection(conn, roomID, playerName) // Include playerName
-	if err := game.RoomManagerInstance.AddConnectionToRoom(roomID, playerConn); err != nil {
-		http.Error(w, "Failed to register connection:"+err.Error(), http.StatusInternalServerError)
+	playerConn := models.NewPlayerConnection(conn, roomID, playerName, requestLog(r))
+	playerConn.MessageLimit = messageLimit
+	playerConn.Host = room.AuthenticateHost(playerName, userID, hostToken(r)) == nil
+	if err := h.Rooms.AddConnectionToRoom(roomID, playerConn); err != nil {
+		conn.WriteJSON(models.Message{Type: "ERROR", Content: err.Error()})
 		return
 	}
-	room, _ := game.RoomManagerInstance.GetRoom(roomID)
-	room.BroadcastMessage(playerName + " joined the room.")
+	room.BroadcastMessage(r.Context(), playerName+" joined the room.")
 	// Handle incoming messages and player disconnects
-	if len(room.Players) == room.TotalPlayers {
-		room.BroadcastTurn() // Start the game when all players join
+	if room.PlayerCount() == room.TotalPlayers {
+		room.BroadcastTurn(r.Context()) // Start the game when all players join
 	}
 
-	playerConn.Listen(room)
+	playerConn.Listen(r.Context(), room)
 }
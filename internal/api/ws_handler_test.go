@@ -0,0 +1,120 @@
+// internal/api/ws_handler_test.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketMissingParameters(t *testing.T) {
+	h := newTestHandlers(t)
+	w := httptest.NewRecorder()
+	h.WebSocketHandler(w, httptest.NewRequest(http.MethodGet, "/ws?room_id=ROOM", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want a problem", ct)
+	}
+}
+
+// Errors after the upgrade arrive as ERROR messages, not HTTP responses
+// written to the hijacked connection.
+func TestWebSocketErrorsAfterUpgrade(t *testing.T) {
+	h := newTestHandlers(t)
+	if _, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "PARTY"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(h.WebSocketHandler))
+	defer srv.Close()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"room_id=PARTY&player_name=Mallory", models.ErrPlayerNotInRoom.Error()},
+		{"room_id=PARTY&player_name=Alice", models.ErrPlayerTokenRequired.Error()},
+		{"room_id=PARTY&player_name=Alice&player_token=guessed", models.ErrPlayerTokenRequired.Error()},
+		{"room_id=NOSUCH&player_name=Alice", game.ErrRoomNotFound.Error()},
+	}
+	for _, tt := range tests {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws?"+tt.query, nil)
+		if err != nil {
+			t.Fatalf("%s: dial: %v", tt.query, err)
+		}
+		var msg models.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("%s: read: %v", tt.query, err)
+		}
+		if msg.Type != "ERROR" || msg.Content != tt.want {
+			t.Errorf("%s: got %+v, want ERROR %q", tt.query, msg, tt.want)
+		}
+		conn.Close()
+	}
+}
+
+// The host's player token connects them, but the host-only messages also
+// need the host token.
+func TestWebSocketHostMessagesNeedHostToken(t *testing.T) {
+	h := newTestHandlers(t)
+	room, err := h.Rooms.CreateRoom("Alice", game.RoomSettings{Code: "PARTY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(h.WebSocketHandler))
+	defer srv.Close()
+
+	dial := func(query string) *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		var history models.Message
+		if err := conn.ReadJSON(&history); err != nil || history.Type != "CHAT_HISTORY" {
+			t.Fatalf("%s: first message %+v, %v; want CHAT_HISTORY", query, history, err)
+		}
+		return conn
+	}
+	readError := func(conn *websocket.Conn) string {
+		t.Helper()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var msg models.Message
+			if json.Unmarshal(data, &msg) == nil && msg.Type == "ERROR" {
+				return msg.Content
+			}
+		}
+	}
+
+	conn := dial("room_id=PARTY&player_name=Alice&player_token=" + room.PlayerToken("Alice"))
+	conn.WriteJSON(models.Message{Type: "START_GAME"})
+	if got := readError(conn); got != models.ErrHostTokenRequired.Error() {
+		t.Errorf("START_GAME without the host token: error %q, want %q", got, models.ErrHostTokenRequired)
+	}
+	if room.GetStatus() != models.StatusWaiting {
+		t.Fatalf("status = %s, want the game still waiting", room.GetStatus())
+	}
+
+	conn = dial("room_id=PARTY&player_name=Alice&player_token=" + room.PlayerToken("Alice") + "&host_token=" + room.HostToken())
+	conn.WriteJSON(models.Message{Type: "START_GAME"})
+	deadline := time.Now().Add(time.Second)
+	for room.GetStatus() != models.StatusInProgress {
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %s, want the host's START_GAME to start the game", room.GetStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,205 @@
+// internal/auth/auth.go
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidUsername    = errors.New("username must be 3-32 characters of a-z, 0-9 or _")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrNotAuthenticated   = errors.New("not logged in")
+	ErrInvalidAvatarURL   = fmt.Errorf("avatar_url must be an http or https URL of at most %d characters", maxAvatarURLLength)
+)
+
+// maxAvatarURLLength caps the avatar URL stored with an account.
+const maxAvatarURLLength = 2048
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+
+// AccountManager registers accounts and manages login sessions.
+type AccountManager struct {
+	storage storage.Storage
+	config  config.AuthConfig
+	clock   models.Clock
+
+	// dummyHash is compared against when a login names no account, so that
+	// unknown usernames take as long to refuse as wrong passwords.
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+}
+
+// NewAccountManager creates an AccountManager backed by the given storage.
+// A nil clock means time.Now.
+func NewAccountManager(store storage.Storage, cfg config.AuthConfig, clock models.Clock) *AccountManager {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &AccountManager{storage: store, config: cfg, clock: clock}
+}
+
+// Register creates a new account. The display name defaults to the username.
+func (am *AccountManager) Register(username, password, displayName, avatarURL string) (*models.User, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if !usernamePattern.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	if len(password) < 8 {
+		return nil, ErrWeakPassword
+	}
+	if !validAvatarURL(avatarURL) {
+		return nil, ErrInvalidAvatarURL
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if displayName = strings.TrimSpace(displayName); displayName == "" {
+		displayName = username
+	}
+
+	user := &models.User{
+		ID:           "usr-" + utils.GenerateToken(8),
+		Username:     username,
+		DisplayName:  displayName,
+		AvatarURL:    avatarURL,
+		PasswordHash: hash,
+		CreatedAt:    am.clock(),
+	}
+	if err := am.storage.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login checks the password and starts a session, returning its token.
+func (am *AccountManager) Login(username, password string) (string, *models.User, error) {
+	user, err := am.storage.GetUserByUsername(strings.ToLower(strings.TrimSpace(username)))
+	if err != nil {
+		bcrypt.CompareHashAndPassword(am.unknownUserHash(), []byte(password))
+		return "", nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)) != nil {
+		return "", nil, ErrInvalidCredentials
+	}
+
+	token := utils.GenerateToken(32)
+	session := &models.Session{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: am.clock().Add(am.config.SessionDuration),
+	}
+	if err := am.storage.SaveSession(session); err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// unknownUserHash returns a hash of a random password, made at the same
+// cost as real ones on first use.
+func (am *AccountManager) unknownUserHash() []byte {
+	am.dummyHashOnce.Do(func() {
+		am.dummyHash, _ = bcrypt.GenerateFromPassword([]byte(utils.GenerateToken(16)), bcrypt.DefaultCost)
+	})
+	return am.dummyHash
+}
+
+// validAvatarURL reports whether u is empty or an absolute http or https URL
+// of acceptable length.
+func validAvatarURL(u string) bool {
+	if u == "" {
+		return true
+	}
+	if len(u) > maxAvatarURLLength {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// Logout ends the session identified by token.
+func (am *AccountManager) Logout(token string) error {
+	return am.storage.DeleteSession(hashToken(token))
+}
+
+// Authenticate returns the user owning a valid session token.
+func (am *AccountManager) Authenticate(token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrNotAuthenticated
+	}
+	session, err := am.storage.GetSession(hashToken(token))
+	if err != nil {
+		return nil, ErrNotAuthenticated
+	}
+	if session.Expired(am.clock()) {
+		am.storage.DeleteSession(session.TokenHash)
+		return nil, ErrNotAuthenticated
+	}
+	return am.storage.GetUser(session.UserID)
+}
+
+// PurgeExpiredSessions removes the sessions that have expired, which would
+// otherwise stay in storage until their token is presented again, and
+// returns how many there were.
+func (am *AccountManager) PurgeExpiredSessions() (int, error) {
+	return am.storage.DeleteExpiredSessions(am.clock())
+}
+
+// GetUser looks up an account by ID.
+func (am *AccountManager) GetUser(userID string) (*models.User, error) {
+	return am.storage.GetUser(userID)
+}
+
+// IsAdmin reports whether token is the configured admin token. Admin access
+// is disabled when no admin token is configured.
+func (am *AccountManager) IsAdmin(token string) bool {
+	return am.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(am.config.AdminToken)) == 1
+}
+
+// TokenFromRequest extracts a session token from the Authorization header, or
+// from the token query parameter for WebSocket clients that cannot set headers.
+func TokenFromRequest(r *http.Request) string {
+	if token := TokenFromHeader(r.Header); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// TokenFromHeader extracts a session token from an Authorization: Bearer header.
+func TokenFromHeader(h http.Header) string {
+	if value := h.Get("Authorization"); strings.HasPrefix(value, "Bearer ") {
+		return strings.TrimPrefix(value, "Bearer ")
+	}
+	return ""
+}
+
+// UserFromRequest returns the logged-in user for a request, or nil for guests.
+func (am *AccountManager) UserFromRequest(r *http.Request) *models.User {
+	user, err := am.Authenticate(TokenFromRequest(r))
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,182 @@
+// internal/cors/cors.go
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/logging"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Policy decides which browser origins may use the server. It answers CORS
+// preflights, adds CORS headers to responses and checks WebSocket upgrades,
+// so that HTTP and WebSocket clients are held to the same allowlist.
+type Policy struct {
+	config   config.CORSConfig
+	allowAll bool
+	exact    []string // lower-case origins such as https://example.com
+	suffixes []string // lower-case wildcard tails such as https://.example.com
+
+	rejectedRequests atomic.Uint64
+	rejectedUpgrades atomic.Uint64
+}
+
+// Stats counts the requests refused because of their origin.
+type Stats struct {
+	RejectedRequests uint64 `json:"rejected_requests"`
+	RejectedUpgrades uint64 `json:"rejected_upgrades"`
+}
+
+// NewPolicy creates a Policy enforcing cfg.
+func NewPolicy(cfg config.CORSConfig) *Policy {
+	p := &Policy{config: cfg}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+		switch {
+		case origin == "*":
+			p.allowAll = true
+		case strings.Contains(origin, "://*."):
+			// https://*.example.com matches https://<anything>.example.com
+			p.suffixes = append(p.suffixes, strings.Replace(origin, "://*.", "://.", 1))
+		default:
+			p.exact = append(p.exact, origin)
+		}
+	}
+	return p
+}
+
+// Allowed reports whether origin is on the allowlist.
+func (p *Policy) Allowed(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(p.exact, origin) {
+		return true
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok {
+		return false
+	}
+	for _, suffix := range p.suffixes {
+		suffixScheme, tail, _ := strings.Cut(suffix, "://")
+		if scheme == suffixScheme && len(host) > len(tail) && strings.HasSuffix(host, tail) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns how many requests and upgrades have been refused.
+func (p *Policy) Stats() Stats {
+	return Stats{
+		RejectedRequests: p.rejectedRequests.Load(),
+		RejectedUpgrades: p.rejectedUpgrades.Load(),
+	}
+}
+
+// Handler applies the policy to a route serving methods at path. Preflights
+// are answered here; requests from origins that are not allowed, or using a
+// method the route does not allow cross-origin, get a 403.
+// Requests without an Origin header, and same-origin requests, pass through.
+func (p *Policy) Handler(path string, methods []string, next http.Handler) http.Handler {
+	allowMethods, allowHeaders := p.routeOptions(path, methods)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !p.allowAll || p.config.AllowCredentials {
+			// The response depends on the Origin header, so caches must key on it.
+			w.Header().Add("Vary", "Origin")
+		}
+		// WebSocket upgrades are checked by CheckOrigin so they are counted separately.
+		if origin == "" || sameOrigin(r, origin) || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		method := r.Method
+		if preflight {
+			method = r.Header.Get("Access-Control-Request-Method")
+		}
+		if !p.Allowed(origin) || !slices.Contains(allowMethods, method) {
+			p.rejectedRequests.Add(1)
+			logging.FromContext(r.Context()).Info("Rejected cross-origin request", "method", method, "path", r.URL.Path, "origin", origin)
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		if p.allowAll && !p.config.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.config.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if len(p.config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.config.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowHeaders, ", "))
+		if p.config.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.config.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// CheckOrigin is the WebSocket upgrader's origin check. Clients that send no
+// Origin (non-browsers) and same-origin pages are always accepted; other
+// origins must be on the allowlist. A nil Policy only accepts those two.
+func (p *Policy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || sameOrigin(r, origin) {
+		return true
+	}
+	if p != nil && p.Allowed(origin) {
+		return true
+	}
+	if p != nil {
+		p.rejectedUpgrades.Add(1)
+	}
+	logging.FromContext(r.Context()).Info("Rejected WebSocket upgrade", "path", r.URL.Path, "origin", origin)
+	return false
+}
+
+// routeOptions returns the methods and headers to allow for a route, using
+// the last matching override in the configuration.
+func (p *Policy) routeOptions(path string, methods []string) ([]string, []string) {
+	allowMethods := append(slices.Clone(methods), http.MethodOptions)
+	allowHeaders := p.config.AllowedHeaders
+	for _, route := range p.config.Routes {
+		if !strings.HasPrefix(path, route.PathPrefix) {
+			continue
+		}
+		if len(route.Methods) > 0 {
+			allowMethods = route.Methods
+		}
+		if len(route.Headers) > 0 {
+			allowHeaders = route.Headers
+		}
+	}
+	return allowMethods, allowHeaders
+}
+
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,63 @@
+// internal/cors/cors_test.go
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/config"
+	"testing"
+)
+
+func TestDefaultPolicyAllowsSameOriginOnly(t *testing.T) {
+	cfg := config.Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default configuration is invalid: %v", err)
+	}
+	p := NewPolicy(cfg.CORS)
+	handler := p.Handler("/rooms", []string{http.MethodGet}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusOK},
+		{"http://game.example", http.StatusOK}, // same origin as the request's host
+		{"https://evil.example", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://game.example/rooms", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("Origin %q: status %d, want %d", tt.origin, w.Code, tt.want)
+		}
+		if upgrade := p.CheckOrigin(r); upgrade != (tt.want == http.StatusOK) {
+			t.Errorf("Origin %q: CheckOrigin = %v", tt.origin, upgrade)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{nil, "https://app.example.com", false},
+		{[]string{"*"}, "https://anything.example", true},
+		{[]string{"https://app.example.com"}, "https://APP.example.com", true},
+		{[]string{"https://app.example.com"}, "http://app.example.com", false},
+		{[]string{"https://*.example.com"}, "https://a.b.example.com", true},
+		{[]string{"https://*.example.com"}, "https://example.com", false},
+		{[]string{"https://*.example.com"}, "https://evilexample.com", false},
+	}
+	for _, tt := range tests {
+		p := NewPolicy(config.CORSConfig{AllowedOrigins: tt.allowed})
+		if got := p.Allowed(tt.origin); got != tt.want {
+			t.Errorf("allowlist %v: Allowed(%q) = %v, want %v", tt.allowed, tt.origin, got, tt.want)
+		}
+	}
+}
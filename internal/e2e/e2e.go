@@ -0,0 +1,344 @@
+// internal/e2e/e2e.go
+
+// Package e2e is an end-to-end conformance suite for the game server. Run
+// serves a complete server through httptest and plays whole games with real
+// clients, asserting every message each client receives, in order.
+//
+// The suite is written against the Storage and Transport a Backend supplies,
+// so a new storage backend or client transport passes the same games as the
+// in-memory store over WebSocket:
+//
+//	func TestConformance(t *testing.T) {
+//		e2e.Run(t, e2e.Backend{Storage: func(t *testing.T) storage.Storage { return newStore(t) }})
+//	}
+//
+// Tests in package storage itself must be in the external storage_test
+// package, as this package imports the server.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/server"
+	"sync"
+	"testing"
+	"time"
+)
+
+// frameTimeout is how long a client waits for each message it expects, and
+// quietPeriod how long it listens for messages it should not get.
+const (
+	frameTimeout = 5 * time.Second
+	quietPeriod  = 100 * time.Millisecond
+)
+
+// epoch is the servers' fixed clock, so lines and stories carry known times.
+var epoch = time.Date(2024, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+// Backend is what the suite runs against. Zero fields fall back to the defaults.
+type Backend struct {
+	// Storage returns an empty store for one test; default: storage.NewMemoryStorage.
+	// A test that restarts the server builds both servers over the same store.
+	Storage func(t *testing.T) storage.Storage
+	// Transport connects the players; default: WebSocket.
+	Transport Transport
+}
+
+// Transport connects a player to a room the way a client would.
+type Transport interface {
+	Dial(ctx context.Context, baseURL string, player Player) (Conn, error)
+}
+
+// Player is a player in a room with the tokens they were given on creating
+// or joining it, which they present when they connect.
+type Player struct {
+	RoomID string
+	Name   string
+	Token  string
+	// HostToken is only set for the host.
+	HostToken string
+}
+
+// Conn is one client's connection to a room.
+type Conn interface {
+	// Send sends a client message, such as SUBMIT_LINE.
+	Send(msg models.Message) error
+	// Receive returns the next message from the server exactly as it was
+	// sent, or an error once the connection has closed.
+	Receive(ctx context.Context) (string, error)
+	// Close hangs up, as a player closing the game would.
+	Close() error
+}
+
+// Run plays every game of the suite against b, each in its own server.
+func Run(t *testing.T, b Backend) {
+	if b.Storage == nil {
+		b.Storage = func(*testing.T) storage.Storage { return storage.NewMemoryStorage() }
+	}
+	if b.Transport == nil {
+		b.Transport = WebSocket{}
+	}
+	for _, game := range games {
+		t.Run(game.name, func(t *testing.T) {
+			t.Parallel()
+			game.play(newEnv(t, b))
+		})
+	}
+}
+
+// env is one test's server and the store it keeps its rooms and stories in.
+type env struct {
+	t         *testing.T
+	transport Transport
+	store     storage.Storage
+	server    *server.Server
+	http      *httptest.Server
+
+	roomIDs int
+	players map[string]Player // by "room ID/name"
+}
+
+func newEnv(t *testing.T, b Backend) *env {
+	e := &env{t: t, transport: b.Transport, store: b.Storage(t), players: make(map[string]Player)}
+	e.start()
+	t.Cleanup(e.stop)
+	return e
+}
+
+// start serves a new server over the env's store, restoring any saved rooms.
+func (e *env) start() {
+	e.t.Helper()
+	cfg := config.Default()
+	cfg.Metrics.Enabled = false
+	srv, err := server.New(&cfg, server.Options{
+		Storage:   e.store,
+		Clock:     func() time.Time { return epoch },
+		NewRoomID: e.newRoomID,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		e.t.Fatalf("server.New: %v", err)
+	}
+	e.server = srv
+	e.http = httptest.NewServer(srv.Handler())
+}
+
+func (e *env) stop() {
+	e.http.Close()
+	e.server.Close()
+}
+
+// restart shuts the server down the way a deploy would and starts a new one
+// over the same store.
+func (e *env) restart() {
+	e.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+	defer cancel()
+	if err := e.server.Shutdown(ctx); err != nil {
+		e.t.Fatalf("Shutdown: %v", err)
+	}
+	e.http.Close()
+	e.start()
+}
+
+// newRoomID numbers rooms in the order they are created. The test creates
+// them one request at a time, so the count needs no lock.
+func (e *env) newRoomID() string {
+	e.roomIDs++
+	return fmt.Sprintf("ROOM%02d", e.roomIDs)
+}
+
+// createRoom creates a room hosted by host and returns its ID.
+func (e *env) createRoom(host, storyName string) string {
+	e.t.Helper()
+	var resp struct {
+		RoomID      string `json:"room_id"`
+		HostToken   string `json:"host_token"`
+		PlayerToken string `json:"player_token"`
+	}
+	e.post("/api/v1/rooms", map[string]interface{}{"player_name": host, "story_name": storyName}, http.StatusCreated, &resp)
+	e.players[resp.RoomID+"/"+host] = Player{RoomID: resp.RoomID, Name: host, Token: resp.PlayerToken, HostToken: resp.HostToken}
+	return resp.RoomID
+}
+
+// join adds a player to a room.
+func (e *env) join(roomID, player string) {
+	e.t.Helper()
+	var resp struct {
+		PlayerToken string `json:"player_token"`
+	}
+	e.post("/api/v1/rooms/"+url.PathEscape(roomID)+"/players", map[string]string{"player_name": player}, http.StatusCreated, &resp)
+	e.players[roomID+"/"+player] = Player{RoomID: roomID, Name: player, Token: resp.PlayerToken}
+}
+
+// record returns a finished story as the API serves it.
+func (e *env) record(roomID string) *models.StoryRecord {
+	e.t.Helper()
+	resp, err := e.http.Client().Get(e.http.URL + "/api/v1/rooms/" + url.PathEscape(roomID) + "/record")
+	if err != nil {
+		e.t.Fatalf("GET record: %v", err)
+	}
+	defer resp.Body.Close()
+	var record models.StoryRecord
+	e.decode(resp, http.StatusOK, &record)
+	return &record
+}
+
+func (e *env) post(path string, body interface{}, want int, out interface{}) {
+	e.t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	resp, err := e.http.Client().Post(e.http.URL+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		e.t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	e.decode(resp, want, out)
+}
+
+func (e *env) decode(resp *http.Response, want int, out interface{}) {
+	e.t.Helper()
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		e.t.Fatalf("%s %s: got %s, want %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, want, body)
+	}
+	if out == nil {
+		return
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		e.t.Fatalf("%s %s: %v", resp.Request.Method, resp.Request.URL.Path, err)
+	}
+}
+
+// connect opens a player's connection to a room.
+func (e *env) connect(roomID, player string) *client {
+	e.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+	defer cancel()
+	conn, err := e.transport.Dial(ctx, e.http.URL, e.players[roomID+"/"+player])
+	if err != nil {
+		e.t.Fatalf("%s: connect to %s: %v", player, roomID, err)
+	}
+	c := &client{t: e.t, name: player, conn: conn}
+	e.t.Cleanup(func() { conn.Close() })
+	return c
+}
+
+// client is a player's connection with the messages it has been sent.
+type client struct {
+	t    *testing.T
+	name string
+	conn Conn
+}
+
+// send sends a message of type typ.
+func (c *client) send(typ, content string) {
+	c.t.Helper()
+	if err := c.conn.Send(models.Message{Type: typ, Content: content}); err != nil {
+		c.t.Fatalf("%s: send %s: %v", c.name, typ, err)
+	}
+}
+
+// close hangs up the client's connection.
+func (c *client) close() {
+	c.t.Helper()
+	if err := c.conn.Close(); err != nil {
+		c.t.Fatalf("%s: close: %v", c.name, err)
+	}
+}
+
+// expect checks that the next messages the client receives are frames, in order.
+func (c *client) expect(frames ...string) {
+	c.t.Helper()
+	for i, want := range frames {
+		ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+		got, err := c.conn.Receive(ctx)
+		cancel()
+		if err != nil {
+			c.t.Fatalf("%s: message %d of %d: want %s, got error: %v", c.name, i+1, len(frames), want, err)
+		}
+		if got != want {
+			c.t.Fatalf("%s: message %d of %d:\n got: %s\nwant: %s", c.name, i+1, len(frames), got, want)
+		}
+	}
+}
+
+// expectClosed checks that the server closes the connection without sending
+// anything more.
+func (c *client) expectClosed() {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+	defer cancel()
+	if got, err := c.conn.Receive(ctx); err == nil {
+		c.t.Fatalf("%s: want the connection closed, got %s", c.name, got)
+	} else if ctx.Err() != nil {
+		c.t.Fatalf("%s: connection still open after %s", c.name, frameTimeout)
+	}
+}
+
+// expectQuiet checks that the client has been sent nothing more.
+func (c *client) expectQuiet() {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), quietPeriod)
+	defer cancel()
+	if got, err := c.conn.Receive(ctx); err == nil {
+		c.t.Fatalf("%s: unexpected message %s", c.name, got)
+	}
+}
+
+// everyone is the clients in a room, to expect the same broadcasts on all of them.
+type everyone []*client
+
+func (cs everyone) expect(frames ...string) {
+	cs[0].t.Helper()
+	var wg sync.WaitGroup
+	failed := make(chan string, len(cs))
+	for _, c := range cs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Receive in parallel, but report on the test goroutine.
+			for i, want := range frames {
+				ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+				got, err := c.conn.Receive(ctx)
+				cancel()
+				if err != nil {
+					failed <- fmt.Sprintf("%s: message %d of %d: want %s, got error: %v", c.name, i+1, len(frames), want, err)
+					return
+				}
+				if got != want {
+					failed <- fmt.Sprintf("%s: message %d of %d:\n got: %s\nwant: %s", c.name, i+1, len(frames), got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(failed)
+	if len(failed) == 0 {
+		return
+	}
+	for msg := range failed {
+		cs[0].t.Error(msg)
+	}
+	cs[0].t.FailNow()
+}
+
+func (cs everyone) expectQuiet() {
+	cs[0].t.Helper()
+	for _, c := range cs {
+		c.expectQuiet()
+	}
+}
@@ -0,0 +1,10 @@
+// internal/e2e/e2e_test.go
+package e2e
+
+import "testing"
+
+// TestMemoryWebSocket runs the suite on the default backend: the in-memory
+// store, with players on WebSocket.
+func TestMemoryWebSocket(t *testing.T) {
+	Run(t, Backend{})
+}
@@ -0,0 +1,255 @@
+// internal/e2e/games.go
+package e2e
+
+import (
+	"encoding/json"
+	"storytelling-backend/internal/models"
+	"strings"
+)
+
+// games are the suite's scenarios. Each plays in a fresh server.
+var games = []struct {
+	name string
+	play func(e *env)
+}{
+	{"FullGame", playFullGame},
+	{"DisconnectMidGame", playDisconnectMidGame},
+	{"ReconnectReplacesConnection", playReconnect},
+	{"RestartMidGame", playRestartMidGame},
+}
+
+// playFullGame plays a game from an empty room to the stored story, with the
+// mistakes players make along the way.
+func playFullGame(e *env) {
+	roomID, room := e.lobby("The Lighthouse", "Alice", "Bob", "Carol")
+	alice, bob, carol := room[0], room[1], room[2]
+
+	bob.send("START_GAME", "")
+	bob.expect(errorFrame("Only the host can start the game"))
+	alice.send("START_GAME", "")
+	room.expect(started("Alice"))
+
+	bob.send("SUBMIT_LINE", "Out of turn.")
+	bob.expect(errorFrame(models.ErrNotYourTurn.Error()))
+
+	lines := []string{"The lamp went dark at midnight.", "A ship's bell answered from the fog.", "Nobody rang it."}
+	alice.send("SUBMIT_LINE", lines[0])
+	room.expect(lineAdded("Alice", lines[:1]...), turn("Bob"))
+	bob.send("SUBMIT_LINE", lines[1])
+	room.expect(lineAdded("Bob", lines[:2]...), turn("Carol"))
+	carol.send("SUBMIT_LINE", lines[2])
+
+	record := finished(roomID, "The Lighthouse", "Alice", []string{"Alice", "Bob", "Carol"},
+		[]models.StoryLine{line(1, "Alice", lines[0]), line(2, "Bob", lines[1]), line(3, "Carol", lines[2])},
+		models.Award{Award: models.AwardMostProlific, Player: "Alice", Count: 1},
+		models.Award{Award: models.AwardLongestLine, Player: "Bob", LineID: 2, Count: len(lines[1])},
+	)
+	room.expect(lineAdded("Carol", lines...), gameOver(record))
+
+	carol.send("SUBMIT_LINE", "One more.")
+	carol.expect(errorFrame(models.ErrGameNotActive.Error()))
+	room.expectQuiet()
+	e.checkRecord(roomID, record)
+}
+
+// playDisconnectMidGame has the player whose turn it is leave; the turn moves
+// on without them, and when they come back they take the last turn.
+func playDisconnectMidGame(e *env) {
+	roomID, room := e.lobby("The Orchard", "Alice", "Bob", "Carol")
+	alice, bob, carol := room[0], room[1], room[2]
+	alice.send("START_GAME", "")
+	room.expect(started("Alice"))
+
+	lines := []string{"The oldest tree bore silver apples.", "Whoever ate one forgot their name.", "Bob ate two."}
+	alice.send("SUBMIT_LINE", lines[0])
+	room.expect(lineAdded("Alice", lines[:1]...), turn("Bob"))
+
+	bob.close()
+	room = everyone{alice, carol}
+	room.expect(left("Bob"), turn("Carol"))
+
+	e.join(roomID, "Bob")
+	bob = e.connect(roomID, "Bob")
+	bob.expect(chatHistory())
+	room = everyone{alice, carol, bob}
+	room.expect(joined("Bob"))
+
+	carol.send("SUBMIT_LINE", lines[1])
+	room.expect(lineAdded("Carol", lines[:2]...), turn("Bob"))
+	bob.send("SUBMIT_LINE", lines[2])
+
+	record := finished(roomID, "The Orchard", "Alice", []string{"Alice", "Carol", "Bob"},
+		[]models.StoryLine{line(1, "Alice", lines[0]), line(2, "Carol", lines[1]), line(3, "Bob", lines[2])},
+		models.Award{Award: models.AwardMostProlific, Player: "Alice", Count: 1},
+		models.Award{Award: models.AwardLongestLine, Player: "Alice", LineID: 1, Count: len(lines[0])},
+	)
+	room.expect(lineAdded("Bob", lines...), gameOver(record))
+	room.expectQuiet()
+	e.checkRecord(roomID, record)
+}
+
+// playReconnect has a player connect again while still connected, as a
+// client does after a network change; the new connection takes over and
+// the player does not leave the game.
+func playReconnect(e *env) {
+	roomID, room := e.lobby("The Tunnel", "Alice", "Bob")
+	alice, bob := room[0], room[1]
+	alice.send("START_GAME", "")
+	room.expect(started("Alice"))
+
+	lines := []string{"The tunnel had no end.", "So they built one."}
+	alice.send("SUBMIT_LINE", lines[0])
+	room.expect(lineAdded("Alice", lines[:1]...), turn("Bob"))
+
+	newBob := e.connect(roomID, "Bob")
+	bob.expectClosed()
+	newBob.expect(chatHistory())
+	room = everyone{alice, newBob}
+	room.expect(joined("Bob"))
+
+	newBob.send("SUBMIT_LINE", lines[1])
+	record := finished(roomID, "The Tunnel", "Alice", []string{"Alice", "Bob"},
+		[]models.StoryLine{line(1, "Alice", lines[0]), line(2, "Bob", lines[1])},
+		models.Award{Award: models.AwardMostProlific, Player: "Alice", Count: 1},
+		models.Award{Award: models.AwardLongestLine, Player: "Alice", LineID: 1, Count: len(lines[0])},
+	)
+	room.expect(lineAdded("Bob", lines...), gameOver(record))
+	room.expectQuiet()
+	e.checkRecord(roomID, record)
+}
+
+// playRestartMidGame restarts the server mid-game. The room is saved to and
+// restored from storage, and its players reconnect to finish the story.
+func playRestartMidGame(e *env) {
+	roomID, room := e.lobby("The Harbour", "Alice", "Bob", "Carol")
+	alice := room[0]
+	alice.send("START_GAME", "")
+	room.expect(started("Alice"))
+
+	lines := []string{"Every boat came home at once.", "None of them had left.", "The harbourmaster counted twice."}
+	alice.send("SUBMIT_LINE", lines[0])
+	room.expect(lineAdded("Alice", lines[:1]...), turn("Bob"))
+
+	e.restart()
+	room.expect(restarting(roomID))
+	for _, c := range room {
+		c.expectClosed()
+	}
+	if saved, err := e.store.ListRooms(); err != nil || len(saved) != 0 {
+		e.t.Fatalf("after restoring, the store still has %d saved rooms (error %v)", len(saved), err)
+	}
+
+	room = e.connectAll(roomID, "Alice", "Bob", "Carol")
+	bob, carol := room[1], room[2]
+	bob.send("SUBMIT_LINE", lines[1])
+	room.expect(lineAdded("Bob", lines[:2]...), turn("Carol"))
+	carol.send("SUBMIT_LINE", lines[2])
+
+	record := finished(roomID, "The Harbour", "Alice", []string{"Alice", "Bob", "Carol"},
+		[]models.StoryLine{line(1, "Alice", lines[0]), line(2, "Bob", lines[1]), line(3, "Carol", lines[2])},
+		models.Award{Award: models.AwardMostProlific, Player: "Alice", Count: 1},
+		models.Award{Award: models.AwardLongestLine, Player: "Carol", LineID: 3, Count: len(lines[2])},
+	)
+	room.expect(lineAdded("Carol", lines...), gameOver(record))
+	room.expectQuiet()
+	e.checkRecord(roomID, record)
+}
+
+// lobby creates a room hosted by the first player, joins the others and
+// connects everyone in order.
+func (e *env) lobby(storyName string, players ...string) (string, everyone) {
+	e.t.Helper()
+	roomID := e.createRoom(players[0], storyName)
+	for _, player := range players[1:] {
+		e.join(roomID, player)
+	}
+	return roomID, e.connectAll(roomID, players...)
+}
+
+// connectAll connects players already in a room one by one. Each is sent
+// the chat history, then everyone connected so far hears that they joined.
+func (e *env) connectAll(roomID string, players ...string) everyone {
+	e.t.Helper()
+	var room everyone
+	for _, player := range players {
+		c := e.connect(roomID, player)
+		c.expect(chatHistory())
+		room = append(room, c)
+		room.expect(joined(player))
+	}
+	return room
+}
+
+// checkRecord checks the finished story both in storage and from the API.
+func (e *env) checkRecord(roomID string, want *models.StoryRecord) {
+	e.t.Helper()
+	stored, err := e.store.GetStory(roomID)
+	if err != nil {
+		e.t.Fatalf("GetStory(%s): %v", roomID, err)
+	}
+	wantJSON := marshal(want)
+	if got := marshal(stored); got != wantJSON {
+		e.t.Errorf("stored story:\n got: %s\nwant: %s", got, wantJSON)
+	}
+	if got := marshal(e.record(roomID)); got != wantJSON {
+		e.t.Errorf("story from the API:\n got: %s\nwant: %s", got, wantJSON)
+	}
+}
+
+func finished(roomID, title, host string, players []string, lines []models.StoryLine, awards ...models.Award) *models.StoryRecord {
+	return &models.StoryRecord{
+		RoomID:      roomID,
+		Title:       title,
+		Host:        host,
+		Status:      models.StatusCompleted,
+		Players:     players,
+		Lines:       lines,
+		Awards:      awards,
+		CompletedAt: epoch,
+	}
+}
+
+func line(id int, author, text string) models.StoryLine {
+	return models.StoryLine{ID: id, Author: author, Text: text, WrittenAt: epoch}
+}
+
+// The messages players receive, as the server sends them.
+
+func joined(player string) string  { return player + " joined the room." }
+func left(player string) string    { return player + " has left the game." }
+func started(player string) string { return "Game started! It's " + player + "'s turn." }
+func turn(player string) string {
+	return marshal(models.Message{Type: "TURN", Content: player + "'s turn"})
+}
+
+func lineAdded(player string, story ...string) string {
+	return player + " added a line to the story. \nNew story: Story: " + strings.Join(story, " ")
+}
+
+func chatHistory() string {
+	return marshal(models.Message{Type: "CHAT_HISTORY", Data: []models.ChatMessage{}})
+}
+
+func gameOver(record *models.StoryRecord) string {
+	return marshal(models.Message{Type: "END_GAME", Content: "Game over!", Data: record})
+}
+
+func restarting(roomID string) string {
+	return marshal(models.Message{
+		Type:    models.MessageServerRestarting,
+		Content: "The server is restarting. Reconnect to carry on with the story.",
+		Data:    map[string]string{"room_id": roomID},
+	})
+}
+
+func errorFrame(reason string) string {
+	return marshal(models.Message{Type: "ERROR", Content: reason})
+}
+
+func marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
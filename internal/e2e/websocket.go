@@ -0,0 +1,98 @@
+// internal/e2e/websocket.go
+package e2e
+
+import (
+	"context"
+	"net/url"
+	"storytelling-backend/internal/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket connects players over /ws, as the web client does.
+type WebSocket struct{}
+
+// Dial opens a player's WebSocket to a room.
+func (WebSocket) Dial(ctx context.Context, baseURL string, player Player) (Conn, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = "/ws"
+	query := url.Values{"room_id": {player.RoomID}, "player_name": {player.Name}, "player_token": {player.Token}}
+	if player.HostToken != "" {
+		query.Set("host_token", player.HostToken)
+	}
+	u.RawQuery = query.Encode()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &wsConn{conn: conn, frames: make(chan string, 64), done: make(chan struct{})}
+	go c.read()
+	return c, nil
+}
+
+// wsConn reads in the background so that Receive can give up on a context
+// without breaking the connection.
+type wsConn struct {
+	conn   *websocket.Conn
+	frames chan string
+	err    error // why reading stopped; set before frames is closed
+	done   chan struct{}
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func (c *wsConn) read() {
+	defer close(c.done)
+	defer close(c.frames)
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.frames <- string(data)
+	}
+}
+
+func (c *wsConn) Send(msg models.Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *wsConn) Receive(ctx context.Context) (string, error) {
+	select {
+	case frame, ok := <-c.frames:
+		if !ok {
+			return "", c.err
+		}
+		return frame, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close sends a normal close frame and waits for the reader to stop.
+func (c *wsConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.writeMu.Lock()
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		c.writeMu.Unlock()
+		c.conn.Close()
+		// Drain, so that a reader blocked on a full buffer can stop.
+		go func() {
+			for range c.frames {
+			}
+		}()
+		<-c.done
+	})
+	return nil
+}
@@ -0,0 +1,350 @@
+// internal/gallery/gallery.go
+package gallery
+
+import (
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sort orders accepted by List.
+const (
+	SortNewest    = "newest"
+	SortMostLiked = "most_liked"
+)
+
+var (
+	ErrNotHost           = errors.New("only the host can publish the story")
+	ErrNotPlayer         = errors.New("player did not take part in this story")
+	ErrWrongAccount      = errors.New("this player belongs to a registered account; log in to act as them")
+	ErrWrongPlayerToken  = errors.New("send the player token you were given on joining to act as this player")
+	ErrNotCompleted      = errors.New("only completed stories can be published")
+	ErrAlreadyRequested  = errors.New("story has already been submitted to the gallery")
+	ErrNotPending        = errors.New("story is not waiting for consent")
+	ErrNotPublished      = errors.New("story is not published")
+	ErrLoginRequired     = errors.New("log in to like stories")
+	ErrUnknownSortOption = errors.New("sort must be newest or most_liked")
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Gallery manages publishing finished stories and browsing published ones.
+type Gallery struct {
+	storage storage.Storage
+	clock   models.Clock
+	mutex   sync.Mutex // serialises changes to stored entries
+}
+
+// NewGallery creates a Gallery backed by the given storage. A nil clock means
+// time.Now.
+func NewGallery(store storage.Storage, clock models.Clock) *Gallery {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Gallery{storage: store, clock: clock}
+}
+
+// Actor is the player a publish request or vote comes from, with the account
+// the caller is logged in as ("" for none) and the player token they sent.
+type Actor struct {
+	PlayerName  string
+	UserID      string
+	PlayerToken string
+}
+
+// RequestPublish starts publishing a finished story. Every player must consent;
+// the host's request counts as their vote. A previously rejected story may be
+// submitted again.
+func (g *Gallery) RequestPublish(record *models.StoryRecord, actor Actor, title, genre string) (*models.PublishedStory, error) {
+	if record.Status != models.StatusCompleted {
+		return nil, ErrNotCompleted
+	}
+	if err := checkActor(record, actor); err != nil {
+		return nil, err
+	}
+	playerName := actor.PlayerName
+	if playerName != record.Host {
+		return nil, ErrNotHost
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	entry, err := g.storage.GetPublishedStoryByRoom(record.RoomID)
+	if err == nil && entry.Status != models.PublishRejected {
+		return nil, ErrAlreadyRequested
+	}
+	if entry == nil {
+		entry = &models.PublishedStory{RoomID: record.RoomID}
+	}
+	if title = strings.TrimSpace(title); title == "" {
+		title = record.Title
+	}
+	if title == "" {
+		title = "Untitled story"
+	}
+	if entry.Slug == "" {
+		entry.Slug = slugify(title) + "-" + utils.GenerateToken(3)
+	}
+	entry.Title = title
+	entry.Genre = strings.ToLower(strings.TrimSpace(genre))
+	entry.Story = record
+	entry.Status = models.PublishPending
+	entry.Required = append([]string{}, record.Players...)
+	entry.Consent = map[string]bool{playerName: true}
+	entry.RequestedAt = g.clock()
+	entry.PublishedAt = nil
+	g.settle(entry)
+
+	if err := g.storage.SavePublishedStory(entry); err != nil {
+		return nil, err
+	}
+	return snapshot(entry), nil
+}
+
+// Vote records a player's consent (or refusal) to publish. A single refusal
+// rejects the request; once everyone has agreed the story is published.
+func (g *Gallery) Vote(roomID string, actor Actor, approve bool) (*models.PublishedStory, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	entry, err := g.storage.GetPublishedStoryByRoom(utils.NormalizeRoomCode(roomID))
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != models.PublishPending {
+		return nil, ErrNotPending
+	}
+	if err := checkActor(entry.Story, actor); err != nil {
+		return nil, err
+	}
+	entry.Consent[actor.PlayerName] = approve
+	g.settle(entry)
+
+	if err := g.storage.SavePublishedStory(entry); err != nil {
+		return nil, err
+	}
+	return snapshot(entry), nil
+}
+
+// Get returns a gallery entry by its permanent slug.
+func (g *Gallery) Get(slug string) (*models.PublishedStory, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	entry, err := g.storage.GetPublishedStory(slug)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(entry), nil
+}
+
+// GetByRoom returns the gallery entry for a room, whatever its status.
+func (g *Gallery) GetByRoom(roomID string) (*models.PublishedStory, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	entry, err := g.storage.GetPublishedStoryByRoom(utils.NormalizeRoomCode(roomID))
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(entry), nil
+}
+
+// List returns published stories, optionally filtered by genre, in the given order.
+func (g *Gallery) List(sortBy, genre string, offset, limit int) ([]*models.PublishedStory, error) {
+	if sortBy == "" {
+		sortBy = SortNewest
+	}
+	if sortBy != SortNewest && sortBy != SortMostLiked {
+		return nil, ErrUnknownSortOption
+	}
+	all, err := g.published()
+	if err != nil {
+		return nil, err
+	}
+
+	genre = strings.ToLower(strings.TrimSpace(genre))
+	stories := []*models.PublishedStory{}
+	for _, s := range all {
+		if genre == "" || s.Genre == genre {
+			stories = append(stories, s)
+		}
+	}
+	sort.Slice(stories, func(i, j int) bool {
+		if sortBy == SortMostLiked && stories[i].Likes != stories[j].Likes {
+			return stories[i].Likes > stories[j].Likes
+		}
+		return stories[i].PublishedAt.After(*stories[j].PublishedAt)
+	})
+	return page(stories, offset, limit), nil
+}
+
+// Search returns published stories whose title or lines contain every word of
+// the query, case-insensitively, newest first.
+func (g *Gallery) Search(query string, offset, limit int) ([]*models.PublishedStory, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	all, err := g.published()
+	if err != nil {
+		return nil, err
+	}
+
+	stories := []*models.PublishedStory{}
+	for _, s := range all {
+		text := strings.ToLower(s.Title + "\n" + strings.Join(s.Story.Text(), "\n"))
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(text, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			stories = append(stories, s)
+		}
+	}
+	sort.Slice(stories, func(i, j int) bool { return stories[i].PublishedAt.After(*stories[j].PublishedAt) })
+	return page(stories, offset, limit), nil
+}
+
+// Like records a registered user's like; liking twice has no further effect.
+func (g *Gallery) Like(slug, userID string) (*models.PublishedStory, error) {
+	return g.setLike(slug, userID, true)
+}
+
+// Unlike removes a registered user's like.
+func (g *Gallery) Unlike(slug, userID string) (*models.PublishedStory, error) {
+	return g.setLike(slug, userID, false)
+}
+
+func (g *Gallery) setLike(slug, userID string, like bool) (*models.PublishedStory, error) {
+	if userID == "" {
+		return nil, ErrLoginRequired
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	entry, err := g.storage.GetPublishedStory(slug)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != models.PublishPublished {
+		return nil, ErrNotPublished
+	}
+	liked := -1
+	for i, id := range entry.LikedBy {
+		if id == userID {
+			liked = i
+			break
+		}
+	}
+	switch {
+	case like && liked < 0:
+		entry.LikedBy = append(entry.LikedBy, userID)
+	case !like && liked >= 0:
+		entry.LikedBy = append(entry.LikedBy[:liked], entry.LikedBy[liked+1:]...)
+	}
+	entry.Likes = len(entry.LikedBy)
+
+	if err := g.storage.SavePublishedStory(entry); err != nil {
+		return nil, err
+	}
+	return snapshot(entry), nil
+}
+
+// published returns snapshots of every published entry.
+func (g *Gallery) published() ([]*models.PublishedStory, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	all, err := g.storage.ListPublishedStories()
+	if err != nil {
+		return nil, err
+	}
+	published := make([]*models.PublishedStory, 0, len(all))
+	for _, s := range all {
+		if s.Status == models.PublishPublished {
+			published = append(published, snapshot(s))
+		}
+	}
+	return published, nil
+}
+
+// snapshot copies an entry so it can be read after the gallery lock is released.
+func snapshot(entry *models.PublishedStory) *models.PublishedStory {
+	c := *entry
+	c.Consent = make(map[string]bool, len(entry.Consent))
+	for name, approved := range entry.Consent {
+		c.Consent[name] = approved
+	}
+	c.LikedBy = append([]string{}, entry.LikedBy...)
+	return &c
+}
+
+// settle moves a pending entry to rejected or published once the votes decide it.
+func (g *Gallery) settle(entry *models.PublishedStory) {
+	for _, name := range entry.Required {
+		approved, voted := entry.Consent[name]
+		if voted && !approved {
+			entry.Status = models.PublishRejected
+			return
+		}
+		if !voted {
+			return
+		}
+	}
+	now := g.clock()
+	entry.Status = models.PublishPublished
+	entry.PublishedAt = &now
+}
+
+// checkActor verifies the actor took part in the story and is who they claim
+// to be: logged in as the player's account or, for a guest, holding the
+// player token they were given on joining.
+func checkActor(record *models.StoryRecord, actor Actor) error {
+	if !slices.Contains(record.Players, actor.PlayerName) {
+		return ErrNotPlayer
+	}
+	if owner := record.PlayerUsers[actor.PlayerName]; owner != "" {
+		if owner != actor.UserID {
+			return ErrWrongAccount
+		}
+		return nil
+	}
+	token := record.PlayerTokens[actor.PlayerName]
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(actor.PlayerToken)) != 1 {
+		return ErrWrongPlayerToken
+	}
+	return nil
+}
+
+func slugify(title string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "story"
+	}
+	return slug
+}
+
+func page(stories []*models.PublishedStory, offset, limit int) []*models.PublishedStory {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(stories) {
+		return []*models.PublishedStory{}
+	}
+	stories = stories[offset:]
+	if limit > 0 && limit < len(stories) {
+		stories = stories[:limit]
+	}
+	return stories
+}
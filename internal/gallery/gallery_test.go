@@ -0,0 +1,63 @@
+// internal/gallery/gallery_test.go
+package gallery
+
+import (
+	"errors"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/storage"
+	"testing"
+)
+
+// newTestRecord is a completed story by a guest host, Alice, a guest, Bob, and
+// Carol, who played with an account.
+func newTestRecord() *models.StoryRecord {
+	return &models.StoryRecord{
+		RoomID:       "TALES",
+		Title:        "The Lighthouse",
+		Host:         "Alice",
+		Status:       models.StatusCompleted,
+		Players:      []string{"Alice", "Bob", "Carol"},
+		PlayerUsers:  map[string]string{"Carol": "user-carol"},
+		PlayerTokens: map[string]string{"Alice": "alice-token", "Bob": "bob-token", "Carol": "carol-token"},
+	}
+}
+
+func TestGuestsNeedTheirPlayerToken(t *testing.T) {
+	g := NewGallery(storage.NewMemoryStorage(), nil)
+	record := newTestRecord()
+
+	if _, err := g.RequestPublish(record, Actor{PlayerName: "Alice"}, "", ""); !errors.Is(err, ErrWrongPlayerToken) {
+		t.Fatalf("publish without a token = %v, want %v", err, ErrWrongPlayerToken)
+	}
+	if _, err := g.RequestPublish(record, Actor{PlayerName: "Alice", PlayerToken: "bob-token"}, "", ""); !errors.Is(err, ErrWrongPlayerToken) {
+		t.Fatalf("publish with another player's token = %v, want %v", err, ErrWrongPlayerToken)
+	}
+	if _, err := g.RequestPublish(record, Actor{PlayerName: "Alice", PlayerToken: "alice-token"}, "", ""); err != nil {
+		t.Fatalf("publish with the host's token: %v", err)
+	}
+
+	votes := []struct {
+		actor Actor
+		want  error
+	}{
+		{Actor{PlayerName: "Bob"}, ErrWrongPlayerToken},
+		{Actor{PlayerName: "Bob", PlayerToken: "alice-token"}, ErrWrongPlayerToken},
+		{Actor{PlayerName: "Mallory", PlayerToken: "bob-token"}, ErrNotPlayer},
+		// Account players log in; their player token is not enough.
+		{Actor{PlayerName: "Carol", PlayerToken: "carol-token"}, ErrWrongAccount},
+		{Actor{PlayerName: "Bob", PlayerToken: "bob-token"}, nil},
+		{Actor{PlayerName: "Carol", UserID: "user-carol"}, nil},
+	}
+	for _, v := range votes {
+		if _, err := g.Vote("tales", v.actor, true); !errors.Is(err, v.want) {
+			t.Errorf("vote by %+v = %v, want %v", v.actor, err, v.want)
+		}
+	}
+	entry, err := g.GetByRoom("TALES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Status != models.PublishPublished {
+		t.Errorf("status = %s, want %s once everyone consented", entry.Status, models.PublishPublished)
+	}
+}
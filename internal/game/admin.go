@@ -0,0 +1,119 @@
+// internal/game/admin.go
+package game
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/pkg/utils"
+)
+
+// Ready reports whether the manager can take new games: it fails once the
+// server has begun to shut down or while storage cannot be reached.
+func (rm *RoomManager) Ready() error {
+	rm.roomsMutex.RLock()
+	draining := rm.draining
+	rm.roomsMutex.RUnlock()
+	if draining {
+		return ErrShuttingDown
+	}
+	if err := rm.storage.Ping(); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
+
+// RoomDetails returns every room with its players' and spectators'
+// connections, for administrators.
+func (rm *RoomManager) RoomDetails() []models.RoomDetails {
+	rooms := rm.allRooms()
+	details := make([]models.RoomDetails, 0, len(rooms))
+	for _, room := range rooms {
+		details = append(details, room.Details())
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].ID < details[j].ID })
+	return details
+}
+
+// RoomDetail returns one room with its players' and spectators' connections.
+func (rm *RoomManager) RoomDetail(roomID string) (models.RoomDetails, error) {
+	room, err := rm.GetRoom(roomID)
+	if err != nil {
+		return models.RoomDetails{}, err
+	}
+	return room.Details(), nil
+}
+
+// RoomEvents returns a room's event log, oldest first.
+func (rm *RoomManager) RoomEvents(roomID string) ([]models.LoggedEvent, error) {
+	room, err := rm.GetRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+	return room.EventLog(), nil
+}
+
+// Stories returns every stored story, oldest first.
+func (rm *RoomManager) Stories() ([]*models.StoryRecord, error) {
+	stories, err := rm.storage.ListStories()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(stories, func(i, j int) bool {
+		if !stories[i].CompletedAt.Equal(stories[j].CompletedAt) {
+			return stories[i].CompletedAt.Before(stories[j].CompletedAt)
+		}
+		return stories[i].RoomID < stories[j].RoomID
+	})
+	return stories, nil
+}
+
+// EndRoom aborts a room's game on an administrator's behalf. The room stays
+// listed until it is deleted.
+func (rm *RoomManager) EndRoom(ctx context.Context, roomID string) error {
+	room, err := rm.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	return room.ForceEnd(ctx)
+}
+
+// DeleteRoom removes a room, ending its game and disconnecting everyone in it.
+func (rm *RoomManager) DeleteRoom(ctx context.Context, roomID string) error {
+	roomID = utils.NormalizeRoomCode(roomID)
+	rm.roomsMutex.Lock()
+	room, exists := rm.rooms[roomID]
+	delete(rm.rooms, roomID)
+	rm.roomsMutex.Unlock()
+	if !exists {
+		return ErrRoomNotFound
+	}
+	room.Shut(ctx)
+	rm.services.Logger.Info("Room deleted by an administrator", "room_id", roomID)
+	return nil
+}
+
+// DisconnectPlayer removes a player or spectator from a room and closes their connection.
+func (rm *RoomManager) DisconnectPlayer(ctx context.Context, roomID, playerName string) error {
+	room, err := rm.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	return room.Kick(ctx, playerName)
+}
+
+// Announce sends a system announcement to everyone in every unfinished room
+// and returns how many rooms it reached.
+func (rm *RoomManager) Announce(ctx context.Context, text string) int {
+	msg := models.Message{Type: models.MessageAnnouncement, Content: text}
+	sent := 0
+	for _, room := range rm.allRooms() {
+		if room.GetStatus().IsFinished() {
+			continue
+		}
+		room.Broadcast(ctx, msg)
+		sent++
+	}
+	return sent
+}
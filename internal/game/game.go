@@ -3,77 +3,299 @@ package game
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/metrics"
 	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/internal/search"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/internal/webhook"
+	"storytelling-backend/pkg/utils"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+var (
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrRoomFull       = models.ErrRoomFull
+	ErrTurnTooLong    = errors.New("turn_seconds is longer than the server allows")
+	ErrTurnNegative   = errors.New("turn_seconds must not be negative")
+	ErrInviteTooLong  = errors.New("expires_in is longer than the server allows")
+	ErrInviteNegative = errors.New("expires_in must not be negative")
+
+	ErrRoomCodeTaken   = errors.New("room code is already in use")
+	ErrInvalidRoomCode = errors.New("room code must be 4 to 16 letters or digits, without 0, O, 1, I, L or Q")
+)
+
+// roomCodeAttempts is how many generated codes CreateRoom tries before giving up.
+const roomCodeAttempts = 20
+
 // RoomManager is responsible for managing rooms and players.
 type RoomManager struct {
 	rooms      map[string]*models.Room
 	roomsMutex sync.RWMutex
+	storage    storage.Storage
+	config     config.GameConfig
+	services   Services
+	draining   bool // set by StopAccepting
 }
 
-var RoomManagerInstance *RoomManager
+// Services are what a RoomManager's rooms report to and are limited by. Nil
+// Index, Webhooks, Limits and Metrics are skipped.
+type Services struct {
+	Index    *search.Index
+	Webhooks *webhook.Dispatcher
+	Limits   *ratelimit.Guard
+	Metrics  *metrics.Metrics
+	// Logger logs the manager's and its rooms' work, each room's lines
+	// tagged with its room_id; nil means slog.Default().
+	Logger *slog.Logger
+	// Tracer traces the rooms' actions and broadcasts; nil records nothing.
+	Tracer trace.Tracer
+	// Clock stamps lines and finished stories; nil means time.Now.
+	Clock models.Clock
+	// NewRoomID generates room IDs; nil means utils.GenerateRoomCode with
+	// the configured length. Codes already in use are skipped.
+	NewRoomID func() string
+	// ChatModerators inspect every room's chat messages, in order.
+	ChatModerators []models.ChatModerator
+}
 
-// NewRoomManager creates and returns a new RoomManager.
-func NewRoomManager() *RoomManager {
+// NewRoomManager creates a RoomManager that stores finished stories in store
+// and applies the game settings in cfg.
+func NewRoomManager(store storage.Storage, cfg config.GameConfig, services Services) *RoomManager {
+	if services.Clock == nil {
+		services.Clock = time.Now
+	}
+	if services.Logger == nil {
+		services.Logger = slog.Default()
+	}
+	if services.Tracer == nil {
+		services.Tracer = noop.NewTracerProvider().Tracer("")
+	}
+	if services.NewRoomID == nil {
+		services.NewRoomID = func() string { return utils.GenerateRoomCode(cfg.RoomCodeLength) }
+	}
 	return &RoomManager{
-		rooms: make(map[string]*models.Room),
+		rooms:    make(map[string]*models.Room),
+		storage:  store,
+		config:   cfg,
+		services: services,
 	}
 }
 
-// CreateRoom creates a new room and adds it to the manager.
-func (rm *RoomManager) CreateRoom(roomID string, host string) (*models.Room, error) {
-	// rm.roomsMutex.Lock()
-	// defer rm.roomsMutex.Unlock()
+// TurnTimeout returns the turn limit for a new room that asked for seconds,
+// using the configured default when seconds is zero.
+func (rm *RoomManager) TurnTimeout(seconds int) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, ErrTurnNegative
+	}
+	if seconds == 0 {
+		seconds = rm.config.DefaultTurnSeconds
+	}
+	if seconds > rm.config.MaxTurnSeconds {
+		return 0, ErrTurnTooLong
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
 
-	if _, exists := rm.rooms[roomID]; exists {
-		return nil, errors.New("room already exists")
+// InviteTTL returns how long a new invite lasts when the host asked for
+// seconds, using the configured lifetime when seconds is zero.
+func (rm *RoomManager) InviteTTL(seconds int) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, ErrInviteNegative
+	}
+	ttl := time.Duration(seconds) * time.Second
+	if seconds == 0 {
+		ttl = rm.config.InviteTTL
+	}
+	if ttl > rm.config.InviteTTL {
+		return 0, ErrInviteTooLong
+	}
+	return ttl, nil
+}
+
+// RoomSettings are what a host chooses when creating a room.
+type RoomSettings struct {
+	// Code is a vanity room code; empty means a generated one.
+	Code      string
+	StoryName string
+	// TurnTimeout is the room's turn limit, as returned by TurnTimeout.
+	TurnTimeout time.Duration
+	Private     bool
+	// UserID is the host's account, or "" for a guest host.
+	UserID string
+}
+
+// CreateRoom creates a new room with host as its first player and adds it to
+// the manager. The room's ID is settings.Code, a vanity code chosen by the
+// host, or a fresh code if that is empty. The room is fully set up before
+// other requests can find it.
+func (rm *RoomManager) CreateRoom(host string, settings RoomSettings) (*models.Room, error) {
+	rm.roomsMutex.Lock()
+	defer rm.roomsMutex.Unlock()
+
+	if rm.draining {
+		return nil, ErrShuttingDown
+	}
+	roomID, err := rm.roomCode(settings.Code)
+	if err != nil {
+		return nil, err
 	}
 
 	room := models.NewRoom(roomID, host)
+	room.StoryName = settings.StoryName
+	room.TurnTimeout = settings.TurnTimeout
+	room.Private = settings.Private
+	if settings.UserID != "" {
+		_, err = room.AddUser(host, settings.UserID)
+	} else {
+		err = room.AddPlayer(host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rm.attach(room)
 	rm.rooms[roomID] = room
+	rm.services.Metrics.RoomCreated()
+	rm.emitEvent(webhook.EventRoomCreated, roomID, map[string]string{"host": host})
 	return room, nil
 }
 
-// GetRoom retrieves a room by ID.
+// roomCode returns the ID for a new room: code in upper case if it is valid
+// and free, or else a generated code that is not in use. Codes of finished
+// stories count as in use so their records are never overwritten.
+func (rm *RoomManager) roomCode(code string) (string, error) {
+	if code != "" {
+		code = utils.NormalizeRoomCode(code)
+		if !validRoomCode(code) {
+			return "", ErrInvalidRoomCode
+		}
+		if rm.codeInUse(code) {
+			return "", ErrRoomCodeTaken
+		}
+		return code, nil
+	}
+	for range roomCodeAttempts {
+		if code := rm.services.NewRoomID(); !rm.codeInUse(code) {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("no free room code after %d attempts", roomCodeAttempts)
+}
+
+func (rm *RoomManager) codeInUse(code string) bool {
+	if _, exists := rm.rooms[code]; exists {
+		return true
+	}
+	_, err := rm.storage.GetStory(code)
+	return err == nil
+}
+
+// validRoomCode reports whether code, in upper case, may name a room: 4 to
+// 16 characters of utils.RoomCodeAlphabet, the same alphabet generated codes
+// are drawn from.
+func validRoomCode(code string) bool {
+	return len(code) >= 4 && len(code) <= 16 && utils.InRoomCodeAlphabet(code)
+}
+
+// attach connects a room's hooks to the manager's storage and services.
+func (rm *RoomManager) attach(room *models.Room) {
+	roomID := room.ID
+	room.OnGameEnd = rm.saveStory
+	room.OnLineAdded = rm.indexLine
+	room.OnEvent = func(eventType string, data interface{}) { rm.emitEvent(eventType, roomID, data) }
+	room.AllowAction = func(action string) bool { return rm.allowRoomAction(roomID, action) }
+	room.Clock = rm.services.Clock
+	room.ChatModerators = rm.services.ChatModerators
+	room.MaxPlayers = rm.config.MaxPlayers
+	room.Rounds = rm.config.Rounds
+	room.MaxLines = rm.config.MaxLines
+	if rm.services.Limits != nil {
+		room.ChatLimit = rm.services.Limits.Config().PlayerChat
+	}
+	room.Metrics = rm.services.Metrics
+	room.Logger = rm.services.Logger.With("room_id", roomID)
+	room.Tracer = rm.services.Tracer
+}
+
+// GetRoom retrieves a room by ID, in any case.
 func (rm *RoomManager) GetRoom(roomID string) (*models.Room, error) {
-	// rm.roomsMutex.RLock()
-	// defer rm.roomsMutex.RUnlock()
+	roomID = utils.NormalizeRoomCode(roomID)
+	rm.roomsMutex.RLock()
+	defer rm.roomsMutex.RUnlock()
 
 	room, exists := rm.rooms[roomID]
 	if !exists {
-		return nil, errors.New("room not found")
+		return nil, ErrRoomNotFound
 	}
 
 	return room, nil
 }
 
-// AddPlayerToRoom adds a player to the specified room.
-func (rm *RoomManager) AddPlayerToRoom(roomID, playerName string) (*models.Room, error) {
-	// rm.roomsMutex.Lock()
-	// defer rm.roomsMutex.Unlock()
+// AddPlayerToRoom adds a player to the specified room and returns the name they
+// joined under. userID is the player's account, or "" for guests. Private
+// rooms need a valid invite; without one the player must Knock instead.
+func (rm *RoomManager) AddPlayerToRoom(roomID, playerName, userID, invite string) (*models.Room, string, error) {
+	roomID = utils.NormalizeRoomCode(roomID)
+	rm.roomsMutex.Lock()
+	defer rm.roomsMutex.Unlock()
 
 	room, exists := rm.rooms[roomID]
 	if !exists {
-		return nil, errors.New("room not found")
+		return nil, "", ErrRoomNotFound
+	}
+	if invite != "" {
+		if err := room.CheckInvite(invite); err != nil {
+			return nil, "", err
+		}
+	} else if room.IsPrivate() {
+		return nil, "", models.ErrApprovalRequired
+	}
+	if rm.config.MaxPlayers > 0 && room.PlayerCount() >= rm.config.MaxPlayers && room.UserID(playerName) == "" {
+		return nil, "", ErrRoomFull
+	}
+
+	name := playerName
+	var err error
+	if userID != "" {
+		name, err = room.AddUser(playerName, userID)
+	} else {
+		err = room.AddPlayer(playerName)
+	}
+	if err != nil {
+		return nil, "", err
 	}
+	if invite != "" {
+		room.UseInvite(invite)
+	}
+	return room, name, nil
+}
 
-	err := room.AddPlayer(playerName)
+// Knock asks the host of a private room to let a player in.
+func (rm *RoomManager) Knock(roomID, playerName, userID string) (*models.JoinRequest, error) {
+	room, err := rm.GetRoom(roomID)
 	if err != nil {
 		return nil, err
 	}
-	return room, nil
+	return room.Knock(playerName, userID)
 }
 
 // AddConnectionToRoom adds a WebSocket connection for a player in a specific room.
 func (rm *RoomManager) AddConnectionToRoom(roomID string, conn *models.PlayerConnection) error {
-	// rm.roomsMutex.Lock()
-	// defer rm.roomsMutex.Unlock()
+	roomID = utils.NormalizeRoomCode(roomID)
+	rm.roomsMutex.Lock()
+	defer rm.roomsMutex.Unlock()
 
 	room, exists := rm.rooms[roomID]
 	if !exists {
-		return errors.New("room not found")
+		return ErrRoomNotFound
 	}
 
 	err := room.AddConnection(conn)
@@ -83,16 +305,101 @@ func (rm *RoomManager) AddConnectionToRoom(roomID string, conn *models.PlayerCon
 	return nil
 }
 
+// GetStory returns the lines written so far in a room.
 func (rm *RoomManager) GetStory(roomID string) ([]string, error) {
-	// rm.roomsMutex.Lock()
-	// defer rm.roomsMutex.Unlock()
+	roomID = utils.NormalizeRoomCode(roomID)
+	rm.roomsMutex.RLock()
+	defer rm.roomsMutex.RUnlock()
 
 	room, exists := rm.rooms[roomID]
 	if !exists {
-		return nil, errors.New("room not found")
+		return nil, ErrRoomNotFound
+	}
+
+	return room.StoryLines(), nil
+}
+
+// GetStoryRecord returns the stored record of a finished story, with authors, reactions and awards.
+func (rm *RoomManager) GetStoryRecord(roomID string) (*models.StoryRecord, error) {
+	return rm.storage.GetStory(utils.NormalizeRoomCode(roomID))
+}
+
+// indexLine adds a committed line to the full-text search index.
+func (rm *RoomManager) indexLine(roomID string, line models.StoryLine) {
+	if rm.services.Index == nil {
+		return
 	}
+	rm.services.Index.Add(search.Document{
+		RoomID:    roomID,
+		LineID:    line.ID,
+		Author:    line.Author,
+		Text:      line.Text,
+		WrittenAt: line.WrittenAt,
+	})
+}
 
-	return room.Story, nil
+// indexStories adds the lines of every stored story to the search index,
+// which starts empty on each run.
+func (rm *RoomManager) indexStories() error {
+	if rm.services.Index == nil {
+		return nil
+	}
+	stories, err := rm.storage.ListStories()
+	if err != nil {
+		return err
+	}
+	for _, story := range stories {
+		for _, line := range story.Lines {
+			rm.indexLine(story.RoomID, line)
+		}
+	}
+	return nil
+}
+
+// emitEvent sends a room lifecycle event to webhook subscribers.
+func (rm *RoomManager) emitEvent(eventType, roomID string, data interface{}) {
+	if rm.services.Webhooks != nil {
+		rm.services.Webhooks.Emit(eventType, roomID, data)
+	}
+}
+
+// allowRoomAction applies the room-wide rate limits, if any are configured.
+func (rm *RoomManager) allowRoomAction(roomID, action string) bool {
+	return rm.services.Limits == nil || rm.services.Limits.AllowRoomAction(roomID, action)
+}
+
+func (rm *RoomManager) saveStory(record *models.StoryRecord) {
+	if err := rm.storage.SaveStory(record); err != nil {
+		rm.services.Logger.Error("Error saving story", "room_id", record.RoomID, "error", err)
+	}
+}
+
+// ListRooms returns a snapshot of every public room, for the lobby. Private
+// rooms are left out so their codes and hosts are not advertised.
+func (rm *RoomManager) ListRooms() []models.RoomInfo {
+	rm.roomsMutex.RLock()
+	defer rm.roomsMutex.RUnlock()
+
+	rooms := make([]models.RoomInfo, 0, len(rm.rooms))
+	for _, room := range rm.rooms {
+		if room.IsPrivate() {
+			continue
+		}
+		rooms = append(rooms, room.Info())
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// Stats counts the rooms by status and their players, for the metrics.
+func (rm *RoomManager) Stats() metrics.RoomStats {
+	stats := metrics.RoomStats{ByStatus: make(map[string]int)}
+	for _, room := range rm.allRooms() {
+		info := room.Info()
+		stats.ByStatus[string(info.Status)]++
+		stats.Players = append(stats.Players, len(info.Players))
+	}
+	return stats
 }
 
 // // BroadcastStoryUpdate broadcasts the updated story to all connected players in a room.
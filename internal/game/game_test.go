@@ -0,0 +1,156 @@
+// internal/game/game_test.go
+package game
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/pkg/utils"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a RoomManager over an empty in-memory store that
+// logs nowhere.
+func newTestManager(t *testing.T) *RoomManager {
+	t.Helper()
+	cfg := config.Default()
+	return NewRoomManager(storage.NewMemoryStorage(), cfg.Game, Services{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+}
+
+func TestRoomCodeLookupIgnoresCase(t *testing.T) {
+	rm := newTestManager(t)
+	room, err := rm.CreateRoom("Alice", RoomSettings{Code: "fave"})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	if room.ID != "FAVE" {
+		t.Fatalf("room ID = %q, want FAVE", room.ID)
+	}
+	for _, code := range []string{"FAVE", "fave", "Fave", " fave "} {
+		if _, err := rm.GetRoom(code); err != nil {
+			t.Errorf("GetRoom(%q): %v", code, err)
+		}
+		if _, err := rm.GetStory(code); err != nil {
+			t.Errorf("GetStory(%q): %v", code, err)
+		}
+	}
+	if _, _, err := rm.AddPlayerToRoom("fave", "Bob", "", ""); err != nil {
+		t.Errorf("AddPlayerToRoom with a lower-case code: %v", err)
+	}
+	if _, err := rm.CreateRoom("Carol", RoomSettings{Code: "FaVe"}); !errors.Is(err, ErrRoomCodeTaken) {
+		t.Errorf("CreateRoom with a taken code in another case = %v, want %v", err, ErrRoomCodeTaken)
+	}
+}
+
+func TestValidRoomCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"FAVE", true},
+		{"TAPE42", true},
+		{"ABC", false},
+		{"ABCDEFGHJKMNPRSTU", false},
+		{"TAPE-1", false},
+		{"B0AT", false},
+		{"BOAT", false},
+		{"R1DE", false},
+		{"RIDE", false},
+		{"TALE", false},
+		{"QUIZ", false},
+	}
+	for _, tt := range tests {
+		if got := validRoomCode(tt.code); got != tt.want {
+			t.Errorf("validRoomCode(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+	// Generated codes come from the same alphabet.
+	for i := 0; i < 100; i++ {
+		if code := utils.GenerateRoomCode(config.Default().Game.RoomCodeLength); !validRoomCode(code) {
+			t.Fatalf("generated code %q is not valid", code)
+		}
+	}
+}
+
+func TestCreateRoomAppliesSettings(t *testing.T) {
+	rm := newTestManager(t)
+	room, err := rm.CreateRoom("Alice", RoomSettings{
+		StoryName:   "The Lighthouse",
+		TurnTimeout: time.Minute,
+		Private:     true,
+		UserID:      "user-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	// Everything is set before CreateRoom returns, so other requests never
+	// find the room half made.
+	info := room.Info()
+	if info.StoryName != "The Lighthouse" || room.TurnTimeout != time.Minute || !room.IsPrivate() {
+		t.Errorf("room = %+v (turn timeout %v, private %v), want the requested settings", info, room.TurnTimeout, room.IsPrivate())
+	}
+	if len(info.TurnOrder) != 1 || info.TurnOrder[0] != "Alice" || room.UserID("Alice") != "user-1" {
+		t.Errorf("turn order %v with Alice as %q, want the host as the first player", info.TurnOrder, room.UserID("Alice"))
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	rm := newTestManager(t)
+	tests := []struct {
+		seconds int
+		want    time.Duration
+		wantErr error
+	}{
+		{0, time.Duration(rm.config.DefaultTurnSeconds) * time.Second, nil},
+		{30, 30 * time.Second, nil},
+		{-1, 0, ErrTurnNegative},
+		{rm.config.MaxTurnSeconds + 1, 0, ErrTurnTooLong},
+	}
+	for _, tt := range tests {
+		got, err := rm.TurnTimeout(tt.seconds)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("TurnTimeout(%d) = %v, %v; want %v, %v", tt.seconds, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestInviteTTL(t *testing.T) {
+	rm := newTestManager(t)
+	tests := []struct {
+		seconds int
+		want    time.Duration
+		wantErr error
+	}{
+		{0, rm.config.InviteTTL, nil},
+		{60, time.Minute, nil},
+		{-1, 0, ErrInviteNegative},
+		{int(rm.config.InviteTTL/time.Second) + 1, 0, ErrInviteTooLong},
+	}
+	for _, tt := range tests {
+		got, err := rm.InviteTTL(tt.seconds)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("InviteTTL(%d) = %v, %v; want %v, %v", tt.seconds, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// Each player's chat allowance comes from the configured limits.
+func TestRoomsUseConfiguredChatLimit(t *testing.T) {
+	limits := ratelimit.DefaultConfig()
+	limits.PlayerChat = ratelimit.Rule{Burst: 3, Per: time.Minute}
+	rm := NewRoomManager(storage.NewMemoryStorage(), config.Default().Game, Services{
+		Limits: ratelimit.NewGuard(limits),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	room, err := rm.CreateRoom("Alice", RoomSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.ChatLimit != limits.PlayerChat {
+		t.Errorf("ChatLimit = %v, want %v", room.ChatLimit, limits.PlayerChat)
+	}
+}
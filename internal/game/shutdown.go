@@ -0,0 +1,103 @@
+// internal/game/shutdown.go
+package game
+
+import (
+	"errors"
+	"fmt"
+	"storytelling-backend/internal/models"
+	"time"
+)
+
+// ErrShuttingDown is returned by CreateRoom once the server has begun to shut down.
+var ErrShuttingDown = errors.New("server is shutting down; try again shortly")
+
+// StopAccepting makes CreateRoom fail with ErrShuttingDown. Existing rooms
+// carry on until they are saved.
+func (rm *RoomManager) StopAccepting() {
+	rm.roomsMutex.Lock()
+	defer rm.roomsMutex.Unlock()
+	rm.draining = true
+}
+
+// NotifyRestart tells the clients of every room that the server is
+// restarting and closes their connections. Players stay in their rooms.
+func (rm *RoomManager) NotifyRestart() {
+	for _, room := range rm.allRooms() {
+		room.NotifyRestart()
+	}
+}
+
+// SaveRooms suspends every unfinished room and writes its snapshot to
+// storage, so that RestoreRooms can bring it back after a restart.
+func (rm *RoomManager) SaveRooms() error {
+	var errs []error
+	saved := 0
+	for _, room := range rm.allRooms() {
+		snapshot := room.Suspend()
+		if snapshot == nil {
+			continue
+		}
+		if err := rm.storage.SaveRoom(snapshot); err != nil {
+			errs = append(errs, fmt.Errorf("room %s: %w", room.ID, err))
+			continue
+		}
+		saved++
+	}
+	rm.services.Logger.Info("Saved unfinished rooms", "rooms", saved)
+	return errors.Join(errs...)
+}
+
+// RestoreRooms brings back the rooms saved by SaveRooms. Their players have
+// the reconnect grace period to rejoin; those who don't are dropped. The
+// search index is rebuilt from their lines and the stored stories.
+func (rm *RoomManager) RestoreRooms() error {
+	snapshots, err := rm.storage.ListRooms()
+	if err != nil {
+		return err
+	}
+
+	rm.roomsMutex.Lock()
+	restored := make([]*models.Room, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if _, exists := rm.rooms[snapshot.ID]; exists {
+			rm.services.Logger.Warn("Not restoring room: a room with that ID already exists", "room_id", snapshot.ID)
+			continue
+		}
+		room := models.RestoreRoom(snapshot)
+		rm.attach(room)
+		for _, line := range snapshot.Lines {
+			rm.indexLine(room.ID, line)
+		}
+		rm.rooms[room.ID] = room
+		restored = append(restored, room)
+	}
+	rm.roomsMutex.Unlock()
+
+	var errs []error
+	if err := rm.indexStories(); err != nil {
+		errs = append(errs, fmt.Errorf("search index: %w", err))
+	}
+	for _, room := range restored {
+		if err := rm.storage.DeleteRoom(room.ID); err != nil {
+			errs = append(errs, fmt.Errorf("room %s: %w", room.ID, err))
+		}
+		room.Resume()
+		time.AfterFunc(rm.config.ReconnectGrace, room.DropDisconnected)
+	}
+	if len(restored) > 0 {
+		rm.services.Logger.Info("Restored rooms", "rooms", len(restored), "reconnect_grace", rm.config.ReconnectGrace)
+	}
+	return errors.Join(errs...)
+}
+
+// allRooms returns the rooms without holding the lock while they are used.
+func (rm *RoomManager) allRooms() []*models.Room {
+	rm.roomsMutex.RLock()
+	defer rm.roomsMutex.RUnlock()
+
+	rooms := make([]*models.Room, 0, len(rm.rooms))
+	for _, room := range rm.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
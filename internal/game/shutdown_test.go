@@ -0,0 +1,115 @@
+// internal/game/shutdown_test.go
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/search"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/internal/webhook"
+	"testing"
+	"time"
+)
+
+// The search index lives in memory, so a new server rebuilds it from the
+// stored stories and the rooms it restores.
+func TestRestoreRoomsRebuildsSearchIndex(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	now := time.Now()
+	if err := store.SaveStory(&models.StoryRecord{
+		RoomID: "FABLE",
+		Host:   "Alice",
+		Status: models.StatusCompleted,
+		Lines:  []models.StoryLine{{ID: 1, Author: "Alice", Text: "A dragon slept under the hill.", WrittenAt: now}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveRoom(&models.RoomSnapshot{
+		ID:        "TAPES",
+		Host:      "Bob",
+		TurnOrder: []string{"Bob"},
+		Status:    models.StatusInProgress,
+		Lines:     []models.StoryLine{{ID: 1, Author: "Bob", Text: "The dragon woke.", WrittenAt: now}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	index := search.NewIndex(cfg.Game.SearchMaxLines)
+	rm := NewRoomManager(store, cfg.Game, Services{Index: index, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := rm.RestoreRooms(); err != nil {
+		t.Fatalf("RestoreRooms: %v", err)
+	}
+	results, err := index.Search(search.Query{Text: "dragon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Total != 2 {
+		t.Errorf("dragon matched %d lines after restoring, want 2: %+v", results.Total, results.Results)
+	}
+}
+
+// A restored room must be hooked up like a new one: its lines indexed, its
+// events sent to webhooks and its finished story stored.
+func TestRestoredRoomsKeepTheirHooks(t *testing.T) {
+	events := make(chan string, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err == nil {
+			events <- e.Type
+		}
+	}))
+	defer hook.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhooks := webhook.NewDispatcher(webhook.Options{Client: webhook.NewClient(time.Second, true), Logger: logger})
+	defer webhooks.Stop(time.Second)
+	if _, _, err := webhooks.Subscribe(hook.URL, "", "TAPES", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.NewMemoryStorage()
+	if err := store.SaveRoom(&models.RoomSnapshot{
+		ID:           "TAPES",
+		Host:         "Bob",
+		TurnOrder:    []string{"Bob"},
+		Status:       models.StatusInProgress,
+		TotalPlayers: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	index := search.NewIndex(cfg.Game.SearchMaxLines)
+	rm := NewRoomManager(store, cfg.Game, Services{Index: index, Webhooks: webhooks, Logger: logger})
+	if err := rm.RestoreRooms(); err != nil {
+		t.Fatalf("RestoreRooms: %v", err)
+	}
+	room, err := rm.GetRoom("TAPES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bob is the only player, so his line finishes the story.
+	if err := room.AddLine(context.Background(), "Bob", "The dragon woke."); err != nil {
+		t.Fatal(err)
+	}
+
+	if results, _ := index.Search(search.Query{Text: "dragon"}); results.Total != 1 {
+		t.Errorf("line written after the restore was not indexed: %+v", results)
+	}
+	if _, err := store.GetStory("TAPES"); err != nil {
+		t.Errorf("finished story was not stored: %v", err)
+	}
+	select {
+	case eventType := <-events:
+		if eventType != models.EventStoryCompleted {
+			t.Errorf("webhook received %q, want %q", eventType, models.EventStoryCompleted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no webhook event from the restored room")
+	}
+}
@@ -0,0 +1,147 @@
+// internal/game/stats.go
+package game
+
+import (
+	"sort"
+	"storytelling-backend/internal/models"
+	"time"
+)
+
+// maxCoWriters is how many favorite co-writers are reported in UserStats.
+const maxCoWriters = 5
+
+// UserStory summarises a user's part in one finished story.
+type UserStory struct {
+	RoomID       string            `json:"room_id"`
+	Host         string            `json:"host"`
+	Status       models.RoomStatus `json:"status"`
+	PlayerName   string            `json:"player_name"`
+	LinesWritten int               `json:"lines_written"`
+	TotalLines   int               `json:"total_lines"`
+	Awards       []string          `json:"awards,omitempty"`
+	CompletedAt  time.Time         `json:"completed_at"`
+}
+
+// CoWriter is someone a user has played with, keyed by account where possible.
+type CoWriter struct {
+	Name   string `json:"name"`
+	UserID string `json:"user_id,omitempty"`
+	Games  int    `json:"games"`
+}
+
+// UserStats is a summary of a user's play across all stored stories.
+type UserStats struct {
+	UserID            string         `json:"user_id"`
+	GamesPlayed       int            `json:"games_played"`
+	LinesWritten      int            `json:"lines_written"`
+	AverageLineLength float64        `json:"average_line_length"`
+	AwardsWon         map[string]int `json:"awards_won"`
+	FavoriteCoWriters []CoWriter     `json:"favorite_co_writers"`
+}
+
+// UserStories lists the stories a user contributed to, newest first.
+func (rm *RoomManager) UserStories(userID string) ([]UserStory, error) {
+	records, err := rm.storage.ListStoriesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CompletedAt.After(records[j].CompletedAt) })
+
+	stories := make([]UserStory, 0, len(records))
+	for _, record := range records {
+		name := playerNameOf(record, userID)
+		story := UserStory{
+			RoomID:      record.RoomID,
+			Host:        record.Host,
+			Status:      record.Status,
+			PlayerName:  name,
+			TotalLines:  len(record.Lines),
+			CompletedAt: record.CompletedAt,
+		}
+		for _, line := range record.Lines {
+			if line.Author == name {
+				story.LinesWritten++
+			}
+		}
+		for _, award := range record.Awards {
+			if award.Player == name {
+				story.Awards = append(story.Awards, award.Award)
+			}
+		}
+		stories = append(stories, story)
+	}
+	return stories, nil
+}
+
+// UserStats computes a user's statistics from their stored stories.
+func (rm *RoomManager) UserStats(userID string) (*UserStats, error) {
+	records, err := rm.storage.ListStoriesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &UserStats{
+		UserID:            userID,
+		AwardsWon:         map[string]int{},
+		FavoriteCoWriters: []CoWriter{},
+	}
+	coWriters := map[string]*CoWriter{}
+	totalLength := 0
+	for _, record := range records {
+		name := playerNameOf(record, userID)
+		stats.GamesPlayed++
+		for _, line := range record.Lines {
+			if line.Author == name {
+				stats.LinesWritten++
+				totalLength += len([]rune(line.Text))
+			}
+		}
+		for _, award := range record.Awards {
+			if award.Player == name {
+				stats.AwardsWon[award.Award]++
+			}
+		}
+		for _, other := range record.Players {
+			if other == name {
+				continue
+			}
+			otherID := record.PlayerUsers[other]
+			key := "name:" + other
+			if otherID != "" {
+				key = "user:" + otherID
+			}
+			if coWriters[key] == nil {
+				coWriters[key] = &CoWriter{Name: other, UserID: otherID}
+			}
+			coWriters[key].Games++
+		}
+	}
+	if stats.LinesWritten > 0 {
+		stats.AverageLineLength = float64(totalLength) / float64(stats.LinesWritten)
+	}
+
+	for _, c := range coWriters {
+		stats.FavoriteCoWriters = append(stats.FavoriteCoWriters, *c)
+	}
+	sort.Slice(stats.FavoriteCoWriters, func(i, j int) bool {
+		a, b := stats.FavoriteCoWriters[i], stats.FavoriteCoWriters[j]
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		return a.Name < b.Name
+	})
+	if len(stats.FavoriteCoWriters) > maxCoWriters {
+		stats.FavoriteCoWriters = stats.FavoriteCoWriters[:maxCoWriters]
+	}
+	return stats, nil
+}
+
+// playerNameOf returns the name a user played under in a story.
+func playerNameOf(record *models.StoryRecord, userID string) string {
+	for name, id := range record.PlayerUsers {
+		if id == userID {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+// internal/game/stats_test.go
+package game
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/storage"
+	"testing"
+)
+
+// newFileManager returns a RoomManager whose stories are kept in the data
+// file at path, as a server's are.
+func newFileManager(t *testing.T, path string) *RoomManager {
+	t.Helper()
+	store := storage.NewMemoryStorage()
+	if err := store.PersistDataTo(path); err != nil {
+		t.Fatal(err)
+	}
+	return NewRoomManager(store, config.Default().Game, Services{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+}
+
+// Stats are computed from the stored stories, so they survive a restart.
+func TestUserStatsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	rm := newFileManager(t, path)
+	room, err := rm.CreateRoom("Alice", RoomSettings{Code: "TAPES", UserID: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rm.AddPlayerToRoom("TAPES", "Bob", "user-2", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := room.StartGame(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, turn := range []struct{ player, line string }{
+		{"Alice", "The lighthouse keeper counted ships."},
+		{"Bob", "None came."},
+	} {
+		if err := room.AddLine(ctx, turn.player, turn.line); err != nil {
+			t.Fatalf("%s: %v", turn.player, err)
+		}
+	}
+	room.EndGame()
+
+	before, err := rm.UserStats("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.GamesPlayed != 1 || before.LinesWritten != 1 || len(before.FavoriteCoWriters) != 1 {
+		t.Fatalf("stats before the restart = %+v, want one game and one line with Bob", before)
+	}
+	stories, err := rm.UserStories("user-2")
+	if err != nil || len(stories) != 1 {
+		t.Fatalf("Bob's stories before the restart = %+v, %v", stories, err)
+	}
+
+	if err := rm.storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+	restarted := newFileManager(t, path)
+	after, err := restarted.UserStats("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("stats after a restart = %+v, want %+v", after, before)
+	}
+	got, err := restarted.UserStories("user-2")
+	if err != nil || len(got) != 1 || !got[0].CompletedAt.Equal(stories[0].CompletedAt) {
+		t.Fatalf("Bob's stories after a restart = %+v, %v; want %+v", got, err, stories)
+	}
+	got[0].CompletedAt = stories[0].CompletedAt // the file keeps neither the clock reading nor the location
+	if !reflect.DeepEqual(got, stories) {
+		t.Errorf("Bob's stories after a restart = %+v, want %+v", got, stories)
+	}
+}
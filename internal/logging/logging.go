@@ -0,0 +1,74 @@
+// internal/logging/logging.go
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"storytelling-backend/config"
+	"storytelling-backend/pkg/utils"
+)
+
+// RequestIDHeader carries a request's ID. A client may send one to
+// correlate its own logs with the server's; the response always echoes it.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs so they cannot bloat the logs.
+const maxRequestIDLength = 64
+
+type contextKey struct{}
+
+// New creates a logger writing to w at the configured level and format.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	var level slog.Level
+	// The level was validated with the rest of the configuration.
+	level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default() if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware gives every request an ID, taken from the X-Request-ID header
+// when the client sent a usable one, and puts a logger tagged with it in the
+// request's context for FromContext.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = utils.GenerateToken(8)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithLogger(r.Context(), logger.With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so that a
+// client cannot forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
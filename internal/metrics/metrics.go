@@ -0,0 +1,284 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "storytelling"
+
+// Message types counted by name; anything else is counted as "unknown" so
+// clients cannot create unbounded label values.
+var knownMessageTypes = map[string]bool{
+	"SUBMIT_LINE": true, "START_GAME": true, "PAUSE_GAME": true, "RESUME_GAME": true,
+	"ABORT_GAME": true, "CHAT": true, "REACT": true, "CHAT_REACT": true,
+	"APPROVE_JOIN": true, "DENY_JOIN": true,
+}
+
+// Metrics holds the server's Prometheus metrics and serves them. Every
+// method is safe to call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	connections       *prometheus.GaugeVec
+	messagesReceived  *prometheus.CounterVec
+	messagesDropped   *prometheus.CounterVec
+	linesSubmitted    prometheus.Counter
+	turnDuration      *prometheus.HistogramVec
+	broadcastDuration prometheus.Histogram
+	roomsCreated      prometheus.Counter
+}
+
+// RoomStats is the state of the rooms at scrape time.
+type RoomStats struct {
+	// ByStatus counts rooms by status, such as "waiting" or "in_progress".
+	ByStatus map[string]int
+	// Players holds the number of players in each room.
+	Players []int
+}
+
+// New creates the metrics, along with the Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "http_requests_total",
+			Help: "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "http_request_duration_seconds",
+			Help:    "Time to serve HTTP requests by route and method. Streams and WebSockets are measured until they close.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "connected_clients",
+			Help: "Open client connections by transport: websocket, sse or rpc.",
+		}, []string{"transport"}),
+		messagesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "websocket_messages_received_total",
+			Help: "Messages received from WebSocket clients by type.",
+		}, []string{"type"}),
+		messagesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "messages_dropped_total",
+			Help: "Messages not delivered or not accepted, by reason: send_failed or rate_limited.",
+		}, []string{"reason"}),
+		linesSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "lines_submitted_total",
+			Help: "Story lines added to rooms.",
+		}),
+		turnDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "turn_duration_seconds",
+			Help:    "How long turns lasted, by how they ended: submitted or timed_out.",
+			Buckets: []float64{1, 5, 10, 20, 30, 60, 120, 300, 600, 1800, 3600},
+		}, []string{"outcome"}),
+		broadcastDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "broadcast_duration_seconds",
+			Help:    "Time to send one message to everyone in a room.",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}),
+		roomsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "rooms_created_total",
+			Help: "Rooms created.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.connections, m.messagesReceived, m.messagesDropped,
+		m.linesSubmitted, m.turnDuration, m.broadcastDuration, m.roomsCreated,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// WatchRooms reports the rooms returned by stats as storytelling_rooms (by
+// status) and the storytelling_room_players histogram, read at each scrape.
+func (m *Metrics) WatchRooms(stats func() RoomStats) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(&roomCollector{stats: stats})
+}
+
+// CounterFunc reports the value returned by f as a counter named
+// storytelling_<name>, read at each scrape.
+func (m *Metrics) CounterFunc(name, help string, f func() float64) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace, Name: name, Help: help,
+	}, f))
+}
+
+// Instrument counts and times the requests next serves for route, which
+// should be the route's pattern rather than the request path.
+func (m *Metrics) Instrument(route string, next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		m.httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(sw.status)).Inc()
+		m.httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ConnectionOpened counts an open client connection until the returned func is called.
+func (m *Metrics) ConnectionOpened(transport string) (closed func()) {
+	if m == nil {
+		return func() {}
+	}
+	gauge := m.connections.WithLabelValues(transport)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// MessageReceived counts a message from a WebSocket client.
+func (m *Metrics) MessageReceived(messageType string) {
+	if m == nil {
+		return
+	}
+	if !knownMessageTypes[messageType] {
+		messageType = "unknown"
+	}
+	m.messagesReceived.WithLabelValues(messageType).Inc()
+}
+
+// MessageDropped counts a message that was not delivered or not accepted.
+func (m *Metrics) MessageDropped(reason string) {
+	if m == nil {
+		return
+	}
+	m.messagesDropped.WithLabelValues(reason).Inc()
+}
+
+// LineSubmitted counts a line added to a story.
+func (m *Metrics) LineSubmitted() {
+	if m == nil {
+		return
+	}
+	m.linesSubmitted.Inc()
+}
+
+// TurnEnded records how long a turn lasted and how it ended.
+func (m *Metrics) TurnEnded(outcome string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.turnDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// Broadcast records how long a room took to send a message to everyone.
+func (m *Metrics) Broadcast(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.broadcastDuration.Observe(d.Seconds())
+}
+
+// RoomCreated counts a new room.
+func (m *Metrics) RoomCreated() {
+	if m == nil {
+		return
+	}
+	m.roomsCreated.Inc()
+}
+
+// roomCollector turns RoomStats into metrics at scrape time.
+type roomCollector struct {
+	stats func() RoomStats
+}
+
+var (
+	roomsDesc = prometheus.NewDesc(namespace+"_rooms", "Rooms by status.", []string{"status"}, nil)
+	// Each room is one observation, so the histogram's count is the number of rooms.
+	roomPlayersDesc    = prometheus.NewDesc(namespace+"_room_players", "Players per room.", nil, nil)
+	roomPlayersBuckets = []float64{1, 2, 3, 4, 5, 6, 8, 10, 15, 20}
+)
+
+func (c *roomCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- roomsDesc
+	ch <- roomPlayersDesc
+}
+
+func (c *roomCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	for status, n := range stats.ByStatus {
+		ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(n), status)
+	}
+	buckets := make(map[float64]uint64, len(roomPlayersBuckets))
+	sum := 0
+	for _, players := range stats.Players {
+		sum += players
+		for _, bound := range roomPlayersBuckets {
+			if float64(players) <= bound {
+				buckets[bound]++
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstHistogram(roomPlayersDesc, uint64(len(stats.Players)), float64(sum), buckets)
+}
+
+// statusWriter records the status code of a response. It passes on flushes
+// and hijacks so that SSE streams and WebSocket upgrades keep working.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	// A hijacked connection is a WebSocket upgrade.
+	w.status = http.StatusSwitchingProtocols
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,177 @@
+// internal/models/admin.go
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Messages sent to clients by administrators.
+const (
+	// MessageAnnouncement carries a system announcement to every room.
+	MessageAnnouncement = "ANNOUNCEMENT"
+	// MessageDisconnected tells a client an administrator removed it from the room.
+	MessageDisconnected = "DISCONNECTED"
+	// MessageRoomClosed tells every client an administrator deleted the room.
+	MessageRoomClosed = "ROOM_CLOSED"
+)
+
+// ConnectionDetails describes a player's or spectator's connection to a room.
+type ConnectionDetails struct {
+	Name      string `json:"name"`
+	UserID    string `json:"user_id,omitempty"`
+	Connected bool   `json:"connected"`
+	// Transport is "websocket", "sse" or "other"; empty while disconnected.
+	Transport    string `json:"transport,omitempty"`
+	ConnectionID string `json:"connection_id,omitempty"`
+}
+
+// RoomDetails is what administrators see of a room, including who is
+// connected and how.
+type RoomDetails struct {
+	ID           string              `json:"id"`
+	StoryName    string              `json:"story_name,omitempty"`
+	Host         string              `json:"host"`
+	Status       RoomStatus          `json:"status"`
+	Private      bool                `json:"private"`
+	CurrentTurn  int                 `json:"current_turn"`
+	TurnOrder    []string            `json:"turn_order"`
+	Lines        int                 `json:"lines"`
+	Players      []ConnectionDetails `json:"players"`
+	Spectators   []ConnectionDetails `json:"spectators"`
+	JoinRequests int                 `json:"pending_join_requests"`
+}
+
+// Details returns the room's state for administrators.
+func (r *Room) Details() RoomDetails {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	pending := 0
+	for _, req := range r.joinRequests {
+		if req.Status == JoinPending {
+			pending++
+		}
+	}
+	return RoomDetails{
+		ID:           r.ID,
+		StoryName:    r.StoryName,
+		Host:         r.Host,
+		Status:       r.Status,
+		Private:      r.Private,
+		CurrentTurn:  r.CurrentTurn,
+		TurnOrder:    append([]string{}, r.TurnOrder...),
+		Lines:        len(r.Lines),
+		Players:      r.connectionDetails(r.Players),
+		Spectators:   r.connectionDetails(r.Spectators),
+		JoinRequests: pending,
+	}
+}
+
+func (r *Room) connectionDetails(conns map[string]*PlayerConnection) []ConnectionDetails {
+	details := make([]ConnectionDetails, 0, len(conns))
+	for name, conn := range conns {
+		d := ConnectionDetails{Name: name, UserID: r.Users[name], Connected: conn.Connected()}
+		if d.Connected {
+			d.Transport = conn.transportName()
+			d.ConnectionID = conn.ID
+		}
+		details = append(details, d)
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].Name < details[j].Name })
+	return details
+}
+
+// transportName names how the client is connected.
+func (pc *PlayerConnection) transportName() string {
+	switch {
+	case pc.Conn != nil:
+		return "websocket"
+	case isSSE(pc.Transport):
+		return "sse"
+	default:
+		return "other"
+	}
+}
+
+func isSSE(t Transport) bool {
+	_, ok := t.(*SSEStream)
+	return ok
+}
+
+// ForceEnd aborts the game whoever is host, on an administrator's behalf.
+func (r *Room) ForceEnd(ctx context.Context) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.force_end")()
+
+	if r.Status.IsFinished() {
+		return ErrGameFinished
+	}
+	r.abort("An administrator ended the game.")
+	return nil
+}
+
+// Kick disconnects a player or spectator and removes them from the room, as
+// if they had left.
+func (r *Room) Kick(ctx context.Context, name string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.kick", attribute.String("player", name))()
+
+	conn := r.Players[name]
+	if conn == nil {
+		conn = r.Spectators[name]
+	}
+	if conn == nil {
+		return ErrPlayerNotInRoom
+	}
+	conn.disconnect(Message{Type: MessageDisconnected, Content: "An administrator removed you from the room."},
+		websocket.ClosePolicyViolation, "removed by an administrator")
+	r.handleDisconnect(conn)
+	return nil
+}
+
+// Shut ends the game if it is still running and disconnects every client.
+// Like Suspend, it leaves a room that must not be played afterwards.
+func (r *Room) Shut(ctx context.Context) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.shut")()
+
+	if !r.Status.IsFinished() {
+		r.abort("An administrator ended the game.")
+	}
+	// Disconnects from here on must not remove players or announce departures.
+	r.suspended = true
+	r.disconnectAll(Message{Type: MessageRoomClosed, Content: "An administrator closed this room."},
+		websocket.CloseGoingAway, "room closed")
+}
+
+// disconnectAll sends msg to every connected client and closes its connection.
+func (r *Room) disconnectAll(msg Message, code int, reason string) {
+	for _, conns := range []map[string]*PlayerConnection{r.Players, r.Spectators} {
+		for _, conn := range conns {
+			if conn.Connected() {
+				conn.disconnect(msg, code, reason)
+			}
+		}
+	}
+}
+
+// disconnect sends msg and closes the connection, telling WebSocket clients
+// why with a close frame.
+func (pc *PlayerConnection) disconnect(msg Message, code int, reason string) {
+	if !pc.Connected() {
+		return
+	}
+	pc.Send(msg)
+	if pc.Conn != nil {
+		pc.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	}
+	pc.Close()
+}
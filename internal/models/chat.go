@@ -0,0 +1,194 @@
+// internal/models/chat.go
+package models
+
+import (
+	"context"
+	"errors"
+	"storytelling-backend/internal/ratelimit"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// ChatHistorySize is how many chat messages a room keeps for late joiners.
+	ChatHistorySize = 100
+	// MaxChatLength is the longest chat message accepted, in runes.
+	MaxChatLength = 500
+)
+
+var (
+	ErrChatEmpty       = errors.New("chat message is empty")
+	ErrChatTooLong     = errors.New("chat message is too long")
+	ErrChatRateLimited = errors.New("too many chat messages; wait a moment before sending another")
+	ErrChatNotFound    = errors.New("chat message not found")
+	ErrInvalidReaction = errors.New("unsupported reaction")
+	// ErrMessageModerated is a convenience error for ChatModerator hooks that reject a message.
+	ErrMessageModerated = errors.New("message rejected by moderation")
+)
+
+// ReactionEmojis is the fixed set of emoji players may react with.
+var ReactionEmojis = []string{"👍", "❤️", "😂", "😮", "😢", "🔥"}
+
+// IsReactionEmoji reports whether emoji is in ReactionEmojis.
+func IsReactionEmoji(emoji string) bool {
+	for _, e := range ReactionEmojis {
+		if e == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatMessage is a single message in a room's chat channel.
+type ChatMessage struct {
+	ID        int                 `json:"id"`
+	Player    string              `json:"player"`
+	Text      string              `json:"text"`
+	SentAt    time.Time           `json:"sent_at"`
+	Reactions map[string][]string `json:"reactions,omitempty"` // emoji -> players who reacted
+}
+
+// ChatModerator inspects a chat message before it is posted. It returns the
+// text to post (possibly rewritten) or an error to reject the message.
+type ChatModerator func(roomID, playerName, text string) (string, error)
+
+// moderateChat runs the room's moderators over a chat message in order.
+func (r *Room) moderateChat(playerName, text string) (string, error) {
+	for _, m := range r.ChatModerators {
+		var err error
+		if text, err = m(r.ID, playerName, text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// chatLog is a room's bounded chat history and per-player send allowance.
+type chatLog struct {
+	messages []*ChatMessage
+	nextID   int
+	limits   map[string]*ratelimit.Bucket // by player token
+}
+
+func (c *chatLog) find(id int) *ChatMessage {
+	for _, m := range c.messages {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// allow takes one of key's messages under rule.
+func (c *chatLog) allow(key string, rule ratelimit.Rule, now time.Time) bool {
+	if c.limits == nil {
+		c.limits = make(map[string]*ratelimit.Bucket)
+	}
+	b, ok := c.limits[key]
+	if !ok {
+		b = ratelimit.NewRuleBucket(rule, now)
+		c.limits[key] = b
+	}
+	return b.Take(now)
+}
+
+// PostChat moderates, rate limits and records a chat message, then broadcasts it to the room.
+func (r *Room) PostChat(ctx context.Context, playerName, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ErrChatEmpty
+	}
+	if len([]rune(text)) > MaxChatLength {
+		return ErrChatTooLong
+	}
+	text, err := r.moderateChat(playerName, text)
+	if err != nil {
+		return err
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.post_chat", attribute.String("player", playerName))()
+
+	if _, exists := r.Players[playerName]; !exists {
+		return ErrPlayerNotInRoom
+	}
+	now := time.Now()
+	if !r.chat.allow(r.playerTokens[playerName], r.ChatLimit, now) {
+		return ErrChatRateLimited
+	}
+	if !r.allow(ratelimit.ActionChat) {
+		return ErrRateLimited
+	}
+
+	r.chat.nextID++
+	msg := &ChatMessage{ID: r.chat.nextID, Player: playerName, Text: text, SentAt: now}
+	r.chat.messages = append(r.chat.messages, msg)
+	if len(r.chat.messages) > ChatHistorySize {
+		r.chat.messages = r.chat.messages[len(r.chat.messages)-ChatHistorySize:]
+	}
+	r.broadcast(Message{Type: "CHAT", Data: msg})
+	return nil
+}
+
+// ReactToChat toggles playerName's emoji reaction on a chat message and broadcasts the new counts.
+func (r *Room) ReactToChat(ctx context.Context, playerName string, messageID int, emoji string) error {
+	if !IsReactionEmoji(emoji) {
+		return ErrInvalidReaction
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.react_to_chat", attribute.String("player", playerName))()
+
+	if _, exists := r.Players[playerName]; !exists {
+		return ErrPlayerNotInRoom
+	}
+	msg := r.chat.find(messageID)
+	if msg == nil {
+		return ErrChatNotFound
+	}
+	if msg.Reactions == nil {
+		msg.Reactions = make(map[string][]string)
+	}
+	msg.Reactions[emoji] = toggle(msg.Reactions[emoji], playerName)
+	if len(msg.Reactions[emoji]) == 0 {
+		delete(msg.Reactions, emoji)
+	}
+	r.broadcast(Message{Type: "CHAT_REACTION", ID: msg.ID, Data: msg.Reactions})
+	return nil
+}
+
+// ChatHistory returns a copy of the room's recent chat messages, oldest first.
+func (r *Room) ChatHistory() []ChatMessage {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.chatHistory()
+}
+
+func (r *Room) chatHistory() []ChatMessage {
+	history := make([]ChatMessage, 0, len(r.chat.messages))
+	for _, m := range r.chat.messages {
+		c := *m
+		if m.Reactions != nil {
+			c.Reactions = make(map[string][]string, len(m.Reactions))
+			for emoji, players := range m.Reactions {
+				c.Reactions[emoji] = append([]string{}, players...)
+			}
+		}
+		history = append(history, c)
+	}
+	return history
+}
+
+// toggle adds name to names, or removes it if already present.
+func toggle(names []string, name string) []string {
+	for i, n := range names {
+		if n == name {
+			return append(names[:i], names[i+1:]...)
+		}
+	}
+	return append(names, name)
+}
@@ -0,0 +1,74 @@
+// internal/models/chat_test.go
+package models
+
+import (
+	"context"
+	"errors"
+	"storytelling-backend/internal/ratelimit"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatModerators(t *testing.T) {
+	errBlocked := errors.New("blocked")
+	var calls []string
+	censor := func(roomID, playerName, text string) (string, error) {
+		calls = append(calls, "censor:"+roomID+":"+playerName)
+		return strings.ReplaceAll(text, "dragon", "d****n"), nil
+	}
+	block := func(roomID, playerName, text string) (string, error) {
+		calls = append(calls, "block")
+		if strings.Contains(text, "spam") {
+			return "", errBlocked
+		}
+		return strings.ToUpper(text), nil
+	}
+
+	room := NewRoom("ROOM", "Alice")
+	room.AddPlayer("Alice")
+	room.ChatModerators = []ChatModerator{censor, block}
+
+	if err := room.PostChat(context.Background(), "Alice", "a dragon!"); err != nil {
+		t.Fatalf("PostChat: %v", err)
+	}
+	if err := room.PostChat(context.Background(), "Alice", "buy spam"); !errors.Is(err, errBlocked) {
+		t.Fatalf("PostChat of a blocked message = %v, want %v", err, errBlocked)
+	}
+	history := room.ChatHistory()
+	if len(history) != 1 || history[0].Text != "A D****N!" {
+		t.Errorf("chat history = %+v, want one message rewritten by both moderators in order", history)
+	}
+	want := "censor:ROOM:Alice block censor:ROOM:Alice block"
+	if got := strings.Join(calls, " "); got != want {
+		t.Errorf("moderator calls = %q, want %q", got, want)
+	}
+
+	// Moderators belong to their room.
+	other := NewRoom("OTHER", "Bob")
+	other.AddPlayer("Bob")
+	if err := other.PostChat(context.Background(), "Bob", "buy spam"); err != nil {
+		t.Errorf("PostChat in a room without moderators: %v", err)
+	}
+}
+
+func TestChatRateLimit(t *testing.T) {
+	room := NewRoom("ROOM", "Alice")
+	room.ChatLimit = ratelimit.Rule{Burst: 2, Per: time.Hour}
+	room.AddPlayer("Alice")
+	room.AddPlayer("Bob")
+	var err error
+	for i := 0; i <= room.ChatLimit.Burst && err == nil; i++ {
+		err = room.PostChat(context.Background(), "Alice", "hello")
+	}
+	if !errors.Is(err, ErrChatRateLimited) {
+		t.Fatalf("PostChat after a burst of %d = %v, want %v", room.ChatLimit.Burst, err, ErrChatRateLimited)
+	}
+	if err := room.PostChat(context.Background(), "Bob", "hello"); err != nil {
+		t.Errorf("another player's chat was limited: %v", err)
+	}
+	// Clients tell a chat limit apart from the connection's message limit by its text.
+	if ErrChatRateLimited.Error() == ErrMessageRateLimited.Error() {
+		t.Errorf("ErrChatRateLimited and ErrMessageRateLimited both read %q", ErrChatRateLimited)
+	}
+}
@@ -0,0 +1,44 @@
+// internal/models/eventlog.go
+package models
+
+import "time"
+
+// EventLineAdded is recorded in a room's event log when a line is committed.
+// Unlike the lifecycle events it is not passed to OnEvent; OnLineAdded
+// reports lines instead.
+const EventLineAdded = "line.added"
+
+// maxEventLog is how many events a room keeps for replay; older ones are dropped.
+const maxEventLog = 1000
+
+// LoggedEvent is an entry in a room's event log.
+type LoggedEvent struct {
+	// Seq numbers the room's events from 1, so gaps show where old events were dropped.
+	Seq    int         `json:"seq"`
+	Type   string      `json:"type"`
+	Status RoomStatus  `json:"status"`
+	At     time.Time   `json:"at"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// record appends an event to the room's log.
+func (r *Room) record(eventType string, data interface{}) {
+	r.eventSeq++
+	if len(r.eventLog) >= maxEventLog {
+		r.eventLog = append(r.eventLog[:0], r.eventLog[1:]...)
+	}
+	r.eventLog = append(r.eventLog, LoggedEvent{
+		Seq:    r.eventSeq,
+		Type:   eventType,
+		Status: r.Status,
+		At:     r.now(),
+		Data:   data,
+	})
+}
+
+// EventLog returns the room's recent events, oldest first.
+func (r *Room) EventLog() []LoggedEvent {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return append([]LoggedEvent{}, r.eventLog...)
+}
@@ -0,0 +1,52 @@
+// internal/models/events.go
+package models
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Room lifecycle events passed to Room.OnEvent.
+const (
+	EventGameStarted    = "game.started"
+	EventTurnChanged    = "turn.changed"
+	EventGamePaused     = "game.paused"
+	EventGameResumed    = "game.resumed"
+	EventGameAborted    = "game.aborted"
+	EventStoryCompleted = "story.completed"
+)
+
+// TurnChange is the data of an EventTurnChanged event.
+type TurnChange struct {
+	Player    string `json:"player"`
+	Turn      int    `json:"turn"`
+	LineCount int    `json:"line_count"`
+}
+
+// emit logs a lifecycle event, adds it to the current span and the room's
+// event log, and passes it to OnEvent. Turn changes are frequent, so they are
+// only logged at debug level.
+func (r *Room) emit(eventType string, data interface{}) {
+	level := slog.LevelInfo
+	if eventType == EventTurnChanged {
+		level = slog.LevelDebug
+	}
+	r.Logger.Log(context.Background(), level, "Room event", "event", eventType, "status", r.Status)
+	trace.SpanFromContext(r.spanContext()).AddEvent(eventType, trace.WithAttributes(attribute.String("status", string(r.Status))))
+	r.record(eventType, data)
+	if r.OnEvent != nil {
+		r.OnEvent(eventType, data)
+	}
+}
+
+// beginTurn starts the current player's turn: it arms the turn timer and
+// reports the turn change.
+func (r *Room) beginTurn() {
+	r.turnStarted = time.Now()
+	r.startTurnTimer(r.TurnTimeout)
+	r.emit(EventTurnChanged, TurnChange{Player: r.currentPlayer(), Turn: r.CurrentTurn, LineCount: len(r.Lines)})
+}
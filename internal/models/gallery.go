@@ -0,0 +1,30 @@
+// internal/models/gallery.go
+package models
+
+import "time"
+
+// PublishStatus tracks a story's way into the public gallery.
+type PublishStatus string
+
+const (
+	PublishPending   PublishStatus = "pending" // waiting for player consent
+	PublishPublished PublishStatus = "published"
+	PublishRejected  PublishStatus = "rejected"
+)
+
+// PublishedStory is a finished story submitted to the public gallery. Its Slug
+// is a permanent address that outlives the room.
+type PublishedStory struct {
+	Slug        string          `json:"slug"`
+	RoomID      string          `json:"room_id"`
+	Title       string          `json:"title"`
+	Genre       string          `json:"genre,omitempty"`
+	Status      PublishStatus   `json:"status"`
+	Story       *StoryRecord    `json:"story"`
+	Consent     map[string]bool `json:"consent"` // player name -> approved; missing means not yet voted
+	Required    []string        `json:"required_consent"`
+	LikedBy     []string        `json:"-"` // user IDs
+	Likes       int             `json:"likes"`
+	RequestedAt time.Time       `json:"requested_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
@@ -0,0 +1,350 @@
+// internal/models/invites.go
+package models
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"storytelling-backend/pkg/utils"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// MaxPendingJoinRequests is how many join requests a room queues for the host.
+	MaxPendingJoinRequests = 20
+
+	// Decided join requests are kept this long so requesters can read the decision.
+	joinRequestRetention = 10 * time.Minute
+)
+
+var (
+	ErrInviteInvalid       = errors.New("invite is invalid, expired or already used")
+	ErrApprovalRequired    = errors.New("room is private; ask the host to let you in")
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	ErrJoinRequestDecided  = errors.New("join request has already been decided")
+	ErrTooManyJoinRequests = errors.New("too many join requests are waiting for the host")
+	ErrPlayerNameTaken     = errors.New("player name is already taken in this room")
+	ErrRoomFull            = errors.New("room is full")
+	ErrPlayerTokenRequired = errors.New("log in as this player or send the player token from joining the room")
+	ErrHostTokenRequired   = errors.New("log in as the host or send the host token from creating the room")
+)
+
+// Join request states.
+const (
+	JoinPending  = "pending"
+	JoinApproved = "approved"
+	JoinDenied   = "denied"
+)
+
+// Invite lets whoever holds Token join the room, even a private one, until
+// it expires. A single-use invite works once.
+type Invite struct {
+	Token     string    `json:"token"`
+	RoomID    string    `json:"room_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SingleUse bool      `json:"single_use"`
+	Uses      int       `json:"uses"`
+}
+
+func (i *Invite) usable(now time.Time) bool {
+	return now.Before(i.ExpiresAt) && !(i.SingleUse && i.Uses > 0)
+}
+
+// JoinRequest is a player knocking on a private room. The host approves or
+// denies it; PlayerName is the name the player was let in under.
+type JoinRequest struct {
+	ID          string     `json:"id"`
+	RoomID      string     `json:"room_id"`
+	PlayerName  string     `json:"player_name"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	// PlayerToken is only in the reply to Knock, so only the requester sees
+	// it. It becomes their player token if the host approves.
+	PlayerToken string `json:"player_token,omitempty"`
+
+	userID  string
+	token   string
+	decided chan struct{} // closed once the host decides
+}
+
+// CreateInvite issues an invite valid for ttl. Only the host may invite.
+func (r *Room) CreateInvite(host string, ttl time.Duration, singleUse bool) (*Invite, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if host != r.Host {
+		return nil, ErrNotHost
+	}
+	if r.Status.IsFinished() {
+		return nil, ErrGameFinished
+	}
+	now := r.now()
+	for token, invite := range r.invites {
+		if !invite.usable(now) {
+			delete(r.invites, token)
+		}
+	}
+	invite := &Invite{
+		Token:     utils.GenerateToken(16),
+		RoomID:    r.ID,
+		ExpiresAt: now.Add(ttl),
+		SingleUse: singleUse,
+	}
+	r.invites[invite.Token] = invite
+	issued := *invite
+	return &issued, nil
+}
+
+// CheckInvite reports whether token is a usable invite to the room.
+func (r *Room) CheckInvite(token string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if invite := r.invites[token]; invite == nil || !invite.usable(r.now()) {
+		return ErrInviteInvalid
+	}
+	return nil
+}
+
+// UseInvite records that someone joined with token.
+func (r *Room) UseInvite(token string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if invite := r.invites[token]; invite != nil {
+		invite.Uses++
+	}
+}
+
+// HostToken returns the secret issued to whoever created the room. A guest
+// host proves they are the host with it, since anyone can claim a guest's name.
+func (r *Room) HostToken() string {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.hostToken
+}
+
+// CheckHostToken reports whether token is the room's host token.
+func (r *Room) CheckHostToken(token string) bool {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(r.hostToken)) == 1
+}
+
+// PlayerToken returns the secret issued to playerName when they joined, or ""
+// if they never joined. Guests prove who they are with it where their name
+// alone is not enough, such as consenting to publish their story.
+func (r *Room) PlayerToken(playerName string) string {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.playerTokens[playerName]
+}
+
+// Authenticate checks that the caller may act as playerName: logged in as the
+// account the player joined with (userID), or holding the player token they
+// were given on creating or joining the room. Connections are bound to the
+// player this way, never by name alone.
+func (r *Room) Authenticate(playerName, userID, token string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if r.Players[playerName] == nil {
+		return ErrPlayerNotInRoom
+	}
+	if owner := r.Users[playerName]; owner != "" && owner == userID {
+		return nil
+	}
+	if want := r.playerTokens[playerName]; token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+		return nil
+	}
+	return ErrPlayerTokenRequired
+}
+
+// AuthenticateHost checks that the caller may act as the host under
+// playerName: logged in as the host's account, or holding the host token.
+func (r *Room) AuthenticateHost(playerName, userID, hostToken string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if playerName == "" || playerName != r.Host {
+		return ErrNotHost
+	}
+	if owner := r.Users[r.Host]; owner != "" && owner == userID {
+		return nil
+	}
+	if hostToken != "" && subtle.ConstantTimeCompare([]byte(hostToken), []byte(r.hostToken)) == 1 {
+		return nil
+	}
+	return ErrHostTokenRequired
+}
+
+// IsPrivate reports whether joining needs an invite or the host's approval.
+func (r *Room) IsPrivate() bool {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.Private
+}
+
+// Knock queues a request to join the private room and pushes it to the host
+// as a JOIN_REQUEST message. userID is the requester's account, or "" for guests.
+func (r *Room) Knock(playerName, userID string) (*JoinRequest, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if r.Status.IsFinished() {
+		return nil, ErrGameFinished
+	}
+	if userID == "" && (r.Players[playerName] != nil || r.Spectators[playerName] != nil) {
+		return nil, ErrPlayerNameTaken
+	}
+	now := r.now()
+	pending := 0
+	for id, req := range r.joinRequests {
+		switch {
+		case req.Status == JoinPending:
+			pending++
+		case now.Sub(*req.DecidedAt) > joinRequestRetention:
+			delete(r.joinRequests, id)
+		}
+	}
+	if pending >= MaxPendingJoinRequests {
+		return nil, ErrTooManyJoinRequests
+	}
+
+	req := &JoinRequest{
+		ID:          utils.GenerateToken(8),
+		RoomID:      r.ID,
+		PlayerName:  playerName,
+		Status:      JoinPending,
+		RequestedAt: now,
+		userID:      userID,
+		token:       utils.GenerateToken(16),
+		decided:     make(chan struct{}),
+	}
+	r.joinRequests[req.ID] = req
+	if host := r.Players[r.Host]; host != nil && host.Connected() {
+		host.Send(Message{Type: "JOIN_REQUEST", Content: req.ID, Data: req.view()})
+	}
+	reply := req.view()
+	reply.PlayerToken = req.token
+	return reply, nil
+}
+
+// PendingJoinRequests returns the join requests waiting for the host, oldest first.
+func (r *Room) PendingJoinRequests(host string) ([]JoinRequest, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if host != r.Host {
+		return nil, ErrNotHost
+	}
+	pending := []JoinRequest{}
+	for _, req := range r.joinRequests {
+		if req.Status == JoinPending {
+			pending = append(pending, *req.view())
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].RequestedAt.Before(pending[j].RequestedAt) })
+	return pending, nil
+}
+
+// DecideJoin approves or denies a join request and tells the host with a
+// JOIN_DECIDED message. An approved requester is added to the room; they
+// connect like any other player.
+func (r *Room) DecideJoin(ctx context.Context, host, requestID string, approve bool) (*JoinRequest, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.decide_join", attribute.String("join_request.id", requestID), attribute.Bool("approve", approve))()
+
+	if host != r.Host {
+		return nil, ErrNotHost
+	}
+	req := r.joinRequests[requestID]
+	if req == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	if req.Status != JoinPending {
+		return nil, ErrJoinRequestDecided
+	}
+	if approve {
+		if r.MaxPlayers > 0 && len(r.Players) >= r.MaxPlayers {
+			return nil, ErrRoomFull
+		}
+		name, err := r.admit(req.PlayerName, req.userID)
+		if err != nil {
+			return nil, err
+		}
+		if req.userID == "" {
+			r.playerTokens[name] = req.token
+		}
+		req.PlayerName = name
+		req.Status = JoinApproved
+	} else {
+		req.Status = JoinDenied
+	}
+	now := r.now()
+	req.DecidedAt = &now
+	close(req.decided)
+	if host := r.Players[r.Host]; host != nil && host.Connected() {
+		host.Send(Message{Type: "JOIN_DECIDED", Content: req.ID, Data: req.view()})
+	}
+	return req.view(), nil
+}
+
+// admit adds a requester under playerName, or under a suffixed name for a
+// registered user whose name is taken.
+func (r *Room) admit(playerName, userID string) (string, error) {
+	if userID == "" {
+		if r.Players[playerName] != nil || r.Spectators[playerName] != nil {
+			return "", ErrPlayerNameTaken
+		}
+		r.addPlayer(playerName)
+		return playerName, nil
+	}
+	for name, id := range r.Users {
+		if id == userID {
+			return name, nil
+		}
+	}
+	name := playerName
+	for i := 2; r.Players[name] != nil || r.Spectators[name] != nil; i++ {
+		name = fmt.Sprintf("%s (%d)", playerName, i)
+	}
+	r.addPlayer(name)
+	r.Users[name] = userID
+	return name, nil
+}
+
+// WaitJoinRequest returns the join request once the host has decided, or its
+// current state when ctx is done first.
+func (r *Room) WaitJoinRequest(ctx context.Context, requestID string) (*JoinRequest, error) {
+	r.Mutex.Lock()
+	req := r.joinRequests[requestID]
+	r.Mutex.Unlock()
+	if req == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+
+	select {
+	case <-req.decided:
+	case <-ctx.Done():
+	}
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return req.view(), nil
+}
+
+// view copies the request for callers outside the room lock.
+func (req *JoinRequest) view() *JoinRequest {
+	return &JoinRequest{
+		ID:          req.ID,
+		RoomID:      req.RoomID,
+		PlayerName:  req.PlayerName,
+		Status:      req.Status,
+		RequestedAt: req.RequestedAt,
+		DecidedAt:   req.DecidedAt,
+	}
+}
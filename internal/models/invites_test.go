@@ -0,0 +1,106 @@
+// internal/models/invites_test.go
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInviteExpiry(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	room := NewRoom("ROOM", "Alice")
+	room.Clock = func() time.Time { return now }
+
+	if _, err := room.CreateInvite("Bob", time.Hour, false); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("CreateInvite by a player = %v, want %v", err, ErrNotHost)
+	}
+	invite, err := room.CreateInvite("Alice", time.Hour, false)
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	once, err := room.CreateInvite("Alice", time.Hour, true)
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+
+	if err := room.CheckInvite(invite.Token); err != nil {
+		t.Errorf("fresh invite: %v", err)
+	}
+	if err := room.CheckInvite("not-a-token"); !errors.Is(err, ErrInviteInvalid) {
+		t.Errorf("unknown invite = %v, want %v", err, ErrInviteInvalid)
+	}
+
+	room.UseInvite(once.Token)
+	if err := room.CheckInvite(once.Token); !errors.Is(err, ErrInviteInvalid) {
+		t.Errorf("used single-use invite = %v, want %v", err, ErrInviteInvalid)
+	}
+	room.UseInvite(invite.Token)
+	if err := room.CheckInvite(invite.Token); err != nil {
+		t.Errorf("used reusable invite: %v", err)
+	}
+
+	now = now.Add(time.Hour - time.Second)
+	if err := room.CheckInvite(invite.Token); err != nil {
+		t.Errorf("invite a second before it expires: %v", err)
+	}
+	now = now.Add(time.Second)
+	if err := room.CheckInvite(invite.Token); !errors.Is(err, ErrInviteInvalid) {
+		t.Errorf("expired invite = %v, want %v", err, ErrInviteInvalid)
+	}
+}
+
+func TestHostToken(t *testing.T) {
+	room := NewRoom("ROOM", "Alice")
+	room.AddPlayer("Alice")
+	token := room.HostToken()
+	if len(token) < 32 || token == NewRoom("ROOM", "Alice").HostToken() {
+		t.Fatalf("host token %q is not a fresh random secret", token)
+	}
+	for _, bad := range []string{"", "guess", token[:len(token)-1]} {
+		if room.CheckHostToken(bad) {
+			t.Errorf("CheckHostToken(%q) = true", bad)
+		}
+	}
+
+	// The token outlives a restart.
+	restored := RestoreRoom(room.Suspend())
+	if !restored.CheckHostToken(token) {
+		t.Error("restored room does not accept its host token")
+	}
+}
+
+func TestKnockIssuesPlayerToken(t *testing.T) {
+	room := NewRoom("ROOM", "Alice")
+	room.AddPlayer("Alice")
+	room.Private = true
+
+	req, err := room.Knock("Bob", "")
+	if err != nil {
+		t.Fatalf("Knock: %v", err)
+	}
+	if req.PlayerToken == "" {
+		t.Fatal("the requester was given no player token")
+	}
+	pending, err := room.PendingJoinRequests("Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].PlayerToken != "" {
+		t.Errorf("host sees %+v, want the request without its token", pending)
+	}
+	decided, err := room.DecideJoin(context.Background(), "Alice", req.ID, true)
+	if err != nil {
+		t.Fatalf("DecideJoin: %v", err)
+	}
+	if decided.PlayerToken != "" {
+		t.Error("the host's decision carries the requester's token")
+	}
+	if got := room.PlayerToken("Bob"); got != req.PlayerToken {
+		t.Errorf("Bob's player token = %q, want the one from knocking", got)
+	}
+	if room.PlayerToken("Alice") == "" || room.PlayerToken("Alice") == room.HostToken() {
+		t.Error("the host has no player token of their own")
+	}
+}
@@ -2,31 +2,148 @@
 package models
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/pkg/utils"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// PlayerConnection represents a player with an associated WebSocket connection in a room.
+// maxRefusedMessages is how many rate limited messages in a row a WebSocket
+// client may send before it is disconnected.
+const maxRefusedMessages = 10
+
+var ErrMessageRateLimited = errors.New("you are sending messages too quickly")
+
+// hostMessages are the messages only the host's connection may send.
+var hostMessages = map[string]bool{
+	"START_GAME":   true,
+	"PAUSE_GAME":   true,
+	"RESUME_GAME":  true,
+	"ABORT_GAME":   true,
+	"APPROVE_JOIN": true,
+	"DENY_JOIN":    true,
+}
+
+// Transport delivers server messages to a connected client.
+type Transport interface {
+	WriteText(data []byte) error
+	Close() error
+}
+
+// wsTransport writes to a WebSocket connection.
+type wsTransport struct {
+	conn *websocket.Conn
+}
+
+func (t wsTransport) WriteText(data []byte) error {
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (t wsTransport) Close() error {
+	return t.conn.Close()
+}
+
+// PlayerConnection represents a player with an associated connection in a room.
+// Conn is set for WebSocket clients; Transport is how messages reach the client
+// whichever way it is connected.
 type PlayerConnection struct {
 	Conn       *websocket.Conn
+	Transport  Transport
 	PlayerName string
 	RoomID     string
+	Spectator  bool
+	// Host is set on a connection that proved it is the host's, with the
+	// host token or the host's account. Only such a connection may start,
+	// pause, resume or abort the game or decide join requests.
+	Host bool
+	// Token is a spectator's secret on an SSE stream, sent to them in a
+	// SPECTATOR_TOKEN message. It is needed to resume the stream and to send
+	// actions on it; players use the room's player token instead.
+	Token string
+	// MessageLimit rate limits the messages the connection may send, over
+	// the WebSocket or as SSE actions; nil means unlimited. See TakeMessage.
+	MessageLimit *ratelimit.Bucket
+	// ID tells apart a player's successive connections in the logs.
+	ID string
+	// Logger logs the connection's work, tagged with its room_id, player and conn_id.
+	Logger *slog.Logger
+
+	writeMu sync.Mutex // gorilla/websocket allows only one concurrent writer
+	limitMu sync.Mutex // guards MessageLimit, which SSE actions share across requests
+}
+
+// TakeMessage uses up one message from MessageLimit. If the connection is
+// sending too quickly it returns false and how long until it may send again.
+func (p *PlayerConnection) TakeMessage() (ok bool, wait time.Duration) {
+	p.limitMu.Lock()
+	defer p.limitMu.Unlock()
+	now := time.Now()
+	if p.MessageLimit.Take(now) {
+		return true, 0
+	}
+	return false, p.MessageLimit.RetryAfter(now)
+}
+
+// NewPlayerConnection initializes a new player connection, logging to logger.
+func NewPlayerConnection(conn *websocket.Conn, roomID, playerName string, logger *slog.Logger) *PlayerConnection {
+	pc := NewTransportConnection(wsTransport{conn}, roomID, playerName, logger)
+	pc.Conn = conn
+	return pc
 }
 
-// NewPlayerConnection initializes a new player connection.
-func NewPlayerConnection(conn *websocket.Conn, roomID, playerName string) *PlayerConnection {
+// NewTransportConnection initializes a player connection over a non-WebSocket
+// transport, logging to logger.
+func NewTransportConnection(t Transport, roomID, playerName string, logger *slog.Logger) *PlayerConnection {
+	id := utils.GenerateToken(4)
 	return &PlayerConnection{
-		Conn:       conn,
+		Transport:  t,
 		RoomID:     roomID,
 		PlayerName: playerName,
+		ID:         id,
+		Logger:     logger.With("room_id", roomID, "player", playerName, "conn_id", id),
+	}
+}
+
+// logger returns the connection's logger. Players who have joined but never
+// connected have none, so they log to slog.Default().
+func (pc *PlayerConnection) logger() *slog.Logger {
+	if pc.Logger == nil {
+		return slog.Default().With("room_id", pc.RoomID, "player", pc.PlayerName)
+	}
+	return pc.Logger
+}
+
+// Connected reports whether the player has a live transport.
+func (pc *PlayerConnection) Connected() bool {
+	return pc.Transport != nil
+}
+
+// Close closes the player's transport, if any.
+func (pc *PlayerConnection) Close() {
+	if pc.Transport != nil {
+		pc.Transport.Close()
 	}
 }
 
 type Message struct {
 	Type    string `json:"type"`
 	Content string `json:"content"`
+	// ID and Emoji address a chat message for CHAT_REACT or a story line for REACT.
+	ID    int    `json:"id,omitempty"`
+	Emoji string `json:"emoji,omitempty"`
+	// Data carries the structured payload of server messages such as CHAT and CHAT_HISTORY.
+	Data interface{} `json:"data,omitempty"`
 }
 
 // // Listen listens for incoming messages from the player and manages disconnections.
@@ -49,52 +166,142 @@ type Message struct {
 // }
 
 // Listen listens for incoming messages from the player and manages disconnections.
-func (p *PlayerConnection) Listen(room *Room) {
+// Each message is traced as its own trace, linked to the connection's span in ctx.
+func (p *PlayerConnection) Listen(ctx context.Context, room *Room) {
 	defer func() {
-		// room, err := game.RoomManagerInstance.GetRoom(p.RoomID)
-		// if err == nil {
-		room.RemovePlayer(p.PlayerName)
-		room.BroadcastMessage(p.PlayerName + " has left the game.")
-		if room.Status != "completed" {
-			room.BroadcastTurn() // Notify the next player if a player disconnects during a live game.
-		}
-		if len(room.Players) == 0 {
-			room.Status = "completed" //Handle cleanup if all players leave
-		}
-		// }
+		room.HandleDisconnect(p)
 		p.Conn.Close()
 	}()
 
+	refused := 0
 	for {
 		var msg Message
 		err := p.Conn.ReadJSON(&msg)
 		if err != nil {
-			log.Printf("Player %s disconnected: %v", p.PlayerName, err)
+			p.logger().Info("Player disconnected", "error", err)
 			break
 		}
 
-		// Process different types of incoming messages
-		switch msg.Type {
-		case "SUBMIT_LINE":
-			// room, err := game.RoomManagerInstance.GetRoom(p.RoomID)
-			room.HandleSubmitLine(p.PlayerName, msg.Content)
-
-		case "START_GAME":
-			// room, err := game.RoomManagerInstance.GetRoom(p.RoomID)
-			if p.PlayerName == room.Host {
-				room.StartGame()
-			} else {
-				log.Printf("Player %s is not the host and cannot start the game", p.PlayerName)
-				p.SendMessage(Message{Type: "ERROR", Content: "Only the host can start the game"})
+		room.Metrics.MessageReceived(msg.Type)
+		if ok, _ := p.TakeMessage(); !ok {
+			room.Metrics.MessageDropped("rate_limited")
+			if refused++; refused >= maxRefusedMessages {
+				p.logger().Warn("Disconnecting player for flooding the room")
+				closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "message rate limit exceeded")
+				p.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+				break
 			}
-		default:
-			log.Printf("Unhandled message type from %s: %s", p.PlayerName, msg.Type)
+			p.SendMessage(Message{Type: "ERROR", Content: ErrMessageRateLimited.Error()})
+			continue
+		}
+		refused = 0
+
+		msgCtx, span := room.Tracer.Start(context.Background(), "websocket.message",
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.LinkFromContext(ctx)),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("room.id", room.ID),
+				attribute.String("player", p.PlayerName),
+				attribute.String("conn.id", p.ID),
+				attribute.String("message.type", msg.Type),
+			))
+		if err := p.HandleMessage(msgCtx, room, msg); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			p.SendMessage(Message{Type: "ERROR", Content: err.Error()})
 		}
+		span.End()
+	}
+}
+
+// CheckToken reports whether token is the connection's Token.
+func (p *PlayerConnection) CheckToken(token string) bool {
+	return p.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(p.Token)) == 1
+}
+
+// HandleMessage processes one client message, whichever transport it arrived
+// on, as part of the trace in ctx. The host's messages are refused unless the
+// connection proved it is the host's; see Host.
+func (p *PlayerConnection) HandleMessage(ctx context.Context, room *Room, msg Message) error {
+	return p.HandleAction(ctx, room, msg, p.Host)
+}
+
+// HandleAction is HandleMessage for a message whose sender proved, or did
+// not, that it is the host with this request rather than when connecting,
+// as actions sent over HTTP do.
+func (p *PlayerConnection) HandleAction(ctx context.Context, room *Room, msg Message, host bool) error {
+	if p.Spectator && msg.Type != "REACT" {
+		return errors.New("Spectators can only react to lines")
+	}
+	if hostMessages[msg.Type] && p.PlayerName == room.Host && !host {
+		return ErrHostTokenRequired
+	}
+
+	// Process different types of incoming messages
+	switch msg.Type {
+	case "SUBMIT_LINE":
+		return room.HandleSubmitLine(ctx, p.PlayerName, msg.Content)
+
+	case "START_GAME":
+		if p.PlayerName != room.Host {
+			return errors.New("Only the host can start the game")
+		}
+		return room.StartGame(ctx)
+
+	case "PAUSE_GAME":
+		return room.PauseGame(ctx, p.PlayerName)
+
+	case "RESUME_GAME":
+		return room.ResumeGame(ctx, p.PlayerName)
+
+	case "ABORT_GAME":
+		return room.AbortGame(ctx, p.PlayerName)
+
+	case "CHAT":
+		return room.PostChat(ctx, p.PlayerName, msg.Content)
+
+	case "REACT":
+		return room.ReactToLine(ctx, p.PlayerName, msg.ID, msg.Emoji)
+
+	case "CHAT_REACT":
+		return room.ReactToChat(ctx, p.PlayerName, msg.ID, msg.Emoji)
+
+	case "APPROVE_JOIN", "DENY_JOIN":
+		_, err := room.DecideJoin(ctx, p.PlayerName, msg.Content, msg.Type == "APPROVE_JOIN")
+		return err
+
+	default:
+		p.logger().Debug("Unhandled message type", "type", msg.Type)
+		return fmt.Errorf("unknown message type %q", msg.Type)
 	}
 }
 
 func (pc *PlayerConnection) Send(msg Message) error {
-	return pc.Conn.WriteJSON(msg)
+	if pc.Transport == nil {
+		return errors.New("player is not connected")
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	pc.writeMu.Lock()
+	defer pc.writeMu.Unlock()
+	return pc.Transport.WriteText(data)
+}
+
+// SendText sends a plain-text frame to the player, if connected.
+func (pc *PlayerConnection) SendText(text string) error {
+	if pc.Transport == nil {
+		return nil
+	}
+	pc.writeMu.Lock()
+	defer pc.writeMu.Unlock()
+	if err := pc.Transport.WriteText([]byte(text)); err != nil {
+		pc.logger().Warn("Failed to send message", "error", err)
+		return err
+	}
+	return nil
 }
 
 // SendStoryUpdate sends the current story to the player.
@@ -106,15 +313,12 @@ func (pc *PlayerConnection) SendStoryUpdate(story []string) {
 	pc.SendMessage(update)
 }
 
-// SendMessage sends a message to the player over WebSocket.
+// SendMessage sends a message to the player.
 func (pc *PlayerConnection) SendMessage(message interface{}) {
 	data, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("Failed to marshal message for player %s: %v", pc.PlayerName, err)
+		pc.logger().Error("Failed to marshal message", "error", err)
 		return
 	}
-	err = pc.Conn.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		log.Printf("Failed to send message to player %s: %v", pc.PlayerName, err)
-	}
+	pc.SendText(string(data))
 }
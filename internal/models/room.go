@@ -2,37 +2,213 @@
 package models
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"storytelling-backend/internal/metrics"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/pkg/utils"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// RoomStatus is the lifecycle state of a room.
+type RoomStatus string
+
+const (
+	StatusWaiting    RoomStatus = "waiting"
+	StatusInProgress RoomStatus = "in_progress"
+	StatusPaused     RoomStatus = "paused"
+	StatusCompleted  RoomStatus = "completed"
+	StatusAborted    RoomStatus = "aborted"
+)
+
+// IsFinished reports whether the room can no longer be played.
+func (s RoomStatus) IsFinished() bool {
+	return s == StatusCompleted || s == StatusAborted
+}
+
+var (
+	ErrNotHost       = errors.New("only the host can do that")
+	ErrGameNotActive = errors.New("game is not in progress")
+	ErrGamePaused    = errors.New("game is paused")
+	ErrGameNotPaused = errors.New("game is not paused")
+	ErrGameStarted   = errors.New("game has already started")
+	ErrGameFinished  = errors.New("game has already finished")
+	ErrNotYourTurn   = errors.New("it's not your turn")
+
+	ErrPlayerNotInRoom = errors.New("player not found in room")
+	ErrRateLimited     = errors.New("too many actions in this room; slow down")
+)
+
+// Clock returns the current time. Servers take one so that tests can fix it.
+type Clock func() time.Time
+
 // Room represents a storytelling room with a unique ID, list of players, and the story.
 type Room struct {
 	ID           string
+	StoryName    string
 	Host         string
 	Players      map[string]*PlayerConnection
+	Spectators   map[string]*PlayerConnection
+	Users        map[string]string // player name -> account user ID; guests are absent
 	Story        []string
+	Lines        []*StoryLine
 	TurnOrder    []string
 	CurrentTurn  int
-	Status       string
+	Status       RoomStatus
 	Mutex        sync.Mutex
 	TotalPlayers int
+	// TurnTimeout is how long a player has to submit a line; zero disables the turn timer.
+	TurnTimeout time.Duration
+	// OnGameEnd, if set, receives the finished story when the game completes. It is
+	// called with the room locked and must not call back into the room.
+	OnGameEnd func(*StoryRecord)
+	// OnLineAdded, if set, is called with each line as it is committed to the
+	// story. Like OnGameEnd it runs with the room locked.
+	OnLineAdded func(roomID string, line StoryLine)
+	// OnEvent, if set, is told about lifecycle changes (see EventGameStarted and
+	// friends). It runs with the room locked and must not block.
+	OnEvent func(eventType string, data interface{})
+	// ChatModerators inspect every chat message before it is posted, in
+	// order; see ChatModerator. They run without the room locked.
+	ChatModerators []ChatModerator
+	// AllowAction, if set, rate limits room-wide actions such as submissions
+	// and chat (see ratelimit.ActionSubmit). It runs with the room locked.
+	AllowAction func(action string) bool
+	// ChatLimit limits each player's own chat messages. It is kept per player
+	// token, so someone who later joins under a departed player's name does
+	// not inherit their allowance. NewRoom sets
+	// ratelimit.DefaultConfig().PlayerChat; the zero Rule allows everything.
+	ChatLimit ratelimit.Rule
+	// Clock, if set, stamps lines and finished stories instead of time.Now.
+	// Turn timers always run on the system clock.
+	Clock Clock
+	// Private rooms can only be joined with an invite or the host's approval.
+	Private bool
+	// MaxPlayers caps the players the host can approve; zero means no cap.
+	MaxPlayers int
+	// Rounds is how many lines each player writes; see isGameOver. NewRoom
+	// sets one round.
+	Rounds int
+	// MaxLines ends the story once it has this many lines; zero means no cap.
+	MaxLines int
+	// Metrics, if set, records lines, turns and broadcasts.
+	Metrics *metrics.Metrics
+	// Logger logs the room's work; NewRoom tags slog.Default() with the room_id.
+	Logger *slog.Logger
+	// Tracer traces the room's actions, state changes and broadcasts; NewRoom
+	// sets one that records nothing.
+	Tracer trace.Tracer
+
+	turnTimer     *time.Timer
+	turnDeadline  time.Time
+	turnStarted   time.Time     // when the current turn began, for the turn duration metric
+	turnRemaining time.Duration // time left on the frozen turn while paused
+	suspended     bool          // the server is shutting down; see NotifyRestart
+
+	// traceCtx carries the span of the action holding the lock; see trace.
+	traceCtx context.Context
+
+	chat chatLog
+
+	eventLog []LoggedEvent // see record
+	eventSeq int
+
+	hostToken    string                  // see HostToken
+	playerTokens map[string]string       // player name -> secret; see PlayerToken
+	invites      map[string]*Invite      // by token
+	joinRequests map[string]*JoinRequest // by ID
+}
+
+// RoomInfo is a read-only snapshot of a Room, safe to encode without holding the lock.
+type RoomInfo struct {
+	ID           string
+	StoryName    string
+	Host         string
+	Players      []string
+	Story        []string
+	TurnOrder    []string
+	CurrentTurn  int
+	Status       RoomStatus
+	TotalPlayers int
+	// TurnRemaining is the time left on the current turn in seconds, if the turn timer is enabled.
+	TurnRemaining float64 `json:",omitempty"`
 }
 
 // NewRoom creates a new Room with a specified ID.
 func NewRoom(roomID, host string) *Room {
 	return &Room{
-		ID:          roomID,
-		Host:        host,
-		Players:     make(map[string]*PlayerConnection),
-		Story:       []string{},
-		TurnOrder:   []string{},
-		CurrentTurn: 0,
-		Status:      "waiting",
+		ID:           roomID,
+		Host:         host,
+		Players:      make(map[string]*PlayerConnection),
+		Spectators:   make(map[string]*PlayerConnection),
+		Users:        make(map[string]string),
+		hostToken:    utils.GenerateToken(16),
+		playerTokens: make(map[string]string),
+		invites:      make(map[string]*Invite),
+		joinRequests: make(map[string]*JoinRequest),
+		Story:        []string{},
+		TurnOrder:    []string{},
+		CurrentTurn:  0,
+		Status:       StatusWaiting,
+		Rounds:       1,
+		ChatLimit:    ratelimit.DefaultConfig().PlayerChat,
+		Logger:       slog.Default().With("room_id", roomID),
+		Tracer:       noopTracer,
+	}
+}
+
+// now reads the room's clock.
+func (r *Room) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
 	}
+	return time.Now()
+}
+
+// Info returns a snapshot of the room's public state.
+func (r *Room) Info() RoomInfo {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	players := make([]string, 0, len(r.Players))
+	for name := range r.Players {
+		players = append(players, name)
+	}
+	info := RoomInfo{
+		ID:           r.ID,
+		StoryName:    r.StoryName,
+		Host:         r.Host,
+		Players:      players,
+		Story:        append([]string{}, r.Story...),
+		TurnOrder:    append([]string{}, r.TurnOrder...),
+		CurrentTurn:  r.CurrentTurn,
+		Status:       r.Status,
+		TotalPlayers: r.TotalPlayers,
+	}
+	if remaining := r.timeLeft(); remaining > 0 {
+		info.TurnRemaining = remaining.Seconds()
+	}
+	return info
+}
+
+// GetStatus returns the room's current status.
+func (r *Room) GetStatus() RoomStatus {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.Status
+}
+
+// PlayerCount returns the number of players in the room.
+func (r *Room) PlayerCount() int {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return len(r.Players)
 }
 
 // AddPlayer adds a player connection to the room.
@@ -41,65 +217,269 @@ func (r *Room) AddPlayer(playerName string) error {
 	defer r.Mutex.Unlock()
 
 	if _, exists := r.Players[playerName]; !exists {
-		r.Players[playerName] = &PlayerConnection{PlayerName: playerName, RoomID: r.ID}
-		r.TurnOrder = append(r.TurnOrder, playerName)
+		r.addPlayer(playerName)
 		return nil
 	}
 	return errors.New("player already exists")
 }
 
+// AddUser adds a registered user to the room under playerName and returns the
+// name they were given. If another player already has that name a numeric
+// suffix is added, so two accounts called "Alex" can share a room. A user who
+// is already in the room keeps their existing name.
+func (r *Room) AddUser(playerName, userID string) (string, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.admit(playerName, userID)
+}
+
+func (r *Room) addPlayer(playerName string) {
+	r.Players[playerName] = &PlayerConnection{PlayerName: playerName, RoomID: r.ID}
+	r.playerTokens[playerName] = utils.GenerateToken(16)
+	r.TurnOrder = append(r.TurnOrder, playerName)
+}
+
+// UserID returns the account a player joined with, or "" for guests.
+func (r *Room) UserID(playerName string) string {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.Users[playerName]
+}
+
+// RemovePlayer removes a player from the room and its turn order.
 func (r *Room) RemovePlayer(playerName string) {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	r.removePlayer(playerName)
+}
 
+func (r *Room) removePlayer(playerName string) {
 	delete(r.Players, playerName)
 	for i, name := range r.TurnOrder {
 		if name == playerName {
 			r.TurnOrder = append(r.TurnOrder[:i], r.TurnOrder[i+1:]...)
+			if i < r.CurrentTurn {
+				r.CurrentTurn--
+			}
 			break
 		}
 	}
+	if len(r.TurnOrder) > 0 {
+		r.CurrentTurn %= len(r.TurnOrder)
+	} else {
+		r.CurrentTurn = 0
+	}
+}
+
+// HandleDisconnect removes a player whose connection closed and keeps the game moving.
+// It is a no-op if the player has since reconnected on a different connection.
+// While the server is shutting down disconnects are ignored, so players stay
+// in the room for the next server.
+func (r *Room) HandleDisconnect(conn *PlayerConnection) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if r.suspended {
+		return
+	}
+	defer r.trace(context.Background(), "room.disconnect", attribute.String("player", conn.PlayerName))()
+	r.handleDisconnect(conn)
+}
+
+func (r *Room) handleDisconnect(conn *PlayerConnection) {
+	playerName := conn.PlayerName
+	if conn.Spectator {
+		if r.Spectators[playerName] == conn {
+			delete(r.Spectators, playerName)
+		}
+		return
+	}
+	if r.Players[playerName] != conn {
+		return
+	}
+	wasCurrent := r.currentPlayer() == playerName
+	r.removePlayer(playerName)
+	r.Logger.Info("Player left", "player", playerName)
+	r.broadcastMessage(playerName + " has left the game.")
+
+	if len(r.Players) == 0 {
+		// Handle cleanup if all players leave
+		if r.Status.IsFinished() || r.Status == StatusWaiting {
+			r.Status = StatusCompleted
+		} else {
+			r.Status = StatusAborted
+			r.emit(EventGameAborted, nil)
+		}
+		r.stopTurnTimer()
+		r.turnRemaining = 0
+		return
+	}
+	switch r.Status {
+	case StatusInProgress:
+		if wasCurrent {
+			r.beginTurn()
+		}
+		r.broadcastTurn() // Notify the next player if a player disconnects during a live game.
+	case StatusPaused:
+		if wasCurrent {
+			r.turnRemaining = r.TurnTimeout
+		}
+	}
 }
 
-// AddConnection assigns a WebSocket connection to a player.
+// AddConnection assigns a WebSocket connection to a player and replays the
+// chat history to it, so late joiners and reconnecting players catch up. The
+// caller must have checked the player's credential with Authenticate, since
+// the new connection replaces (and closes) any earlier one.
 func (r *Room) AddConnection(conn *PlayerConnection) error {
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
+	return r.addConnection(conn)
+}
+
+// addConnection is AddConnection for a caller holding the lock.
+func (r *Room) addConnection(conn *PlayerConnection) error {
 	if r.Players[conn.PlayerName] == nil {
-		return errors.New("player not found in room")
-	}
-	if r.Players[conn.PlayerName].Conn != nil {
-		r.Players[conn.PlayerName].Conn.Close()
+		return ErrPlayerNotInRoom
 	}
+	r.Players[conn.PlayerName].Close()
 	r.Players[conn.PlayerName] = conn
+	conn.logger().Info("Player connected")
+	conn.Send(Message{Type: "CHAT_HISTORY", Data: r.chatHistory()})
+	return nil
+}
+
+// AddSpectator registers a watch-only connection. Spectators receive every
+// broadcast and may react to story lines, but do not take turns. A name
+// already in use by a connected spectator is refused, so that nobody can
+// take over someone else's connection.
+func (r *Room) AddSpectator(conn *PlayerConnection) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.addSpectator(conn)
+}
+
+// addSpectator is AddSpectator for a caller holding the lock.
+func (r *Room) addSpectator(conn *PlayerConnection) error {
+	if _, exists := r.Players[conn.PlayerName]; exists {
+		return errors.New("name is already taken by a player")
+	}
+	if r.Spectators[conn.PlayerName] != nil {
+		return ErrPlayerNameTaken
+	}
+	conn.Spectator = true
+	r.Spectators[conn.PlayerName] = conn
+	conn.logger().Info("Spectator connected")
+	conn.Send(Message{Type: "CHAT_HISTORY", Data: r.chatHistory()})
 	return nil
 }
 
 // Start the game by setting the first player's turn
-func (r *Room) StartGame() {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
+func (r *Room) StartGame(ctx context.Context) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.start_game")()
 
-	r.Status = "in_progress"
+	if r.Status.IsFinished() {
+		return ErrGameFinished
+	}
+	if r.Status != StatusWaiting {
+		return ErrGameStarted
+	}
+	if len(r.TurnOrder) == 0 {
+		return errors.New("no players in room")
+	}
+	r.Status = StatusInProgress
 	r.CurrentTurn = 0
-	r.BroadcastMessage("Game started! It's " + r.TurnOrder[r.CurrentTurn] + "'s turn.")
+	r.emit(EventGameStarted, map[string]interface{}{"host": r.Host, "players": append([]string{}, r.TurnOrder...)})
+	r.beginTurn()
+	r.broadcastMessage("Game started! It's " + r.TurnOrder[r.CurrentTurn] + "'s turn.")
+	return nil
 }
 
-// Add a line to the story and move to the next turn
-func (r *Room) AddLine(playerName, line string) error {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
+// PauseGame freezes the current turn; only the host may pause.
+func (r *Room) PauseGame(ctx context.Context, playerName string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.pause_game", attribute.String("player", playerName))()
 
-	// Validate if it's the player's turn
-	if r.TurnOrder[r.CurrentTurn] != playerName {
-		return errors.New("it's not your turn")
+	if playerName != r.Host {
+		return ErrNotHost
 	}
+	if r.Status != StatusInProgress {
+		return ErrGameNotActive
+	}
+	r.turnRemaining = r.timeLeft()
+	r.stopTurnTimer()
+	r.Status = StatusPaused
+	r.emit(EventGamePaused, nil)
+	r.broadcast(Message{Type: "GAME_PAUSED", Content: "The host paused the game."})
+	return nil
+}
 
-	if _, exists := r.Players[playerName]; !exists {
-		return errors.New("player not found in room")
+// ResumeGame continues a paused game with whatever time was left on the turn.
+func (r *Room) ResumeGame(ctx context.Context, playerName string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.resume_game", attribute.String("player", playerName))()
+
+	if playerName != r.Host {
+		return ErrNotHost
+	}
+	if r.Status != StatusPaused {
+		return ErrGameNotPaused
+	}
+	remaining := r.turnRemaining
+	if remaining <= 0 {
+		remaining = r.TurnTimeout
+	}
+	r.Status = StatusInProgress
+	r.startTurnTimer(remaining)
+	r.turnRemaining = 0
+	r.emit(EventGameResumed, nil)
+	r.broadcast(Message{Type: "GAME_RESUMED", Content: "The host resumed the game."})
+	r.broadcastTurn()
+	return nil
+}
+
+// AbortGame ends the game without completing the story.
+func (r *Room) AbortGame(ctx context.Context, playerName string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.abort_game", attribute.String("player", playerName))()
+
+	if playerName != r.Host {
+		return ErrNotHost
+	}
+	if r.Status.IsFinished() {
+		return ErrGameFinished
+	}
+	r.abort("The host aborted the game.")
+	return nil
+}
+
+// abort stops an unfinished game and tells everyone why.
+func (r *Room) abort(reason string) {
+	r.stopTurnTimer()
+	r.turnRemaining = 0
+	r.Status = StatusAborted
+	r.emit(EventGameAborted, nil)
+	r.broadcast(Message{Type: "GAME_ABORTED", Content: reason})
+}
+
+// Add a line to the story and move to the next turn
+func (r *Room) AddLine(ctx context.Context, playerName, line string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.add_line", attribute.String("player", playerName))()
+
+	if err := r.checkCanSubmit(playerName); err != nil {
+		return err
+	}
+	if !r.allow(ratelimit.ActionSubmit) {
+		return ErrRateLimited
 	}
 	// Update story
-	r.Story = append(r.Story, line)
+	r.appendLine(playerName, line)
 	r.advanceTurn()
 
 	return nil
@@ -107,31 +487,84 @@ func (r *Room) AddLine(playerName, line string) error {
 
 // Move to the next player's turn, or end the game if all turns are completed
 func (r *Room) advanceTurn() {
-	r.CurrentTurn++
-	if r.CurrentTurn >= len(r.TurnOrder) {
-		r.Status = "completed"
-		r.BroadcastMessage("Game completed! Final story: " + r.GetStory())
+	r.CurrentTurn = (r.CurrentTurn + 1) % len(r.TurnOrder)
+	if r.isGameOver() {
+		r.broadcastMessage("Game completed! Final story: " + r.getStory())
+		r.endGame()
 	} else {
-		r.BroadcastMessage("It's " + r.TurnOrder[r.CurrentTurn] + "'s turn.")
+		r.beginTurn()
+		r.broadcastMessage("It's " + r.TurnOrder[r.CurrentTurn] + "'s turn.")
 	}
 }
 
-func (r *Room) BroadcastMessage(message string) {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
+// Broadcast sends a message to every connected player and spectator.
+func (r *Room) Broadcast(ctx context.Context, msg Message) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.announce")()
+	r.broadcast(msg)
+}
+
+// BroadcastMessage sends a plain-text message to every connected player.
+func (r *Room) BroadcastMessage(ctx context.Context, message string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.announce")()
+	r.broadcastMessage(message)
+}
+
+func (r *Room) broadcastMessage(message string) {
+	r.sendAll("text", func(conn *PlayerConnection) func() error {
+		return func() error { return conn.SendText(message) }
+	})
+}
+
+func (r *Room) broadcast(msg Message) {
+	r.sendAll(msg.Type, func(conn *PlayerConnection) func() error {
+		return func() error { return conn.Send(msg) }
+	})
+}
 
-	for _, player := range r.Players {
-		if player.Conn != nil {
-			player.Conn.WriteMessage(websocket.TextMessage, []byte(message))
+// sendAll sends a message of messageType to every connected player and
+// spectator, traced as a child of the current action.
+func (r *Room) sendAll(messageType string, send func(*PlayerConnection) func() error) {
+	ctx, span := r.Tracer.Start(r.spanContext(), "room.broadcast", trace.WithAttributes(
+		attribute.String("room.id", r.ID),
+		attribute.String("message.type", messageType),
+	))
+	defer span.End()
+	start := time.Now()
+	recipients := 0
+	for _, conns := range []map[string]*PlayerConnection{r.Players, r.Spectators} {
+		for _, conn := range conns {
+			if conn.Connected() {
+				recipients++
+				r.deliver(ctx, conn, send(conn))
+			}
 		}
 	}
+	span.SetAttributes(attribute.Int("recipients", recipients))
+	r.Metrics.Broadcast(time.Since(start))
 }
 
 // GetStory returns the full story as a single string
 func (r *Room) GetStory() string {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return r.getStory()
+}
+
+func (r *Room) getStory() string {
 	return "Story: " + strings.Join(r.Story, " ")
 }
 
+// StoryLines returns a copy of the story lines.
+func (r *Room) StoryLines() []string {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	return append([]string{}, r.Story...)
+}
+
 // BroadcastStoryUpdate sends the updated story to all players in the room.
 // func (r *Room) BroadcastStoryUpdate() {
 // r.connMutex.Lock()
@@ -144,49 +577,169 @@ func (r *Room) GetStory() string {
 // 	}
 // }
 
-func (r *Room) BroadcastTurn() {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
+// BroadcastTurn tells every player whose turn it is.
+func (r *Room) BroadcastTurn(ctx context.Context) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.announce_turn")()
+	r.broadcastTurn()
+}
 
-	currentPlayer := r.TurnOrder[r.CurrentTurn]
-	message := Message{Type: "TURN", Content: currentPlayer + "'s turn"}
+func (r *Room) broadcastTurn() {
+	currentPlayer := r.currentPlayer()
+	if currentPlayer == "" {
+		return
+	}
+	r.broadcast(Message{Type: "TURN", Content: currentPlayer + "'s turn"})
+}
 
-	for _, player := range r.Players {
-		player.Send(message)
+func (r *Room) currentPlayer() string {
+	if r.CurrentTurn < 0 || r.CurrentTurn >= len(r.TurnOrder) {
+		return ""
 	}
+	return r.TurnOrder[r.CurrentTurn]
 }
-func (r *Room) HandleSubmitLine(playerName, line string) {
-	// r.Mutex.Lock()
-	// defer r.Mutex.Unlock()
-	if r.Status != "in_progress" {
-		return
+
+// HandleSubmitLine appends a line from the current player and passes the turn on.
+func (r *Room) HandleSubmitLine(ctx context.Context, playerName, line string) error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.submit_line", attribute.String("player", playerName))()
+
+	if err := r.checkCanSubmit(playerName); err != nil {
+		return err
 	}
-	if r.TurnOrder[r.CurrentTurn] != playerName {
-		return
+	if !r.allow(ratelimit.ActionSubmit) {
+		return ErrRateLimited
+	}
+	r.appendLine(playerName, line)
+	r.broadcastMessage(playerName + " added a line to the story. \nNew story: " + r.getStory())
+	r.nextTurn()
+	return nil
+}
+
+func (r *Room) allow(action string) bool {
+	return r.AllowAction == nil || r.AllowAction(action)
+}
+
+func (r *Room) checkCanSubmit(playerName string) error {
+	switch r.Status {
+	case StatusInProgress:
+	case StatusPaused:
+		return ErrGamePaused
+	default:
+		return ErrGameNotActive
+	}
+	if _, exists := r.Players[playerName]; !exists {
+		return ErrPlayerNotInRoom
+	}
+	if r.currentPlayer() != playerName {
+		return ErrNotYourTurn
 	}
-	r.Story = append(r.Story, line)
-	r.BroadcastMessage(playerName + " added a line to the story. \nNew story: " + r.GetStory())
-	r.NextTurn()
+	return nil
 }
 
+// NextTurn passes the turn to the next player, ending the game when the story is long enough.
 func (r *Room) NextTurn() {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	r.nextTurn()
+}
+
+func (r *Room) nextTurn() {
+	if len(r.TurnOrder) == 0 {
+		return
+	}
 	r.CurrentTurn = (r.CurrentTurn + 1) % len(r.TurnOrder)
 
-	if r.isGameOver() { // Example end-game logic
-		r.EndGame()
+	if r.isGameOver() {
+		r.endGame()
 		return
 	}
-	r.BroadcastTurn()
+	r.beginTurn()
+	r.broadcastTurn()
 }
 
+// isGameOver reports whether the story is finished: it has MaxLines lines,
+// or the turn is back with the first player and the story has Rounds lines
+// for every player. A skipped turn writes no line, so the story runs on
+// until the lines are written.
 func (r *Room) isGameOver() bool {
-	return r.CurrentTurn == 0 && len(r.Story) >= r.TotalPlayers*5
+	if r.MaxLines > 0 && len(r.Story) >= r.MaxLines {
+		return true
+	}
+	return r.CurrentTurn == 0 && len(r.Story) >= r.Rounds*len(r.TurnOrder)
 }
 
+// EndGame marks the story as completed and notifies every player.
 func (r *Room) EndGame() {
-	r.Status = "completed"
-	endMessage := Message{Type: "END_GAME", Content: "Game over!"}
-	for _, player := range r.Players {
-		player.Send(endMessage)
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	r.endGame()
+}
+
+// endGame completes the story, computes awards and hands the record to OnGameEnd.
+func (r *Room) endGame() {
+	r.stopTurnTimer()
+	r.Status = StatusCompleted
+	record := r.storyRecord()
+	if r.OnGameEnd != nil {
+		r.OnGameEnd(record)
+	}
+	r.emit(EventStoryCompleted, record)
+	r.broadcast(Message{Type: "END_GAME", Content: "Game over!", Data: record})
+}
+
+// startTurnTimer arms the turn timer for d; a zero duration leaves the turn untimed.
+func (r *Room) startTurnTimer(d time.Duration) {
+	r.stopTurnTimer()
+	if d <= 0 {
+		return
+	}
+	r.turnDeadline = time.Now().Add(d)
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		r.Mutex.Lock()
+		defer r.Mutex.Unlock()
+		if r.turnTimer != timer || r.Status != StatusInProgress {
+			return // superseded by a later turn, pause or end of game
+		}
+		defer r.trace(context.Background(), "room.turn_timeout", attribute.String("player", r.currentPlayer()))()
+		r.turnTimer = nil
+		r.endTurn("timed_out")
+		r.Logger.Info("Turn timed out", "player", r.currentPlayer())
+		r.broadcast(Message{Type: "TURN_TIMEOUT", Content: r.currentPlayer() + " ran out of time."})
+		r.nextTurn()
+	})
+	r.turnTimer = timer
+}
+
+// endTurn records how long the current turn lasted and how it ended.
+func (r *Room) endTurn(outcome string) {
+	if !r.turnStarted.IsZero() {
+		r.Metrics.TurnEnded(outcome, time.Since(r.turnStarted))
+		r.turnStarted = time.Time{}
+	}
+}
+
+func (r *Room) stopTurnTimer() {
+	if r.turnTimer != nil {
+		r.turnTimer.Stop()
+		r.turnTimer = nil
+	}
+	r.turnDeadline = time.Time{}
+}
+
+// timeLeft returns the time remaining on the current turn, or zero if it is untimed.
+func (r *Room) timeLeft() time.Duration {
+	if r.Status == StatusPaused {
+		return r.turnRemaining
+	}
+	if r.turnTimer == nil {
+		return 0
+	}
+	if left := time.Until(r.turnDeadline); left > 0 {
+		return left
 	}
+	return 0
 }
@@ -0,0 +1,120 @@
+// internal/models/room_test.go
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestGameStateMachine walks a room through every host action, checking that
+// each is refused in the states where it makes no sense.
+func TestGameStateMachine(t *testing.T) {
+	ctx := context.Background()
+	room := NewRoom("ROOM", "Alice")
+	room.AddPlayer("Alice")
+	room.AddPlayer("Bob")
+
+	steps := []struct {
+		name   string
+		action func() error
+		want   error
+		status RoomStatus
+	}{
+		{"pause before the start", func() error { return room.PauseGame(ctx, "Alice") }, ErrGameNotActive, StatusWaiting},
+		{"resume before the start", func() error { return room.ResumeGame(ctx, "Alice") }, ErrGameNotPaused, StatusWaiting},
+		{"start", func() error { return room.StartGame(ctx) }, nil, StatusInProgress},
+		{"start in progress", func() error { return room.StartGame(ctx) }, ErrGameStarted, StatusInProgress},
+		{"pause by a player", func() error { return room.PauseGame(ctx, "Bob") }, ErrNotHost, StatusInProgress},
+		{"resume while running", func() error { return room.ResumeGame(ctx, "Alice") }, ErrGameNotPaused, StatusInProgress},
+		{"pause", func() error { return room.PauseGame(ctx, "Alice") }, nil, StatusPaused},
+		{"pause again", func() error { return room.PauseGame(ctx, "Alice") }, ErrGameNotActive, StatusPaused},
+		{"start while paused", func() error { return room.StartGame(ctx) }, ErrGameStarted, StatusPaused},
+		{"line while paused", func() error { return room.AddLine(ctx, "Alice", "Once.") }, ErrGamePaused, StatusPaused},
+		{"resume by a player", func() error { return room.ResumeGame(ctx, "Bob") }, ErrNotHost, StatusPaused},
+		{"resume", func() error { return room.ResumeGame(ctx, "Alice") }, nil, StatusInProgress},
+		{"line", func() error { return room.AddLine(ctx, "Alice", "Once upon a time.") }, nil, StatusInProgress},
+		{"abort by a player", func() error { return room.AbortGame(ctx, "Bob") }, ErrNotHost, StatusInProgress},
+		{"abort", func() error { return room.AbortGame(ctx, "Alice") }, nil, StatusAborted},
+		{"abort again", func() error { return room.AbortGame(ctx, "Alice") }, ErrGameFinished, StatusAborted},
+		{"start after abort", func() error { return room.StartGame(ctx) }, ErrGameFinished, StatusAborted},
+		{"resume after abort", func() error { return room.ResumeGame(ctx, "Alice") }, ErrGameNotPaused, StatusAborted},
+	}
+	for _, step := range steps {
+		if err := step.action(); !errors.Is(err, step.want) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.want)
+		}
+		if status := room.GetStatus(); status != step.status {
+			t.Fatalf("%s: status = %s, want %s", step.name, status, step.status)
+		}
+	}
+	if got := room.StoryLines(); len(got) != 1 {
+		t.Errorf("story = %q, want the one line written before the abort", got)
+	}
+}
+
+func TestStoryRecordKeepsPlayerTokens(t *testing.T) {
+	ctx := context.Background()
+	room := NewRoom("ROOM", "Alice")
+	room.AddPlayer("Alice")
+	room.AddPlayer("Bob")
+	var record *StoryRecord
+	room.OnGameEnd = func(r *StoryRecord) { record = r }
+
+	if err := room.StartGame(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := room.AddLine(ctx, name, name+" wrote this."); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if record == nil {
+		t.Fatal("the game did not end")
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if token := record.PlayerTokens[name]; token == "" || token != room.PlayerToken(name) {
+			t.Errorf("record token for %s = %q, want %q", name, token, room.PlayerToken(name))
+		}
+	}
+}
+
+// The story ends after Rounds lines per player, or at MaxLines if that comes first.
+func TestGameEndsAfterRoundsOrMaxLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		rounds    int
+		maxLines  int
+		wantLines int
+	}{
+		{"one round", 1, 0, 3},
+		{"two rounds", 2, 0, 6},
+		{"line cap mid-round", 2, 4, 4},
+		{"line cap beyond the rounds", 1, 10, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			room := NewRoom("ROOM", "Alice")
+			players := []string{"Alice", "Bob", "Carol"}
+			for _, name := range players {
+				room.AddPlayer(name)
+			}
+			room.Rounds, room.MaxLines = tt.rounds, tt.maxLines
+			if err := room.StartGame(ctx); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; room.GetStatus() == StatusInProgress; i++ {
+				if i > 10 {
+					t.Fatalf("the game did not end after %d lines", i)
+				}
+				if err := room.AddLine(ctx, players[i%len(players)], "A line."); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := len(room.StoryLines()); got != tt.wantLines {
+				t.Errorf("the game ended after %d lines, want %d", got, tt.wantLines)
+			}
+		})
+	}
+}
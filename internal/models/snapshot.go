@@ -0,0 +1,187 @@
+// internal/models/snapshot.go
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MessageServerRestarting is the type of the message sent to every client
+// before the server restarts. Clients should reconnect with the same room ID
+// and player name to resume.
+const MessageServerRestarting = "SERVER_RESTARTING"
+
+// RoomSnapshot is the saved state of an unfinished room, written when the
+// server shuts down so that the next server can restore it. Connections are
+// not saved; players reconnect by name.
+type RoomSnapshot struct {
+	ID           string            `json:"id"`
+	StoryName    string            `json:"story_name"`
+	Host         string            `json:"host"`
+	Users        map[string]string `json:"users,omitempty"`
+	Lines        []StoryLine       `json:"lines"`
+	TurnOrder    []string          `json:"turn_order"`
+	CurrentTurn  int               `json:"current_turn"`
+	Status       RoomStatus        `json:"status"`
+	TotalPlayers int               `json:"total_players"`
+	TurnTimeout  time.Duration     `json:"turn_timeout"`
+	// TurnRemaining is the time that was left on the current turn.
+	TurnRemaining time.Duration `json:"turn_remaining"`
+	Chat          []ChatMessage `json:"chat,omitempty"`
+	ChatNextID    int           `json:"chat_next_id"`
+	Private       bool          `json:"private,omitempty"`
+	HostToken     string        `json:"host_token,omitempty"`
+	// PlayerTokens are kept so players can still prove who they are.
+	PlayerTokens map[string]string `json:"player_tokens,omitempty"`
+	// Invites are kept; pending join requests are not, so requesters knock again.
+	Invites []Invite `json:"invites,omitempty"`
+	// Events is the room's event log, so it can still be replayed after a restart.
+	Events  []LoggedEvent `json:"events,omitempty"`
+	SavedAt time.Time     `json:"saved_at"`
+}
+
+// NotifyRestart tells every connected client that the server is restarting
+// and closes their connections. From then on disconnects no longer remove
+// players, so the room can be snapshotted with everyone still in it.
+func (r *Room) NotifyRestart() {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.suspended = true
+	msg := Message{
+		Type:    MessageServerRestarting,
+		Content: "The server is restarting. Reconnect to carry on with the story.",
+		Data:    map[string]string{"room_id": r.ID},
+	}
+	r.disconnectAll(msg, websocket.CloseServiceRestart, "server restarting")
+}
+
+// Suspend stops the room's turn timer and returns its state, or nil if the
+// game has already finished. The room must not be played afterwards.
+func (r *Room) Suspend() *RoomSnapshot {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.suspended = true
+	if r.Status.IsFinished() {
+		return nil
+	}
+	remaining := r.timeLeft()
+	r.stopTurnTimer()
+	lines := make([]StoryLine, len(r.Lines))
+	for i, l := range r.Lines {
+		lines[i] = *l
+	}
+	invites := make([]Invite, 0, len(r.invites))
+	for _, invite := range r.invites {
+		if invite.usable(r.now()) {
+			invites = append(invites, *invite)
+		}
+	}
+	users := make(map[string]string, len(r.Users))
+	for name, id := range r.Users {
+		users[name] = id
+	}
+	tokens := make(map[string]string, len(r.playerTokens))
+	for name, token := range r.playerTokens {
+		tokens[name] = token
+	}
+	return &RoomSnapshot{
+		ID:            r.ID,
+		StoryName:     r.StoryName,
+		Host:          r.Host,
+		Users:         users,
+		Lines:         lines,
+		TurnOrder:     append([]string{}, r.TurnOrder...),
+		CurrentTurn:   r.CurrentTurn,
+		Status:        r.Status,
+		TotalPlayers:  r.TotalPlayers,
+		TurnTimeout:   r.TurnTimeout,
+		TurnRemaining: remaining,
+		Chat:          r.chatHistory(),
+		ChatNextID:    r.chat.nextID,
+		Private:       r.Private,
+		HostToken:     r.hostToken,
+		PlayerTokens:  tokens,
+		Invites:       invites,
+		Events:        append([]LoggedEvent{}, r.eventLog...),
+		SavedAt:       r.now(),
+	}
+}
+
+// RestoreRoom rebuilds a room from a snapshot. Every player is in the room
+// but not connected. Set the hooks, then call Resume to restart the turn timer.
+func RestoreRoom(s *RoomSnapshot) *Room {
+	r := NewRoom(s.ID, s.Host)
+	r.StoryName = s.StoryName
+	r.CurrentTurn = s.CurrentTurn
+	r.Status = s.Status
+	r.TotalPlayers = s.TotalPlayers
+	r.TurnTimeout = s.TurnTimeout
+	r.turnRemaining = s.TurnRemaining
+	for _, name := range s.TurnOrder {
+		r.addPlayer(name)
+	}
+	for name, id := range s.Users {
+		r.Users[name] = id
+	}
+	for i := range s.Lines {
+		line := s.Lines[i]
+		r.Lines = append(r.Lines, &line)
+		r.Story = append(r.Story, line.Text)
+	}
+	for i := range s.Chat {
+		msg := s.Chat[i]
+		r.chat.messages = append(r.chat.messages, &msg)
+	}
+	r.chat.nextID = s.ChatNextID
+	r.Private = s.Private
+	if s.HostToken != "" {
+		r.hostToken = s.HostToken
+	}
+	for name, token := range s.PlayerTokens {
+		r.playerTokens[name] = token
+	}
+	for i := range s.Invites {
+		invite := s.Invites[i]
+		r.invites[invite.Token] = &invite
+	}
+	r.eventLog = s.Events
+	if n := len(s.Events); n > 0 {
+		r.eventSeq = s.Events[n-1].Seq
+	}
+	return r
+}
+
+// Resume restarts a restored game's turn timer with the time that was left.
+// A paused game stays paused.
+func (r *Room) Resume() {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if r.Status != StatusInProgress {
+		return
+	}
+	remaining := r.turnRemaining
+	if remaining <= 0 {
+		remaining = r.TurnTimeout
+	}
+	r.turnRemaining = 0
+	r.startTurnTimer(remaining)
+}
+
+// DropDisconnected removes the players who have not reconnected, as if they
+// had left the game.
+func (r *Room) DropDisconnected() {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(context.Background(), "room.drop_disconnected")()
+
+	for _, name := range append([]string{}, r.TurnOrder...) {
+		if conn := r.Players[name]; conn != nil && !conn.Connected() {
+			r.handleDisconnect(conn)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// internal/models/sse.go
+package models
+
+import (
+	"log/slog"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/pkg/utils"
+	"sync"
+	"time"
+)
+
+// sseBufferSize is how many recent events an SSE stream keeps for Last-Event-ID resume.
+const sseBufferSize = 256
+
+// SSEEvent is a message queued on an SSE stream with its resumable ID.
+type SSEEvent struct {
+	ID   int64
+	Data []byte
+}
+
+// SSEStream is a Transport for Server-Sent Events clients. Messages are
+// buffered with increasing IDs so a client that reconnects with Last-Event-ID
+// receives what it missed, and the stream outlives individual HTTP requests.
+type SSEStream struct {
+	mutex    sync.Mutex
+	events   []SSEEvent
+	nextID   int64
+	closed   bool
+	attached int
+	notify   chan struct{}
+}
+
+// NewSSEStream creates an empty stream.
+func NewSSEStream() *SSEStream {
+	return &SSEStream{nextID: 1, notify: make(chan struct{}, 1)}
+}
+
+// WriteText queues a message for the client.
+func (s *SSEStream) WriteText(data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.events = append(s.events, SSEEvent{ID: s.nextID, Data: append([]byte{}, data...)})
+	s.nextID++
+	if len(s.events) > sseBufferSize {
+		s.events = s.events[len(s.events)-sseBufferSize:]
+	}
+	s.signal()
+	return nil
+}
+
+// Close ends the stream; attached readers finish after draining queued events.
+func (s *SSEStream) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	s.signal()
+	return nil
+}
+
+// Since returns the buffered events after lastID and whether the stream is closed.
+func (s *SSEStream) Since(lastID int64) ([]SSEEvent, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var events []SSEEvent
+	for _, e := range s.events {
+		if e.ID > lastID {
+			events = append(events, e)
+		}
+	}
+	return events, s.closed
+}
+
+// Notify is signalled whenever events are queued or the stream closes.
+func (s *SSEStream) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// Attach marks an HTTP request as reading the stream; Detach undoes it.
+func (s *SSEStream) Attach() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attached++
+}
+
+// Detach ends a reader and reports whether any readers remain.
+func (s *SSEStream) Detach() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attached--
+	return s.attached > 0
+}
+
+// Attached reports whether a client is currently reading the stream.
+func (s *SSEStream) Attached() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.attached > 0
+}
+
+func (s *SSEStream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// AttachSSE connects playerName (a player, or a spectator if spectator is set)
+// over SSE. If they are already on an SSE stream it is returned so the client
+// can resume, keeping its message limit; otherwise a new stream limited by
+// limit replaces any existing connection, logging to logger. Both happen under
+// the room lock, so two clients attaching at once get the same stream.
+//
+// The caller must have authenticated a player. A new spectator stream is
+// given a Token, sent as its first message after the chat history, and a
+// spectator resumes only with that token.
+func (r *Room) AttachSSE(playerName string, spectator bool, token string, limit *ratelimit.Bucket, logger *slog.Logger) (*PlayerConnection, *SSEStream, error) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	existing := r.Players[playerName]
+	if spectator {
+		existing = r.Spectators[playerName]
+	}
+	if existing != nil {
+		if stream, ok := existing.Transport.(*SSEStream); ok {
+			if spectator && !existing.CheckToken(token) {
+				return nil, nil, ErrPlayerNameTaken
+			}
+			return existing, stream, nil
+		}
+	}
+
+	stream := NewSSEStream()
+	conn := NewTransportConnection(stream, r.ID, playerName, logger)
+	conn.MessageLimit = limit
+	if spectator {
+		conn.Token = utils.GenerateToken(16)
+		if err := r.addSpectator(conn); err != nil {
+			return nil, nil, err
+		}
+		conn.Send(Message{Type: "SPECTATOR_TOKEN", Content: conn.Token})
+	} else if err := r.addConnection(conn); err != nil {
+		return nil, nil, err
+	}
+	return conn, stream, nil
+}
+
+// DisconnectAfter removes an SSE client that has not reconnected within grace.
+func (r *Room) DisconnectAfter(conn *PlayerConnection, stream *SSEStream, grace time.Duration) {
+	time.AfterFunc(grace, func() {
+		if !stream.Attached() {
+			r.HandleDisconnect(conn)
+			stream.Close()
+		}
+	})
+}
+
+// Connection returns the connection for a player or spectator by name.
+func (r *Room) Connection(name string) *PlayerConnection {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if conn := r.Players[name]; conn != nil {
+		return conn
+	}
+	return r.Spectators[name]
+}
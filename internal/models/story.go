@@ -0,0 +1,216 @@
+// internal/models/story.go
+package models
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrLineNotFound = errors.New("story line not found")
+
+// Award names computed at the end of a game.
+const (
+	AwardMostReactedLine = "most_reacted_line"
+	AwardMostProlific    = "most_prolific_writer"
+	AwardCrowdFavorite   = "crowd_favorite"
+	AwardLongestLine     = "longest_line"
+)
+
+// StoryLine is a single line of the story with its author and reactions.
+type StoryLine struct {
+	ID        int                 `json:"id"` // 1-based position in the story
+	Author    string              `json:"author"`
+	Text      string              `json:"text"`
+	WrittenAt time.Time           `json:"written_at"`
+	Reactions map[string][]string `json:"reactions,omitempty"` // emoji -> players or spectators who reacted
+}
+
+// ReactionCounts returns how many times each emoji was used on the line.
+func (l *StoryLine) ReactionCounts() map[string]int {
+	counts := make(map[string]int, len(l.Reactions))
+	for emoji, who := range l.Reactions {
+		counts[emoji] = len(who)
+	}
+	return counts
+}
+
+func (l *StoryLine) totalReactions() int {
+	total := 0
+	for _, who := range l.Reactions {
+		total += len(who)
+	}
+	return total
+}
+
+// Award recognises a player (and for line awards, the line) at the end of a game.
+type Award struct {
+	Award  string `json:"award"`
+	Player string `json:"player"`
+	LineID int    `json:"line_id,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// StoryRecord is the finished story as it is stored once a game ends.
+type StoryRecord struct {
+	RoomID  string     `json:"room_id"`
+	Title   string     `json:"title,omitempty"`
+	Host    string     `json:"host"`
+	Status  RoomStatus `json:"status"`
+	Players []string   `json:"players"`
+	// PlayerUsers maps player names to account user IDs for registered players.
+	PlayerUsers map[string]string `json:"player_users,omitempty"`
+	// PlayerTokens maps player names to the secrets they were given on
+	// joining. They are never sent to clients.
+	PlayerTokens map[string]string `json:"-"`
+	Lines        []StoryLine       `json:"lines"`
+	Awards       []Award           `json:"awards"`
+	CompletedAt  time.Time         `json:"completed_at"`
+}
+
+// Text returns the story lines as plain strings.
+func (s *StoryRecord) Text() []string {
+	text := make([]string, len(s.Lines))
+	for i, l := range s.Lines {
+		text[i] = l.Text
+	}
+	return text
+}
+
+// appendLine records a line written by playerName in both the plain and detailed story.
+func (r *Room) appendLine(playerName, line string) {
+	r.Story = append(r.Story, line)
+	l := &StoryLine{
+		ID:        len(r.Lines) + 1,
+		Author:    playerName,
+		Text:      line,
+		WrittenAt: r.now(),
+	}
+	r.Lines = append(r.Lines, l)
+	r.endTurn("submitted")
+	r.Metrics.LineSubmitted()
+	r.record(EventLineAdded, *l)
+	if r.OnLineAdded != nil {
+		r.OnLineAdded(r.ID, *l)
+	}
+}
+
+// ReactToLine toggles a player's or spectator's emoji reaction on a story line
+// and pushes the line's updated counts to the room.
+func (r *Room) ReactToLine(ctx context.Context, name string, lineID int, emoji string) error {
+	if !IsReactionEmoji(emoji) {
+		return ErrInvalidReaction
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	defer r.trace(ctx, "room.react_to_line", attribute.String("player", name))()
+
+	if r.Players[name] == nil && r.Spectators[name] == nil {
+		return ErrPlayerNotInRoom
+	}
+	if r.Status.IsFinished() {
+		return ErrGameFinished
+	}
+	if lineID < 1 || lineID > len(r.Lines) {
+		return ErrLineNotFound
+	}
+	line := r.Lines[lineID-1]
+	if line.Reactions == nil {
+		line.Reactions = make(map[string][]string)
+	}
+	line.Reactions[emoji] = toggle(line.Reactions[emoji], name)
+	if len(line.Reactions[emoji]) == 0 {
+		delete(line.Reactions, emoji)
+	}
+	r.broadcast(Message{Type: "LINE_REACTION", ID: line.ID, Data: line.ReactionCounts()})
+	return nil
+}
+
+// storyRecord builds the stored form of the story, including awards.
+func (r *Room) storyRecord() *StoryRecord {
+	record := &StoryRecord{
+		RoomID:      r.ID,
+		Title:       r.StoryName,
+		Host:        r.Host,
+		Status:      r.Status,
+		Lines:       make([]StoryLine, len(r.Lines)),
+		CompletedAt: r.now(),
+	}
+	seen := map[string]bool{}
+	for _, name := range r.TurnOrder {
+		seen[name] = true
+		record.Players = append(record.Players, name)
+	}
+	for i, l := range r.Lines {
+		record.Lines[i] = *l
+		if !seen[l.Author] {
+			seen[l.Author] = true
+			record.Players = append(record.Players, l.Author)
+		}
+	}
+	for name, userID := range r.Users {
+		if record.PlayerUsers == nil {
+			record.PlayerUsers = make(map[string]string)
+		}
+		record.PlayerUsers[name] = userID
+	}
+	record.PlayerTokens = make(map[string]string, len(r.playerTokens))
+	for name, token := range r.playerTokens {
+		record.PlayerTokens[name] = token
+	}
+	record.Awards = computeAwards(r.Lines)
+	return record
+}
+
+// computeAwards picks the winners of each award. Ties go to the earlier line or,
+// for player awards, to the player whose first line came first.
+func computeAwards(lines []*StoryLine) []Award {
+	awards := []Award{}
+	if len(lines) == 0 {
+		return awards
+	}
+
+	var mostReacted, longest *StoryLine
+	linesBy := map[string]int{}
+	reactionsBy := map[string]int{}
+	var authors []string
+	for _, l := range lines {
+		if _, ok := linesBy[l.Author]; !ok {
+			authors = append(authors, l.Author)
+		}
+		linesBy[l.Author]++
+		reactionsBy[l.Author] += l.totalReactions()
+		if mostReacted == nil || l.totalReactions() > mostReacted.totalReactions() {
+			mostReacted = l
+		}
+		if longest == nil || len([]rune(l.Text)) > len([]rune(longest.Text)) {
+			longest = l
+		}
+	}
+
+	if n := mostReacted.totalReactions(); n > 0 {
+		awards = append(awards, Award{Award: AwardMostReactedLine, Player: mostReacted.Author, LineID: mostReacted.ID, Count: n})
+	}
+	if p := topPlayer(authors, linesBy); p != "" {
+		awards = append(awards, Award{Award: AwardMostProlific, Player: p, Count: linesBy[p]})
+	}
+	if p := topPlayer(authors, reactionsBy); p != "" && reactionsBy[p] > 0 {
+		awards = append(awards, Award{Award: AwardCrowdFavorite, Player: p, Count: reactionsBy[p]})
+	}
+	awards = append(awards, Award{Award: AwardLongestLine, Player: longest.Author, LineID: longest.ID, Count: len([]rune(longest.Text))})
+	return awards
+}
+
+// topPlayer returns the player with the highest score, preferring earlier players on ties.
+func topPlayer(players []string, score map[string]int) string {
+	ranked := append([]string{}, players...)
+	sort.SliceStable(ranked, func(i, j int) bool { return score[ranked[i]] > score[ranked[j]] })
+	if len(ranked) == 0 {
+		return ""
+	}
+	return ranked[0]
+}
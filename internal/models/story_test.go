@@ -0,0 +1,131 @@
+// internal/models/story_test.go
+package models
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestComputeAwards(t *testing.T) {
+	line := func(id int, author, text string, reactions map[string][]string) *StoryLine {
+		return &StoryLine{ID: id, Author: author, Text: text, Reactions: reactions}
+	}
+	tests := []struct {
+		name  string
+		lines []*StoryLine
+		want  []Award
+	}{
+		{"no lines", nil, []Award{}},
+		{
+			"no reactions",
+			[]*StoryLine{line(1, "Alice", "Once.", nil), line(2, "Bob", "Upon a time.", nil), line(3, "Alice", "The end.", nil)},
+			[]Award{
+				{Award: AwardMostProlific, Player: "Alice", Count: 2},
+				{Award: AwardLongestLine, Player: "Bob", LineID: 2, Count: 12},
+			},
+		},
+		{
+			"reactions",
+			[]*StoryLine{
+				line(1, "Alice", "A storm rolled in.", map[string][]string{"😂": {"Bob"}}),
+				line(2, "Bob", "The ship held.", map[string][]string{"❤️": {"Alice", "Carol"}, "😮": {"Dan"}}),
+				line(3, "Alice", "Mostly.", map[string][]string{"😂": {"Bob", "Carol"}, "❤️": {"Dan"}}),
+			},
+			[]Award{
+				{Award: AwardMostReactedLine, Player: "Bob", LineID: 2, Count: 3}, // tied with line 3; the earlier line wins
+				{Award: AwardMostProlific, Player: "Alice", Count: 2},
+				{Award: AwardCrowdFavorite, Player: "Alice", Count: 4},
+				{Award: AwardLongestLine, Player: "Alice", LineID: 1, Count: 18},
+			},
+		},
+		{
+			"ties go to the first writer",
+			[]*StoryLine{line(1, "Bob", "Ab.", map[string][]string{"😮": {"Alice"}}), line(2, "Alice", "Cd.", map[string][]string{"😮": {"Bob"}})},
+			[]Award{
+				{Award: AwardMostReactedLine, Player: "Bob", LineID: 1, Count: 1},
+				{Award: AwardMostProlific, Player: "Bob", Count: 1},
+				{Award: AwardCrowdFavorite, Player: "Bob", Count: 1},
+				{Award: AwardLongestLine, Player: "Bob", LineID: 1, Count: 3},
+			},
+		},
+		{
+			"line length counts characters, not bytes",
+			[]*StoryLine{line(1, "Alice", "ééééé", nil), line(2, "Bob", "abcdef", nil)},
+			[]Award{
+				{Award: AwardMostProlific, Player: "Alice", Count: 1},
+				{Award: AwardLongestLine, Player: "Bob", LineID: 2, Count: 6},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := computeAwards(tt.lines); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: computeAwards = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Reactions toggle, only the fixed emoji set is accepted, and the awards they
+// earn are in the stored story.
+func TestReactToLine(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	room := NewRoom("ROOM", "Alice")
+	room.Logger = logger
+	for _, name := range []string{"Alice", "Bob"} {
+		room.AddPlayer(name)
+		if _, _, err := room.AttachSSE(name, false, "", nil, logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := room.AttachSSE("Carol", true, "", nil, logger); err != nil {
+		t.Fatal(err)
+	}
+	var record *StoryRecord
+	room.OnGameEnd = func(r *StoryRecord) { record = r }
+	if err := room.StartGame(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := room.AddLine(ctx, "Alice", "The lighthouse went dark."); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name  string
+		who   string
+		line  int
+		emoji string
+		want  error
+	}{
+		{"player", "Bob", 1, "🔥", nil},
+		{"spectator", "Carol", 1, "🔥", nil},
+		{"another emoji", "Bob", 1, "😮", nil},
+		{"toggled off", "Bob", 1, "😮", nil},
+		{"not in the set", "Bob", 1, "🦄", ErrInvalidReaction},
+		{"no such line", "Bob", 2, "🔥", ErrLineNotFound},
+		{"not in the room", "Dan", 1, "🔥", ErrPlayerNotInRoom},
+	}
+	for _, step := range steps {
+		if err := room.ReactToLine(ctx, step.who, step.line, step.emoji); !errors.Is(err, step.want) {
+			t.Errorf("%s: error = %v, want %v", step.name, err, step.want)
+		}
+	}
+
+	room.EndGame()
+	if record == nil {
+		t.Fatal("OnGameEnd was not called")
+	}
+	if got, want := record.Lines[0].ReactionCounts(), map[string]int{"🔥": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reaction counts = %v, want %v", got, want)
+	}
+	if err := room.ReactToLine(ctx, "Bob", 1, "👍"); !errors.Is(err, ErrGameFinished) {
+		t.Errorf("reaction after the end: error = %v, want %v", err, ErrGameFinished)
+	}
+	want := Award{Award: AwardMostReactedLine, Player: "Alice", LineID: 1, Count: 2}
+	if len(record.Awards) == 0 || record.Awards[0] != want {
+		t.Errorf("awards = %+v, want %+v first", record.Awards, want)
+	}
+}
@@ -0,0 +1,50 @@
+// internal/models/tracing.go
+package models
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// noopTracer records nothing; rooms use it until they are given a Tracer.
+var noopTracer = noop.NewTracerProvider().Tracer("")
+
+// trace starts a span for a room action and makes it the parent of the spans
+// the action causes, such as broadcasts, until the returned func ends it. The
+// room must be locked for as long as the span is open.
+func (r *Room) trace(ctx context.Context, name string, attrs ...attribute.KeyValue) (end func()) {
+	ctx, span := r.Tracer.Start(ctx, name, trace.WithAttributes(append(attrs, attribute.String("room.id", r.ID))...))
+	r.traceCtx = ctx
+	return func() {
+		r.traceCtx = nil
+		span.End()
+	}
+}
+
+// spanContext returns the context of the action holding the lock, if it is traced.
+func (r *Room) spanContext() context.Context {
+	if r.traceCtx != nil {
+		return r.traceCtx
+	}
+	return context.Background()
+}
+
+// deliver sends one message of a broadcast in its own span, so that a
+// message that never reached a player shows up in the trace.
+func (r *Room) deliver(ctx context.Context, conn *PlayerConnection, send func() error) {
+	_, span := r.Tracer.Start(ctx, "room.send", trace.WithAttributes(
+		attribute.String("player", conn.PlayerName),
+		attribute.String("conn.id", conn.ID),
+		attribute.Bool("spectator", conn.Spectator),
+	))
+	defer span.End()
+	if err := send(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+		r.Metrics.MessageDropped("send_failed")
+	}
+}
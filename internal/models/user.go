@@ -0,0 +1,26 @@
+// internal/models/user.go
+package models
+
+import "time"
+
+// User is a registered player account. Guests play without one.
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	DisplayName  string    `json:"display_name"`
+	AvatarURL    string    `json:"avatar_url,omitempty"`
+	PasswordHash []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session is a logged-in session. Only a hash of the session token is kept.
+type Session struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the session is no longer valid at now.
+func (s *Session) Expired(now time.Time) bool {
+	return now.After(s.ExpiresAt)
+}
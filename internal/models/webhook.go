@@ -0,0 +1,15 @@
+// internal/models/webhook.go
+package models
+
+import "time"
+
+// WebhookSubscription receives events for one room, or for every room when
+// RoomID is empty.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	RoomID    string    `json:"room_id,omitempty"`
+	Events    []string  `json:"events,omitempty"` // empty means every event
+	CreatedAt time.Time `json:"created_at"`
+}
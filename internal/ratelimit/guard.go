@@ -0,0 +1,162 @@
+// internal/ratelimit/guard.go
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Room actions limited per room by Guard.AllowRoomAction.
+const (
+	ActionSubmit = "submit"
+	ActionChat   = "chat"
+)
+
+// Config holds every abuse limit. Rules are per key: HTTP per IP, room creation
+// and joins per client (the account if logged in, else the IP), failed logins
+// per username and per IP, submissions and chat per room, each player's chat
+// (applied by the room), and WebSocket messages per connection.
+type Config struct {
+	HTTP           Rule `yaml:"http" toml:"http" env:"RATE_LIMIT_HTTP"`
+	CreateRoom     Rule `yaml:"create_room" toml:"create_room" env:"RATE_LIMIT_CREATE_ROOM"`
+	JoinRoom       Rule `yaml:"join_room" toml:"join_room" env:"RATE_LIMIT_JOIN"`
+	FailedLogins   Rule `yaml:"failed_logins" toml:"failed_logins" env:"RATE_LIMIT_FAILED_LOGINS"`
+	FailedLoginsIP Rule `yaml:"failed_logins_ip" toml:"failed_logins_ip" env:"RATE_LIMIT_FAILED_LOGINS_IP"`
+	Submit         Rule `yaml:"submit" toml:"submit" env:"RATE_LIMIT_SUBMIT"`
+	Chat           Rule `yaml:"chat" toml:"chat" env:"RATE_LIMIT_CHAT"`
+	PlayerChat     Rule `yaml:"player_chat" toml:"player_chat" env:"RATE_LIMIT_PLAYER_CHAT"`
+	WSMessages     Rule `yaml:"ws_messages" toml:"ws_messages" env:"RATE_LIMIT_WS_MESSAGES"`
+	// WSMaxFrameBytes is the largest WebSocket message accepted; zero means no limit.
+	WSMaxFrameBytes int64 `yaml:"ws_max_frame_bytes" toml:"ws_max_frame_bytes" env:"WS_MAX_FRAME_BYTES"`
+	// MaxConnsPerIP caps concurrent WebSocket, SSE and streaming RPC connections; zero means no cap.
+	MaxConnsPerIP int `yaml:"max_conns_per_ip" toml:"max_conns_per_ip" env:"MAX_CONNS_PER_IP"`
+	// TrustProxy takes the client IP from X-Forwarded-For, for deployments behind a proxy.
+	TrustProxy bool `yaml:"trust_proxy" toml:"trust_proxy" env:"TRUST_PROXY"`
+}
+
+// DefaultConfig returns the limits used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		HTTP:            Rule{Burst: 300, Per: time.Minute},
+		CreateRoom:      Rule{Burst: 10, Per: time.Minute},
+		JoinRoom:        Rule{Burst: 30, Per: time.Minute},
+		FailedLogins:    Rule{Burst: 5, Per: 15 * time.Minute},
+		FailedLoginsIP:  Rule{Burst: 20, Per: 15 * time.Minute},
+		Submit:          Rule{Burst: 30, Per: 10 * time.Second},
+		Chat:            Rule{Burst: 60, Per: 10 * time.Second},
+		PlayerChat:      Rule{Burst: 5, Per: 10 * time.Second},
+		WSMessages:      Rule{Burst: 20, Per: 5 * time.Second},
+		WSMaxFrameBytes: 16 << 10,
+		MaxConnsPerIP:   20,
+	}
+}
+
+// Guard enforces a Config across the HTTP, WebSocket, SSE and RPC entry points.
+type Guard struct {
+	config     Config
+	http       *Limiter
+	createRoom *Limiter
+	joinRoom   *Limiter
+	loginsUser *Limiter
+	loginsIP   *Limiter
+	submit     *Limiter
+	chat       *Limiter
+	conns      *ConnCounter
+}
+
+// NewGuard creates a Guard enforcing cfg.
+func NewGuard(cfg Config) *Guard {
+	return &Guard{
+		config:     cfg,
+		http:       NewLimiter(cfg.HTTP),
+		createRoom: NewLimiter(cfg.CreateRoom),
+		joinRoom:   NewLimiter(cfg.JoinRoom),
+		loginsUser: NewLimiter(cfg.FailedLogins),
+		loginsIP:   NewLimiter(cfg.FailedLoginsIP),
+		submit:     NewLimiter(cfg.Submit),
+		chat:       NewLimiter(cfg.Chat),
+		conns:      NewConnCounter(cfg.MaxConnsPerIP),
+	}
+}
+
+// Config returns the limits being enforced.
+func (g *Guard) Config() Config {
+	return g.config
+}
+
+// AllowRequest limits HTTP requests per client IP.
+func (g *Guard) AllowRequest(ip string) (bool, time.Duration) {
+	return g.http.Allow(ip)
+}
+
+// AllowCreateRoom limits room creation per client.
+func (g *Guard) AllowCreateRoom(client string) (bool, time.Duration) {
+	return g.createRoom.Allow(client)
+}
+
+// AllowJoin limits joining rooms per client.
+func (g *Guard) AllowJoin(client string) (bool, time.Duration) {
+	return g.joinRoom.Allow(client)
+}
+
+// AllowLogin reports whether a login for username from ip may be tried: it
+// is refused once either has used up its failed logins. It takes nothing;
+// report failures with LoginFailed.
+func (g *Guard) AllowLogin(username, ip string) (bool, time.Duration) {
+	userOK, userWait := g.loginsUser.Check(username)
+	ipOK, ipWait := g.loginsIP.Check(ip)
+	return userOK && ipOK, max(userWait, ipWait)
+}
+
+// LoginFailed counts a failed login against username and ip.
+func (g *Guard) LoginFailed(username, ip string) {
+	g.loginsUser.Allow(username)
+	g.loginsIP.Allow(ip)
+}
+
+// AllowRoomAction limits submissions and chat per room, whoever sends them.
+func (g *Guard) AllowRoomAction(roomID, action string) bool {
+	var ok bool
+	switch action {
+	case ActionSubmit:
+		ok, _ = g.submit.Allow(roomID)
+	case ActionChat:
+		ok, _ = g.chat.Allow(roomID)
+	default:
+		ok = true
+	}
+	return ok
+}
+
+// AcquireConn reserves one of the IP's concurrent connection slots.
+func (g *Guard) AcquireConn(ip string) (release func(), ok bool) {
+	return g.conns.Acquire(ip)
+}
+
+// MessageBucket returns a bucket limiting one WebSocket connection's messages,
+// or nil if they are unlimited.
+func (g *Guard) MessageBucket() *Bucket {
+	return NewRuleBucket(g.config.WSMessages, time.Now())
+}
+
+// ClientIP returns the client address of a request from remoteAddr, honouring
+// X-Forwarded-For only when the Guard is configured to trust a proxy.
+func (g *Guard) ClientIP(remoteAddr string, header http.Header) string {
+	if g.config.TrustProxy {
+		if forwarded := header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	return HostIP(remoteAddr)
+}
+
+// HostIP strips the port from a host:port address.
+func HostIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,221 @@
+// internal/ratelimit/ratelimit.go
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often a Limiter forgets keys whose buckets have refilled.
+const sweepInterval = time.Minute
+
+// Rule allows bursts of up to Burst events, earning back Burst events every Per.
+// The zero Rule allows everything.
+type Rule struct {
+	Burst int
+	Per   time.Duration
+}
+
+// ParseRule reads a rule written as "burst/duration", e.g. "10/1m" for ten
+// events a minute. "off" or "0" disables the limit.
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" || s == "" {
+		return Rule{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("rate limit %q must look like 10/1m", s)
+	}
+	burst, err := strconv.Atoi(count)
+	if err != nil || burst < 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid count", s)
+	}
+	per, err := time.ParseDuration(period)
+	if err != nil || per <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid duration", s)
+	}
+	return Rule{Burst: burst, Per: per}, nil
+}
+
+// Enabled reports whether the rule limits anything.
+func (r Rule) Enabled() bool {
+	return r.Burst > 0 && r.Per > 0
+}
+
+// UnmarshalText lets rules be written as strings in configuration files.
+func (r *Rule) UnmarshalText(text []byte) error {
+	rule, err := ParseRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// MarshalText writes the rule in the form ParseRule reads.
+func (r Rule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r Rule) String() string {
+	if !r.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Burst, r.Per)
+}
+
+// Bucket is a token bucket. It is not safe for concurrent use; callers guard it
+// with their own lock.
+type Bucket struct {
+	tokens   float64
+	capacity float64
+	interval time.Duration // time to earn back one token
+	last     time.Time
+}
+
+// NewBucket creates a full bucket of capacity tokens, refilling one every interval.
+func NewBucket(capacity int, interval time.Duration, now time.Time) *Bucket {
+	return &Bucket{tokens: float64(capacity), capacity: float64(capacity), interval: interval, last: now}
+}
+
+// NewRuleBucket creates a full bucket for a rule, or nil if the rule is disabled.
+func NewRuleBucket(rule Rule, now time.Time) *Bucket {
+	if !rule.Enabled() {
+		return nil
+	}
+	return NewBucket(rule.Burst, rule.Per/time.Duration(rule.Burst), now)
+}
+
+// Take uses a token if one is available. A nil Bucket always allows.
+func (b *Bucket) Take(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryAfter is how long until a token is available.
+func (b *Bucket) RetryAfter(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(b.interval))
+}
+
+func (b *Bucket) refill(now time.Time) {
+	b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// Limiter applies a Rule separately to each key, such as an IP address or room.
+type Limiter struct {
+	rule      Rule
+	mutex     sync.Mutex
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates a Limiter; a disabled rule allows everything.
+func NewLimiter(rule Rule) *Limiter {
+	return &Limiter{rule: rule, buckets: make(map[string]*Bucket), lastSweep: time.Now()}
+}
+
+// Allow takes a token for key. When it is refused, it also returns how long
+// the caller should wait before trying again.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || !l.rule.Enabled() {
+		return true, 0
+	}
+	now := time.Now()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewRuleBucket(l.rule, now)
+		l.buckets[key] = b
+	}
+	if b.Take(now) {
+		return true, 0
+	}
+	return false, b.RetryAfter(now)
+}
+
+// Check reports whether Allow would succeed for key, and if not how long to
+// wait, without taking a token.
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	if l == nil || !l.rule.Enabled() {
+		return true, 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if wait := l.buckets[key].RetryAfter(time.Now()); wait > 0 {
+		return false, wait
+	}
+	return true, 0
+}
+
+// sweep forgets buckets that have refilled, since a new bucket would be identical.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.RetryAfter(now) == 0 && b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// ConnCounter caps concurrent connections per key. A max of zero means no cap.
+type ConnCounter struct {
+	max   int
+	mutex sync.Mutex
+	open  map[string]int
+}
+
+// NewConnCounter creates a counter allowing max connections per key.
+func NewConnCounter(max int) *ConnCounter {
+	return &ConnCounter{max: max, open: make(map[string]int)}
+}
+
+// Acquire reserves a connection slot for key. Call the returned release
+// function when the connection closes; ok is false if key is at its cap.
+func (c *ConnCounter) Acquire(key string) (release func(), ok bool) {
+	if c == nil || c.max <= 0 {
+		return func() {}, true
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.open[key] >= c.max {
+		return nil, false
+	}
+	c.open[key]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if c.open[key]--; c.open[key] <= 0 {
+				delete(c.open, key)
+			}
+		})
+	}, true
+}
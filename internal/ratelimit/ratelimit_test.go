@@ -0,0 +1,176 @@
+// internal/ratelimit/ratelimit_test.go
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rule
+		wantErr bool
+	}{
+		{"10/1m", Rule{Burst: 10, Per: time.Minute}, false},
+		{" 30/10s ", Rule{Burst: 30, Per: 10 * time.Second}, false},
+		{"off", Rule{}, false},
+		{"0", Rule{}, false},
+		{"", Rule{}, false},
+		{"10", Rule{}, true},
+		{"ten/1m", Rule{}, true},
+		{"-1/1m", Rule{}, true},
+		{"10/soon", Rule{}, true},
+		{"10/0s", Rule{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRule(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil {
+			if again, err := ParseRule(got.String()); err != nil || again != got {
+				t.Errorf("ParseRule(%q) does not read back %v: %v, %v", got.String(), got, again, err)
+			}
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	start := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	b := NewRuleBucket(Rule{Burst: 3, Per: 3 * time.Second}, start) // one token a second
+
+	for i := 0; i < 3; i++ {
+		if !b.Take(start) {
+			t.Fatalf("take %d of the burst refused", i+1)
+		}
+	}
+	if b.Take(start) {
+		t.Fatal("took a fourth token from a bucket of three")
+	}
+	if wait := b.RetryAfter(start); wait != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", wait)
+	}
+	if wait := b.RetryAfter(start.Add(400 * time.Millisecond)); wait != 600*time.Millisecond {
+		t.Errorf("RetryAfter 400ms later = %v, want 600ms", wait)
+	}
+	if !b.Take(start.Add(time.Second)) {
+		t.Error("no token a second later")
+	}
+	if b.Take(start.Add(time.Second)) {
+		t.Error("two tokens earned in one second")
+	}
+	// A long wait refills the bucket only up to its capacity.
+	later := start.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !b.Take(later) {
+			t.Fatalf("take %d after refilling refused", i+1)
+		}
+	}
+	if b.Take(later) {
+		t.Error("bucket refilled past its capacity")
+	}
+
+	unlimited := NewRuleBucket(Rule{}, start)
+	if unlimited != nil || !unlimited.Take(start) || unlimited.RetryAfter(start) != 0 {
+		t.Error("a disabled rule must give a nil bucket that always allows")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(Rule{Burst: 2, Per: time.Hour})
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatalf("request %d refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("1.2.3.4")
+	if ok || wait <= 0 || wait > 30*time.Minute {
+		t.Errorf("third request = %v, wait %v; want refused with a wait of up to 30m", ok, wait)
+	}
+	if ok, _ := l.Allow("5.6.7.8"); !ok {
+		t.Error("another key shares the first key's bucket")
+	}
+
+	off := NewLimiter(Rule{})
+	for i := 0; i < 1000; i++ {
+		if ok, _ := off.Allow("1.2.3.4"); !ok {
+			t.Fatal("a disabled rule refused a request")
+		}
+	}
+}
+
+func TestConnCounter(t *testing.T) {
+	c := NewConnCounter(2)
+	release1, ok1 := c.Acquire("1.2.3.4")
+	_, ok2 := c.Acquire("1.2.3.4")
+	if !ok1 || !ok2 {
+		t.Fatal("connections under the cap refused")
+	}
+	if _, ok := c.Acquire("1.2.3.4"); ok {
+		t.Error("connection over the cap allowed")
+	}
+	if _, ok := c.Acquire("5.6.7.8"); !ok {
+		t.Error("another IP counted against the first")
+	}
+	release1()
+	release1() // releasing twice frees one slot only
+	if _, ok := c.Acquire("1.2.3.4"); !ok {
+		t.Error("released slot not reusable")
+	}
+	if _, ok := c.Acquire("1.2.3.4"); ok {
+		t.Error("double release freed a second slot")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	header := http.Header{"X-Forwarded-For": {"203.0.113.7, 10.0.0.1"}}
+	if ip := NewGuard(Config{}).ClientIP("192.0.2.1:5555", header); ip != "192.0.2.1" {
+		t.Errorf("untrusted proxy: ClientIP = %q, want the peer address", ip)
+	}
+	if ip := NewGuard(Config{TrustProxy: true}).ClientIP("192.0.2.1:5555", header); ip != "203.0.113.7" {
+		t.Errorf("trusted proxy: ClientIP = %q, want the first forwarded address", ip)
+	}
+	if ip := NewGuard(Config{TrustProxy: true}).ClientIP("[2001:db8::1]:443", nil); ip != "2001:db8::1" {
+		t.Errorf("no forwarded header: ClientIP = %q", ip)
+	}
+}
+
+func TestGuardRoomActions(t *testing.T) {
+	g := NewGuard(Config{Submit: Rule{Burst: 1, Per: time.Hour}})
+	if !g.AllowRoomAction("TALES", ActionSubmit) || g.AllowRoomAction("TALES", ActionSubmit) {
+		t.Error("submissions are not limited to one per room")
+	}
+	if !g.AllowRoomAction("FABLE", ActionSubmit) {
+		t.Error("another room shares the first room's submission limit")
+	}
+	if !g.AllowRoomAction("TALES", ActionChat) || !g.AllowRoomAction("TALES", "other") {
+		t.Error("actions without a rule were limited")
+	}
+}
+
+// Logins are only counted when they fail, per username and per IP.
+func TestGuardLogins(t *testing.T) {
+	g := NewGuard(Config{
+		FailedLogins:   Rule{Burst: 2, Per: time.Hour},
+		FailedLoginsIP: Rule{Burst: 3, Per: time.Hour},
+	})
+	for i := 0; i < 5; i++ {
+		if ok, _ := g.AllowLogin("alice", "1.2.3.4"); !ok {
+			t.Fatalf("login %d refused without any failures", i+1)
+		}
+	}
+	g.LoginFailed("alice", "1.2.3.4")
+	g.LoginFailed("alice", "1.2.3.4")
+	if ok, wait := g.AllowLogin("alice", "5.6.7.8"); ok || wait <= 0 {
+		t.Errorf("alice after 2 failures = %v, wait %v; want refused", ok, wait)
+	}
+	if ok, _ := g.AllowLogin("bob", "1.2.3.4"); !ok {
+		t.Error("bob refused after 2 failures from the same IP")
+	}
+	g.LoginFailed("bob", "1.2.3.4")
+	if ok, _ := g.AllowLogin("carol", "1.2.3.4"); ok {
+		t.Error("carol allowed from an IP with 3 failures")
+	}
+}
@@ -0,0 +1,444 @@
+// internal/search/index.go
+package search
+
+import (
+	"errors"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// snippetRadius is how many characters of context are kept either side of the first match.
+const snippetRadius = 80
+
+// removedDoc marks the place in Index.order of a line that has been removed.
+const removedDoc = -1
+
+// ErrNoTerms is returned for query text with no words in it, such as "!!!".
+var ErrNoTerms = errors.New("query has no words to search for")
+
+// Document is a single indexed story line.
+type Document struct {
+	RoomID    string    `json:"room_id"`
+	LineID    int       `json:"line_id"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// Query is a parsed search request. Text uses a small syntax: plain words must
+// all appear, "quoted phrases" must appear in order, and word* matches any word
+// starting with word. Author and the From/To range filter the matching lines.
+type Query struct {
+	Text   string
+	Author string
+	From   time.Time
+	To     time.Time
+	Offset int
+	Limit  int
+}
+
+// Result is a matching line with its matches highlighted in Snippet using <mark> tags.
+type Result struct {
+	Document
+	Snippet string `json:"snippet"`
+}
+
+// Results is a page of search results.
+type Results struct {
+	Total   int      `json:"total"`
+	Results []Result `json:"results"`
+}
+
+// Index is an in-memory inverted index of story lines with word positions,
+// supporting phrase and prefix queries. It holds at most maxDocs lines,
+// dropping the oldest added first.
+type Index struct {
+	mutex    sync.RWMutex
+	maxDocs  int
+	nextID   int
+	docs     map[int]Document
+	order    []int                    // doc IDs, oldest added first, or removedDoc
+	position map[int]int              // doc ID -> its index in order
+	head     int                      // order before head holds only removedDoc
+	holes    int                      // how many of order are removedDoc
+	byLine   map[lineKey]int          // room and line -> doc ID
+	postings map[string]map[int][]int // term -> doc ID -> positions
+	terms    []string                 // every indexed term, sorted, for prefix lookups
+}
+
+// lineKey identifies a story line, so that indexing it twice replaces it.
+type lineKey struct {
+	roomID string
+	lineID int
+}
+
+// NewIndex creates an empty index holding at most maxDocs lines; zero or
+// less means no cap.
+func NewIndex(maxDocs int) *Index {
+	return &Index{
+		maxDocs:  maxDocs,
+		docs:     make(map[int]Document),
+		position: make(map[int]int),
+		byLine:   make(map[lineKey]int),
+		postings: make(map[string]map[int][]int),
+	}
+}
+
+// Add indexes a story line, replacing any earlier copy of the same line.
+func (idx *Index) Add(doc Document) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	key := lineKey{doc.RoomID, doc.LineID}
+	if old, ok := idx.byLine[key]; ok {
+		idx.remove(old)
+	}
+	for idx.maxDocs > 0 && len(idx.docs) >= idx.maxDocs {
+		for idx.order[idx.head] == removedDoc {
+			idx.head++
+		}
+		idx.remove(idx.order[idx.head])
+	}
+
+	id := idx.nextID
+	idx.nextID++
+	idx.docs[id] = doc
+	idx.position[id] = len(idx.order)
+	idx.order = append(idx.order, id)
+	idx.byLine[key] = id
+	for pos, tok := range tokenize(doc.Text) {
+		docs, ok := idx.postings[tok.term]
+		if !ok {
+			docs = make(map[int][]int)
+			idx.postings[tok.term] = docs
+			i := sort.SearchStrings(idx.terms, tok.term)
+			idx.terms = append(idx.terms, "")
+			copy(idx.terms[i+1:], idx.terms[i:])
+			idx.terms[i] = tok.term
+		}
+		docs[id] = append(docs[id], pos)
+	}
+}
+
+// remove drops a document and any terms only it contained. The caller holds
+// the write lock.
+func (idx *Index) remove(id int) {
+	doc := idx.docs[id]
+	delete(idx.docs, id)
+	delete(idx.byLine, lineKey{doc.RoomID, doc.LineID})
+	idx.order[idx.position[id]] = removedDoc
+	delete(idx.position, id)
+	if idx.holes++; idx.holes > len(idx.order)/2 {
+		idx.compact()
+	}
+	for _, tok := range tokenize(doc.Text) {
+		docs := idx.postings[tok.term]
+		delete(docs, id)
+		if len(docs) > 0 {
+			continue
+		}
+		delete(idx.postings, tok.term)
+		if i := sort.SearchStrings(idx.terms, tok.term); i < len(idx.terms) && idx.terms[i] == tok.term {
+			idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+		}
+	}
+}
+
+// compact drops the removed lines from order. The caller holds the write lock.
+func (idx *Index) compact() {
+	live := make([]int, 0, len(idx.docs))
+	for _, id := range idx.order {
+		if id != removedDoc {
+			idx.position[id] = len(live)
+			live = append(live, id)
+		}
+	}
+	idx.order, idx.head, idx.holes = live, 0, 0
+}
+
+// Len returns the number of indexed lines.
+func (idx *Index) Len() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return len(idx.docs)
+}
+
+// Search runs a query and returns matching lines, newest first. Text with no
+// words in it returns ErrNoTerms rather than matching every line.
+func (idx *Index) Search(q Query) (Results, error) {
+	clauses := parseQuery(q.Text)
+	if len(clauses) == 0 && strings.TrimSpace(q.Text) != "" {
+		return Results{Results: []Result{}}, ErrNoTerms
+	}
+	author := strings.ToLower(strings.TrimSpace(q.Author))
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	var matches []int
+	if len(clauses) == 0 {
+		// No text: filters alone select the lines.
+		matches = make([]int, 0, len(idx.docs))
+		for id := range idx.docs {
+			matches = append(matches, id)
+		}
+	} else {
+		var candidates map[int]bool
+		for _, c := range clauses {
+			candidates = intersect(candidates, idx.match(c))
+			if len(candidates) == 0 {
+				break
+			}
+		}
+		for id := range candidates {
+			matches = append(matches, id)
+		}
+	}
+
+	results := Results{Results: []Result{}}
+	filtered := matches[:0]
+	for _, id := range matches {
+		doc := idx.docs[id]
+		if author != "" && strings.ToLower(doc.Author) != author {
+			continue
+		}
+		if !q.From.IsZero() && doc.WrittenAt.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && doc.WrittenAt.After(q.To) {
+			continue
+		}
+		filtered = append(filtered, id)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		a, b := idx.docs[filtered[i]], idx.docs[filtered[j]]
+		if !a.WrittenAt.Equal(b.WrittenAt) {
+			return a.WrittenAt.After(b.WrittenAt)
+		}
+		return filtered[i] > filtered[j]
+	})
+
+	results.Total = len(filtered)
+	if q.Offset > 0 {
+		if q.Offset >= len(filtered) {
+			return results, nil
+		}
+		filtered = filtered[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(filtered) {
+		filtered = filtered[:q.Limit]
+	}
+	for _, id := range filtered {
+		doc := idx.docs[id]
+		results.Results = append(results.Results, Result{Document: doc, Snippet: highlight(doc.Text, clauses)})
+	}
+	return results, nil
+}
+
+// match returns the documents satisfying a single clause.
+func (idx *Index) match(c clause) map[int]bool {
+	found := map[int]bool{}
+	switch {
+	case c.prefix:
+		i := sort.SearchStrings(idx.terms, c.terms[0])
+		for ; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], c.terms[0]); i++ {
+			for id := range idx.postings[idx.terms[i]] {
+				found[id] = true
+			}
+		}
+	case len(c.terms) == 1:
+		for id := range idx.postings[c.terms[0]] {
+			found[id] = true
+		}
+	default:
+		for id, starts := range idx.postings[c.terms[0]] {
+			for _, start := range starts {
+				if idx.phraseAt(id, start, c.terms[1:]) {
+					found[id] = true
+					break
+				}
+			}
+		}
+	}
+	return found
+}
+
+// phraseAt reports whether rest follows the word at position start in a document.
+func (idx *Index) phraseAt(id, start int, rest []string) bool {
+	for i, term := range rest {
+		if !contains(idx.postings[term][id], start+i+1) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(positions []int, pos int) bool {
+	for _, p := range positions {
+		if p == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func intersect(a, b map[int]bool) map[int]bool {
+	if a == nil {
+		return b
+	}
+	out := map[int]bool{}
+	for id := range a {
+		if b[id] {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+// clause is one part of a query: a word, a prefix, or a phrase of several words.
+type clause struct {
+	terms  []string
+	prefix bool
+}
+
+func (c clause) matches(term string) bool {
+	if c.prefix {
+		return strings.HasPrefix(term, c.terms[0])
+	}
+	for _, t := range c.terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
+
+func parseQuery(text string) []clause {
+	var clauses []clause
+	for len(text) > 0 {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			break
+		}
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			phrase := text[1:]
+			text = ""
+			if end >= 0 {
+				phrase, text = phrase[:end], phrase[end+1:]
+			}
+			if terms := termsOf(phrase); len(terms) > 0 {
+				clauses = append(clauses, clause{terms: terms})
+			}
+			continue
+		}
+		word := text
+		if end := strings.IndexFunc(text, unicode.IsSpace); end >= 0 {
+			word, text = text[:end], text[end:]
+		} else {
+			text = ""
+		}
+		prefix := strings.HasSuffix(word, "*")
+		terms := termsOf(word)
+		if len(terms) == 0 {
+			continue
+		}
+		if prefix && len(terms) == 1 {
+			clauses = append(clauses, clause{terms: terms, prefix: true})
+		} else {
+			clauses = append(clauses, clause{terms: terms})
+		}
+	}
+	return clauses
+}
+
+func termsOf(text string) []string {
+	var terms []string
+	for _, tok := range tokenize(text) {
+		terms = append(terms, tok.term)
+	}
+	return terms
+}
+
+// token is a lower-cased word and its byte range in the original text.
+type token struct {
+	term       string
+	start, end int
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		wordChar := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if wordChar && start < 0 {
+			start = i
+		} else if !wordChar && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// highlight returns an HTML-escaped excerpt of text around the first match,
+// with every matching word wrapped in <mark>.
+func highlight(text string, clauses []clause) string {
+	var marks []token
+	for _, tok := range tokenize(text) {
+		for _, c := range clauses {
+			if c.matches(tok.term) {
+				marks = append(marks, tok)
+				break
+			}
+		}
+	}
+
+	from, to := 0, len(text)
+	if len(marks) > 0 {
+		from = clampToRune(text, marks[0].start-snippetRadius)
+		to = clampToRune(text, marks[0].end+snippetRadius)
+	} else if to > 2*snippetRadius {
+		to = clampToRune(text, 2*snippetRadius)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range marks {
+		if m.start < from || m.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m.start]))
+		b.WriteString("<mark>" + html.EscapeString(text[m.start:m.end]) + "</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// clampToRune bounds i to text and moves it back to the start of a UTF-8 character.
+func clampToRune(text string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && text[i]&0xC0 == 0x80 {
+		i--
+	}
+	return i
+}
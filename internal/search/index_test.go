@@ -0,0 +1,151 @@
+// internal/search/index_test.go
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want []clause
+	}{
+		{"", nil},
+		{"  Dragon  ", []clause{{terms: []string{"dragon"}}}},
+		{"dark forest", []clause{{terms: []string{"dark"}}, {terms: []string{"forest"}}}},
+		{`"dark forest" drag*`, []clause{{terms: []string{"dark", "forest"}}, {terms: []string{"drag"}, prefix: true}}},
+		{`"unclosed phrase`, []clause{{terms: []string{"unclosed", "phrase"}}}},
+		{"sea-side*", []clause{{terms: []string{"sea", "side"}}}}, // a prefix of several words is a phrase
+		{`!!! * "" "?"`, nil},
+	}
+	for _, tt := range tests {
+		if got := parseQuery(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQuery(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func testIndex(maxDocs int) *Index {
+	idx := NewIndex(maxDocs)
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	idx.Add(Document{RoomID: "TALES", LineID: 1, Author: "Alice", Text: "The dark forest hid a dragon.", WrittenAt: now})
+	idx.Add(Document{RoomID: "TALES", LineID: 2, Author: "Bob", Text: "A forest, dark & <deep>, dreaming.", WrittenAt: now.Add(time.Second)})
+	idx.Add(Document{RoomID: "FABLE", LineID: 1, Author: "Carol", Text: "Dragons sleep by the sea.", WrittenAt: now.Add(2 * time.Second)})
+	return idx
+}
+
+func lineIDs(results Results) []string {
+	var ids []string
+	for _, r := range results.Results {
+		ids = append(ids, r.RoomID+"/"+r.Author)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	idx := testIndex(0)
+	tests := []struct {
+		q    Query
+		want []string
+	}{
+		{Query{Text: "forest"}, []string{"TALES/Bob", "TALES/Alice"}},
+		{Query{Text: `"dark forest"`}, []string{"TALES/Alice"}},
+		{Query{Text: "drag*"}, []string{"FABLE/Carol", "TALES/Alice"}},
+		{Query{Text: "forest", Author: "BOB"}, []string{"TALES/Bob"}},
+		{Query{Text: "forest dragon"}, []string{"TALES/Alice"}},
+		{Query{Author: "carol"}, []string{"FABLE/Carol"}},
+		{Query{Text: "unicorn"}, nil},
+	}
+	for _, tt := range tests {
+		results, err := idx.Search(tt.q)
+		if err != nil {
+			t.Errorf("Search(%+v): %v", tt.q, err)
+			continue
+		}
+		if got := lineIDs(results); !reflect.DeepEqual(got, tt.want) || results.Total != len(tt.want) {
+			t.Errorf("Search(%+v) = %v (total %d), want %v", tt.q, got, results.Total, tt.want)
+		}
+	}
+
+	results, _ := idx.Search(Query{Text: "deep"})
+	if want := "A forest, dark &amp; &lt;<mark>deep</mark>&gt;, dreaming."; len(results.Results) != 1 || results.Results[0].Snippet != want {
+		t.Errorf("snippet = %+v, want %q", results.Results, want)
+	}
+}
+
+// Text with nothing to search for must not fall through to the filter-only
+// path and return every line.
+func TestSearchNeedsTerms(t *testing.T) {
+	idx := testIndex(0)
+	for _, text := range []string{"!!!", "*", `""`} {
+		results, err := idx.Search(Query{Text: text})
+		if !errors.Is(err, ErrNoTerms) || len(results.Results) != 0 {
+			t.Errorf("Search(%q) = %d results, %v; want none and %v", text, len(results.Results), err, ErrNoTerms)
+		}
+	}
+}
+
+func TestIndexCap(t *testing.T) {
+	idx := testIndex(2)
+	if idx.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", idx.Len())
+	}
+	// Alice's line was added first, so it went to make room for Carol's.
+	if results, _ := idx.Search(Query{Text: "drag*"}); !reflect.DeepEqual(lineIDs(results), []string{"FABLE/Carol"}) {
+		t.Errorf("after eviction drag* found %v", lineIDs(results))
+	}
+	if results, _ := idx.Search(Query{Text: "hid"}); results.Total != 0 {
+		t.Errorf("evicted line still matches: %v", lineIDs(results))
+	}
+	for _, term := range idx.terms {
+		if term == "hid" {
+			t.Error("term of an evicted line is still indexed")
+		}
+	}
+}
+
+// Rebuilding the index re-adds lines it may already hold; each line is kept once.
+func TestAddReplacesLine(t *testing.T) {
+	idx := testIndex(0)
+	idx.Add(Document{RoomID: "TALES", LineID: 1, Author: "Alice", Text: "The dark forest hid a dragon.", WrittenAt: time.Now()})
+	if idx.Len() != 3 {
+		t.Errorf("Len = %d after adding a line again, want 3", idx.Len())
+	}
+	if results, _ := idx.Search(Query{Text: `"dark forest"`}); results.Total != 1 {
+		t.Errorf(`"dark forest" matched %d lines, want 1`, results.Total)
+	}
+}
+
+// Lines replaced in the middle of the index leave it evicting the oldest
+// remaining line first.
+func TestIndexCapAfterReplacing(t *testing.T) {
+	idx := NewIndex(3)
+	add := func(lineID int, text string) {
+		idx.Add(Document{RoomID: "TALES", LineID: lineID, Author: "Alice", Text: text, WrittenAt: time.Now()})
+	}
+	add(1, "first")
+	add(2, "second")
+	add(3, "third")
+	for i := 0; i < 10; i++ {
+		add(2, "second again")
+	}
+	add(4, "fourth")
+	add(5, "fifth")
+
+	var got []string
+	for _, term := range []string{"first", "second", "third", "fourth", "fifth"} {
+		if results, _ := idx.Search(Query{Text: term}); results.Total > 0 {
+			got = append(got, term)
+		}
+	}
+	if want := []string{"second", "fourth", "fifth"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines kept = %v, want %v", got, want)
+	}
+	if len(idx.order)-idx.holes != idx.Len() || len(idx.position) != idx.Len() {
+		t.Errorf("order has %d entries and %d holes, position %d, for %d lines",
+			len(idx.order), idx.holes, len(idx.position), idx.Len())
+	}
+}
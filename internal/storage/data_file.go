@@ -0,0 +1,233 @@
+// internal/storage/data_file.go
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"storytelling-backend/internal/models"
+	"time"
+)
+
+// dataWriteDelay is how long changes are gathered before the data file is
+// rewritten, so that a burst of changes costs one write.
+const dataWriteDelay = time.Second
+
+// DataFileVersion is the format of the data files this server writes.
+const DataFileVersion = 1
+
+// dataFile is the layout of the file holding accounts, sessions, finished
+// stories, the gallery and webhook subscriptions. Fields the API never sends
+// to clients are kept by the record types below.
+type dataFile struct {
+	Version   int                `json:"version"`
+	Users     []*userRecord      `json:"users"`
+	Sessions  []*models.Session  `json:"sessions"`
+	Stories   []*storyRecord     `json:"stories"`
+	Published []*publishedRecord `json:"published"`
+	Webhooks  []*webhookRecord   `json:"webhooks,omitempty"`
+}
+
+type userRecord struct {
+	*models.User
+	PasswordHash []byte `json:"password_hash"`
+}
+
+type storyRecord struct {
+	*models.StoryRecord
+	PlayerTokens map[string]string `json:"player_tokens,omitempty"`
+}
+
+type publishedRecord struct {
+	*models.PublishedStory
+	Story   *storyRecord `json:"story"`
+	LikedBy []string     `json:"liked_by,omitempty"`
+}
+
+type webhookRecord struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func newStoryRecord(story *models.StoryRecord) *storyRecord {
+	if story == nil {
+		return nil
+	}
+	return &storyRecord{StoryRecord: story, PlayerTokens: story.PlayerTokens}
+}
+
+func (r *storyRecord) story() *models.StoryRecord {
+	if r == nil || r.StoryRecord == nil {
+		return nil
+	}
+	r.StoryRecord.PlayerTokens = r.PlayerTokens
+	return r.StoryRecord
+}
+
+// readDataFile reads the data saved in path. If the file does not exist the
+// error satisfies errors.Is(err, os.ErrNotExist).
+func readDataFile(path string) (*dataFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("data file: %w", err)
+	}
+	var file dataFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("data file %s: %w", path, err)
+	}
+	if file.Version > DataFileVersion {
+		return nil, fmt.Errorf("data file %s: version %d was written by a newer server (this one reads up to %d)",
+			path, file.Version, DataFileVersion)
+	}
+	return &file, nil
+}
+
+// writeDataFile replaces path with data, an encoded dataFile. The data is
+// written to a temporary file first so a crash never leaves a partial file.
+func writeDataFile(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// PersistDataTo loads the accounts, sessions, stories, gallery and webhook
+// subscriptions saved in path, if it exists, and rewrites the file in the
+// background shortly after any of them changes. Close writes the last
+// changes.
+func (ms *MemoryStorage) PersistDataTo(path string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	file, err := readDataFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = &dataFile{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	ms.dataFile = path
+	if ms.dataStop == nil {
+		ms.dataPending = make(chan struct{}, 1)
+		ms.dataStop = make(chan struct{})
+		ms.dataDone = make(chan struct{})
+		go ms.writeDataLoop()
+	}
+	for _, u := range file.Users {
+		if u.User == nil {
+			continue
+		}
+		u.User.PasswordHash = u.PasswordHash
+		ms.users[u.ID] = u.User
+	}
+	for _, s := range file.Sessions {
+		ms.sessions[s.TokenHash] = s
+	}
+	for _, s := range file.Stories {
+		if story := s.story(); story != nil {
+			ms.stories[story.RoomID] = story
+		}
+	}
+	for _, p := range file.Published {
+		if p.PublishedStory == nil {
+			continue
+		}
+		p.PublishedStory.Story = p.Story.story()
+		p.PublishedStory.LikedBy = p.LikedBy
+		ms.gallery[p.Slug] = p.PublishedStory
+	}
+	for _, w := range file.Webhooks {
+		if w.WebhookSubscription == nil {
+			continue
+		}
+		w.WebhookSubscription.Secret = w.Secret
+		ms.webhooks[w.ID] = w.WebhookSubscription
+	}
+	return nil
+}
+
+// dataChanged schedules a rewrite of the data file, if there is one. The
+// caller holds the write lock, which is never held while the file is written.
+func (ms *MemoryStorage) dataChanged() {
+	if ms.dataFile == "" {
+		return
+	}
+	select {
+	case ms.dataPending <- struct{}{}:
+	default: // a write is already due and will include this change
+	}
+}
+
+// writeDataLoop rewrites the data file dataWriteDelay after it changes, and
+// once more when the storage is closed if a write is still due.
+func (ms *MemoryStorage) writeDataLoop() {
+	defer close(ms.dataDone)
+	for {
+		select {
+		case <-ms.dataPending:
+			select {
+			case <-time.After(dataWriteDelay):
+			case <-ms.dataStop:
+			}
+			ms.writeData()
+		case <-ms.dataStop:
+			select {
+			case <-ms.dataPending:
+				ms.writeData()
+			default:
+			}
+			return
+		}
+	}
+}
+
+// writeData saves everything but the room snapshots to the data file. The
+// data is encoded with the read lock held and written without it; the error
+// is kept for Ping and Close.
+func (ms *MemoryStorage) writeData() {
+	ms.mutex.RLock()
+	data, err := json.MarshalIndent(ms.dataFileContents(), "", "  ")
+	ms.mutex.RUnlock()
+	if err == nil {
+		err = writeDataFile(ms.dataFile, data)
+	}
+	ms.mutex.Lock()
+	ms.dataErr = err
+	ms.mutex.Unlock()
+}
+
+// dataFileContents collects what the data file holds. The caller holds the
+// lock.
+func (ms *MemoryStorage) dataFileContents() *dataFile {
+	file := &dataFile{Version: DataFileVersion}
+	for _, u := range ms.users {
+		file.Users = append(file.Users, &userRecord{User: u, PasswordHash: u.PasswordHash})
+	}
+	sort.Slice(file.Users, func(i, j int) bool { return file.Users[i].ID < file.Users[j].ID })
+	for _, s := range ms.sessions {
+		file.Sessions = append(file.Sessions, s)
+	}
+	sort.Slice(file.Sessions, func(i, j int) bool { return file.Sessions[i].TokenHash < file.Sessions[j].TokenHash })
+	for _, s := range ms.stories {
+		file.Stories = append(file.Stories, newStoryRecord(s))
+	}
+	sort.Slice(file.Stories, func(i, j int) bool { return file.Stories[i].RoomID < file.Stories[j].RoomID })
+	for _, p := range ms.gallery {
+		file.Published = append(file.Published, &publishedRecord{PublishedStory: p, Story: newStoryRecord(p.Story), LikedBy: p.LikedBy})
+	}
+	sort.Slice(file.Published, func(i, j int) bool { return file.Published[i].Slug < file.Published[j].Slug })
+	for _, w := range ms.webhooks {
+		file.Webhooks = append(file.Webhooks, &webhookRecord{WebhookSubscription: w, Secret: w.Secret})
+	}
+	sort.Slice(file.Webhooks, func(i, j int) bool { return file.Webhooks[i].ID < file.Webhooks[j].ID })
+	return file
+}
@@ -0,0 +1,151 @@
+// internal/storage/data_file_test.go
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"storytelling-backend/internal/models"
+	"testing"
+	"time"
+)
+
+// Everything but the room snapshots is written to the data file, at the
+// latest by Close, including the fields that are never sent to clients.
+func TestDataFileSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "storytelling-data.json")
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: "user-1", Username: "alice", DisplayName: "Alice", AvatarURL: "https://example.com/a.png",
+		PasswordHash: []byte("hash"), CreatedAt: now}
+	session := &models.Session{TokenHash: "token-hash", UserID: "user-1", ExpiresAt: now.Add(time.Hour)}
+	story := &models.StoryRecord{
+		RoomID:       "TALES",
+		Host:         "Alice",
+		Status:       models.StatusCompleted,
+		Players:      []string{"Alice", "Bob"},
+		PlayerUsers:  map[string]string{"Alice": "user-1"},
+		PlayerTokens: map[string]string{"Bob": "bob-token"},
+		Lines:        []models.StoryLine{{ID: 1, Author: "Alice", Text: "Once upon a time.", WrittenAt: now}},
+		Awards:       []models.Award{},
+		CompletedAt:  now,
+	}
+	published := &models.PublishedStory{Slug: "tales-abc", RoomID: "TALES", Title: "Tales", Status: models.PublishPublished,
+		Story: story, Consent: map[string]bool{"Alice": true, "Bob": true}, Required: []string{"Alice", "Bob"},
+		LikedBy: []string{"user-1"}, Likes: 1, RequestedAt: now}
+
+	ms := NewMemoryStorage()
+	if err := ms.PersistDataTo(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		ms.CreateUser(user),
+		ms.SaveSession(session),
+		ms.SaveSession(&models.Session{TokenHash: "logged-out", UserID: "user-1", ExpiresAt: now}),
+		ms.DeleteSession("logged-out"),
+		ms.SaveStory(story),
+		ms.SavePublishedStory(published),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ms.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	restarted := NewMemoryStorage()
+	if err := restarted.PersistDataTo(path); err != nil {
+		t.Fatalf("PersistDataTo: %v", err)
+	}
+	gotUser, err := restarted.GetUserByUsername("alice")
+	if err != nil || !reflect.DeepEqual(gotUser, user) {
+		t.Errorf("user = %+v, %v; want %+v", gotUser, err, user)
+	}
+	gotSession, err := restarted.GetSession("token-hash")
+	if err != nil || !reflect.DeepEqual(gotSession, session) {
+		t.Errorf("session = %+v, %v; want %+v", gotSession, err, session)
+	}
+	if _, err := restarted.GetSession("logged-out"); err == nil {
+		t.Error("a deleted session came back")
+	}
+	gotStories, err := restarted.ListStoriesByUser("user-1")
+	if err != nil || len(gotStories) != 1 || !reflect.DeepEqual(gotStories[0], story) {
+		t.Errorf("stories = %+v, %v; want [%+v]", gotStories, err, story)
+	}
+	gotPublished, err := restarted.GetPublishedStory("tales-abc")
+	if err != nil || !reflect.DeepEqual(gotPublished, published) {
+		t.Errorf("published = %+v, %v; want %+v", gotPublished, err, published)
+	}
+}
+
+func TestDataFileFromNewerServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "users": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMemoryStorage().PersistDataTo(path); err == nil {
+		t.Error("a data file from a newer server was read")
+	}
+}
+
+// Changes reach the data file shortly after they are made, without Close.
+func TestDataFileWrittenInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	ms := NewMemoryStorage()
+	if err := ms.PersistDataTo(path); err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := ms.CreateUser(&models.User{ID: "user-" + name, Username: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(10 * dataWriteDelay)
+	for {
+		file, err := readDataFile(path)
+		if err == nil && len(file.Users) == 3 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("data file after %v = %+v, %v; want 3 users", 10*dataWriteDelay, file, err)
+		}
+		time.Sleep(dataWriteDelay / 10)
+	}
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	ms := NewMemoryStorage()
+	if err := ms.PersistDataTo(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*models.Session{
+		{TokenHash: "expired", UserID: "user-1", ExpiresAt: now.Add(-time.Minute)},
+		{TokenHash: "valid", UserID: "user-1", ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := ms.SaveSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := ms.DeleteExpiredSessions(now); n != 1 || err != nil {
+		t.Fatalf("DeleteExpiredSessions = %d, %v; want 1", n, err)
+	}
+	if err := ms.Close(); err != nil {
+		t.Fatal(err)
+	}
+	restarted := NewMemoryStorage()
+	if err := restarted.PersistDataTo(path); err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if _, err := restarted.GetSession("expired"); err == nil {
+		t.Error("the expired session was saved")
+	}
+	if _, err := restarted.GetSession("valid"); err != nil {
+		t.Errorf("the valid session was lost: %v", err)
+	}
+}
@@ -3,27 +3,371 @@ package storage
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"storytelling-backend/internal/models"
+	"sync"
+	"time"
 )
 
-var memoryStore = make(map[string]*models.Room)
+// MemoryStorage keeps everything in memory. Each instance has its own data,
+// so servers built on separate instances share nothing. Room snapshots, and
+// the rest of the data, can also be written to files (see PersistRoomsTo and
+// PersistDataTo) so they outlive the process.
+type MemoryStorage struct {
+	rooms    map[string]*models.RoomSnapshot
+	stories  map[string]*models.StoryRecord
+	gallery  map[string]*models.PublishedStory
+	users    map[string]*models.User
+	sessions map[string]*models.Session
+	webhooks map[string]*models.WebhookSubscription
+	mutex    sync.RWMutex
 
-type MemoryStorage struct{}
+	roomsFile string
+	dataFile  string
 
-func (ms *MemoryStorage) SaveRoom(room *models.Room) error {
-	memoryStore[room.ID] = room
+	// The data file is written by writeDataLoop: dataPending holds a token
+	// while a write is due, closing dataStop asks for a last write, and
+	// dataDone is closed after it. dataErr is the last write's error.
+	dataPending chan struct{}
+	dataStop    chan struct{}
+	dataDone    chan struct{}
+	dataErr     error
+	closeOnce   sync.Once
+}
+
+// NewMemoryStorage creates an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		rooms:    make(map[string]*models.RoomSnapshot),
+		stories:  make(map[string]*models.StoryRecord),
+		gallery:  make(map[string]*models.PublishedStory),
+		users:    make(map[string]*models.User),
+		sessions: make(map[string]*models.Session),
+		webhooks: make(map[string]*models.WebhookSubscription),
+	}
+}
+
+// PersistRoomsTo loads the room snapshots saved in path, if it exists, and
+// rewrites the file whenever a snapshot is saved or deleted.
+func (ms *MemoryStorage) PersistRoomsTo(path string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.roomsFile = path
+	rooms, _, err := ReadSnapshotFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, room := range rooms {
+		ms.rooms[room.ID] = room
+	}
 	return nil
 }
 
-func (ms *MemoryStorage) GetRoom(roomID string) (*models.Room, error) {
-	room, exists := memoryStore[roomID]
+// writeRooms saves the room snapshots to the file, if there is one.
+func (ms *MemoryStorage) writeRooms() error {
+	if ms.roomsFile == "" {
+		return nil
+	}
+	rooms := make([]*models.RoomSnapshot, 0, len(ms.rooms))
+	for _, room := range ms.rooms {
+		rooms = append(rooms, room)
+	}
+	return WriteSnapshotFile(ms.roomsFile, rooms)
+}
+
+func (ms *MemoryStorage) SaveRoom(room *models.RoomSnapshot) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.rooms[room.ID] = room
+	return ms.writeRooms()
+}
+
+func (ms *MemoryStorage) GetRoom(roomID string) (*models.RoomSnapshot, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	room, exists := ms.rooms[roomID]
 	if !exists {
 		return nil, errors.New("room not found")
 	}
 	return room, nil
 }
 
+func (ms *MemoryStorage) ListRooms() ([]*models.RoomSnapshot, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	rooms := make([]*models.RoomSnapshot, 0, len(ms.rooms))
+	for _, room := range ms.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
 func (ms *MemoryStorage) DeleteRoom(roomID string) error {
-	delete(memoryStore, roomID)
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if _, exists := ms.rooms[roomID]; !exists {
+		return nil
+	}
+	delete(ms.rooms, roomID)
+	return ms.writeRooms()
+}
+
+func (ms *MemoryStorage) SaveStory(story *models.StoryRecord) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.stories[story.RoomID] = story
+	ms.dataChanged()
 	return nil
 }
+
+func (ms *MemoryStorage) GetStory(roomID string) (*models.StoryRecord, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	story, exists := ms.stories[roomID]
+	if !exists {
+		return nil, errors.New("story not found")
+	}
+	return story, nil
+}
+
+func (ms *MemoryStorage) ListStoriesByUser(userID string) ([]*models.StoryRecord, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	var stories []*models.StoryRecord
+	for _, story := range ms.stories {
+		for _, id := range story.PlayerUsers {
+			if id == userID {
+				stories = append(stories, story)
+				break
+			}
+		}
+	}
+	return stories, nil
+}
+
+func (ms *MemoryStorage) ListStories() ([]*models.StoryRecord, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	stories := make([]*models.StoryRecord, 0, len(ms.stories))
+	for _, story := range ms.stories {
+		stories = append(stories, story)
+	}
+	return stories, nil
+}
+
+func (ms *MemoryStorage) SavePublishedStory(story *models.PublishedStory) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.gallery[story.Slug] = copyPublished(story)
+	ms.dataChanged()
+	return nil
+}
+
+// copyPublished copies a gallery entry's votes and likes, which the gallery
+// changes in place, so a stored entry only changes when it is saved.
+func copyPublished(story *models.PublishedStory) *models.PublishedStory {
+	c := *story
+	c.Consent = make(map[string]bool, len(story.Consent))
+	for name, approved := range story.Consent {
+		c.Consent[name] = approved
+	}
+	c.Required = append([]string{}, story.Required...)
+	c.LikedBy = append([]string{}, story.LikedBy...)
+	return &c
+}
+
+func (ms *MemoryStorage) GetPublishedStory(slug string) (*models.PublishedStory, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	story, exists := ms.gallery[slug]
+	if !exists {
+		return nil, errors.New("story not found")
+	}
+	return copyPublished(story), nil
+}
+
+func (ms *MemoryStorage) GetPublishedStoryByRoom(roomID string) (*models.PublishedStory, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	for _, story := range ms.gallery {
+		if story.RoomID == roomID {
+			return copyPublished(story), nil
+		}
+	}
+	return nil, errors.New("story not found")
+}
+
+func (ms *MemoryStorage) ListPublishedStories() ([]*models.PublishedStory, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	stories := make([]*models.PublishedStory, 0, len(ms.gallery))
+	for _, story := range ms.gallery {
+		stories = append(stories, copyPublished(story))
+	}
+	return stories, nil
+}
+
+func (ms *MemoryStorage) CreateUser(user *models.User) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	for _, u := range ms.users {
+		if u.Username == user.Username {
+			return ErrUsernameTaken
+		}
+	}
+	ms.users[user.ID] = user
+	ms.dataChanged()
+	return nil
+}
+
+func (ms *MemoryStorage) GetUser(userID string) (*models.User, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	user, exists := ms.users[userID]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (ms *MemoryStorage) GetUserByUsername(username string) (*models.User, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	for _, u := range ms.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (ms *MemoryStorage) SaveSession(session *models.Session) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.sessions[session.TokenHash] = session
+	ms.dataChanged()
+	return nil
+}
+
+func (ms *MemoryStorage) GetSession(tokenHash string) (*models.Session, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	session, exists := ms.sessions[tokenHash]
+	if !exists {
+		return nil, errors.New("session not found")
+	}
+	return session, nil
+}
+
+func (ms *MemoryStorage) DeleteSession(tokenHash string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if _, exists := ms.sessions[tokenHash]; !exists {
+		return nil
+	}
+	delete(ms.sessions, tokenHash)
+	ms.dataChanged()
+	return nil
+}
+
+func (ms *MemoryStorage) DeleteExpiredSessions(now time.Time) (int, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	deleted := 0
+	for tokenHash, session := range ms.sessions {
+		if session.Expired(now) {
+			delete(ms.sessions, tokenHash)
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		ms.dataChanged()
+	}
+	return deleted, nil
+}
+
+func (ms *MemoryStorage) SaveWebhook(sub *models.WebhookSubscription) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.webhooks[sub.ID] = sub
+	ms.dataChanged()
+	return nil
+}
+
+func (ms *MemoryStorage) DeleteWebhook(id string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if _, exists := ms.webhooks[id]; !exists {
+		return nil
+	}
+	delete(ms.webhooks, id)
+	ms.dataChanged()
+	return nil
+}
+
+func (ms *MemoryStorage) ListWebhooks() ([]*models.WebhookSubscription, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	subs := make([]*models.WebhookSubscription, 0, len(ms.webhooks))
+	for _, sub := range ms.webhooks {
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// Ping checks that a file can be written next to the room snapshot file and
+// the data file, for those in use, so that they can be saved, and that the
+// last write of the data file succeeded. Everything else is in memory and
+// always available.
+func (ms *MemoryStorage) Ping() error {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	if ms.roomsFile != "" {
+		if err := checkWritable(filepath.Dir(ms.roomsFile)); err != nil {
+			return fmt.Errorf("room snapshots: %w", err)
+		}
+	}
+	if ms.dataFile != "" {
+		if err := checkWritable(filepath.Dir(ms.dataFile)); err != nil {
+			return fmt.Errorf("data file: %w", err)
+		}
+		if ms.dataErr != nil {
+			return fmt.Errorf("data file: %w", ms.dataErr)
+		}
+	}
+	return nil
+}
+
+// Close writes the changes still waiting for the data file and stops the
+// background writer, returning the last write's error. The storage must not
+// be changed afterwards.
+func (ms *MemoryStorage) Close() error {
+	ms.closeOnce.Do(func() {
+		ms.mutex.RLock()
+		stop, done := ms.dataStop, ms.dataDone
+		ms.mutex.RUnlock()
+		if stop != nil {
+			close(stop)
+			<-done
+		}
+	})
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.dataErr
+}
+
+// checkWritable creates and removes a file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".ping-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,42 @@
+// internal/storage/memory_storage_test.go
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		roomsFile string
+		wantErr   bool
+	}{
+		{"no snapshot file", "", false},
+		{"writable directory", filepath.Join(dir, "rooms.json"), false},
+		{"missing directory", filepath.Join(dir, "missing", "rooms.json"), true},
+		{"not a directory", filepath.Join(notDir, "rooms.json"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStorage()
+			ms.roomsFile = tt.roomsFile
+			if err := ms.Ping(); (err != nil) != tt.wantErr {
+				t.Errorf("Ping() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Ping left files behind: %v", entries)
+	}
+}
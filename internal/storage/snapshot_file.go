@@ -0,0 +1,70 @@
+// internal/storage/snapshot_file.go
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"storytelling-backend/internal/models"
+)
+
+// SnapshotFileVersion is the format of the room snapshot files this server
+// writes. Version 0 files, written before the format was versioned, are a
+// bare JSON array of snapshots; they are still read.
+const SnapshotFileVersion = 1
+
+// snapshotFile is the layout of a versioned snapshot file.
+type snapshotFile struct {
+	Version int                    `json:"version"`
+	Rooms   []*models.RoomSnapshot `json:"rooms"`
+}
+
+// ReadSnapshotFile reads the room snapshots saved in path and the format
+// version they were written in. If the file does not exist the error
+// satisfies errors.Is(err, os.ErrNotExist).
+func ReadSnapshotFile(path string) ([]*models.RoomSnapshot, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("room snapshots: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var rooms []*models.RoomSnapshot
+		if err := json.Unmarshal(data, &rooms); err != nil {
+			return nil, 0, fmt.Errorf("room snapshots %s: %w", path, err)
+		}
+		return rooms, 0, nil
+	}
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, 0, fmt.Errorf("room snapshots %s: %w", path, err)
+	}
+	if file.Version > SnapshotFileVersion {
+		return nil, file.Version, fmt.Errorf("room snapshots %s: version %d was written by a newer server (this one reads up to %d)",
+			path, file.Version, SnapshotFileVersion)
+	}
+	return file.Rooms, file.Version, nil
+}
+
+// WriteSnapshotFile replaces path with rooms in the current format. The file
+// is replaced atomically so a crash never leaves half a snapshot behind.
+func WriteSnapshotFile(path string, rooms []*models.RoomSnapshot) error {
+	rooms = append([]*models.RoomSnapshot{}, rooms...)
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	data, err := json.MarshalIndent(snapshotFile{Version: SnapshotFileVersion, Rooms: rooms}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
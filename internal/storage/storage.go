@@ -1,10 +1,81 @@
 // internal/storage/storage.go
 package storage
 
-import "storytelling-backend/internal/models"
+import (
+	"errors"
+	"fmt"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/models"
+	"time"
+)
+
+var ErrUsernameTaken = errors.New("username already taken")
+
+// Open returns the storage backend selected by the configuration.
+func Open(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "memory":
+		store := NewMemoryStorage()
+		if cfg.SnapshotFile != "" {
+			if err := store.PersistRoomsTo(cfg.SnapshotFile); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.DataFile != "" {
+			if err := store.PersistDataTo(cfg.DataFile); err != nil {
+				return nil, err
+			}
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
 
 type Storage interface {
-	SaveRoom(roomID string) error
-	GetRoom(roomID string) (*models.Room, error)
+	// SaveRoom stores the snapshot of an unfinished room across a restart;
+	// ListRooms returns every stored snapshot.
+	SaveRoom(room *models.RoomSnapshot) error
+	GetRoom(roomID string) (*models.RoomSnapshot, error)
+	ListRooms() ([]*models.RoomSnapshot, error)
 	DeleteRoom(roomID string) error
+
+	// SaveStory stores a finished story; GetStory retrieves it by room ID.
+	SaveStory(story *models.StoryRecord) error
+	GetStory(roomID string) (*models.StoryRecord, error)
+	// ListStoriesByUser returns every stored story a registered user played in.
+	ListStoriesByUser(userID string) ([]*models.StoryRecord, error)
+	// ListStories returns every stored story, for exports.
+	ListStories() ([]*models.StoryRecord, error)
+
+	// SavePublishedStory stores a gallery entry; entries are found by slug or by room.
+	SavePublishedStory(story *models.PublishedStory) error
+	GetPublishedStory(slug string) (*models.PublishedStory, error)
+	GetPublishedStoryByRoom(roomID string) (*models.PublishedStory, error)
+	ListPublishedStories() ([]*models.PublishedStory, error)
+
+	// CreateUser stores a new account and fails if the username is taken.
+	CreateUser(user *models.User) error
+	GetUser(userID string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+
+	SaveSession(session *models.Session) error
+	GetSession(tokenHash string) (*models.Session, error)
+	DeleteSession(tokenHash string) error
+	// DeleteExpiredSessions removes every session that has expired by now
+	// and returns how many there were.
+	DeleteExpiredSessions(now time.Time) (int, error)
+
+	// SaveWebhook stores a webhook subscription, secret included, so that it
+	// outlives a restart; ListWebhooks returns every stored subscription.
+	SaveWebhook(sub *models.WebhookSubscription) error
+	DeleteWebhook(id string) error
+	ListWebhooks() ([]*models.WebhookSubscription, error)
+
+	// Ping reports whether the backend is reachable; the server is not
+	// ready to take traffic while it fails.
+	Ping() error
+	// Close saves whatever is still being written and releases the backend.
+	// The storage must not be used afterwards.
+	Close() error
 }
@@ -0,0 +1,196 @@
+// internal/storage/traced_storage.go
+package storage
+
+import (
+	"context"
+	"storytelling-backend/internal/models"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedStorage traces every call to a Storage. Storage calls take no
+// context, so each span starts its own trace.
+type TracedStorage struct {
+	Storage
+	tracer trace.Tracer
+}
+
+// Traced wraps store so that its calls are traced with tracer.
+func Traced(store Storage, tracer trace.Tracer) *TracedStorage {
+	return &TracedStorage{Storage: store, tracer: tracer}
+}
+
+// start begins the span for a call; end it with the call's error.
+func (s *TracedStorage) start(method string, attrs ...attribute.KeyValue) func(error) {
+	_, span := s.tracer.Start(context.Background(), "storage."+method,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (s *TracedStorage) SaveRoom(room *models.RoomSnapshot) error {
+	end := s.start("SaveRoom", attribute.String("room.id", room.ID))
+	err := s.Storage.SaveRoom(room)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) GetRoom(roomID string) (*models.RoomSnapshot, error) {
+	end := s.start("GetRoom", attribute.String("room.id", roomID))
+	v, err := s.Storage.GetRoom(roomID)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) ListRooms() ([]*models.RoomSnapshot, error) {
+	end := s.start("ListRooms")
+	v, err := s.Storage.ListRooms()
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) DeleteRoom(roomID string) error {
+	end := s.start("DeleteRoom", attribute.String("room.id", roomID))
+	err := s.Storage.DeleteRoom(roomID)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) SaveStory(story *models.StoryRecord) error {
+	end := s.start("SaveStory", attribute.String("room.id", story.RoomID))
+	err := s.Storage.SaveStory(story)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) GetStory(roomID string) (*models.StoryRecord, error) {
+	end := s.start("GetStory", attribute.String("room.id", roomID))
+	v, err := s.Storage.GetStory(roomID)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) ListStoriesByUser(userID string) ([]*models.StoryRecord, error) {
+	end := s.start("ListStoriesByUser", attribute.String("user.id", userID))
+	v, err := s.Storage.ListStoriesByUser(userID)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) ListStories() ([]*models.StoryRecord, error) {
+	end := s.start("ListStories")
+	v, err := s.Storage.ListStories()
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) SavePublishedStory(story *models.PublishedStory) error {
+	end := s.start("SavePublishedStory", attribute.String("gallery.slug", story.Slug))
+	err := s.Storage.SavePublishedStory(story)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) GetPublishedStory(slug string) (*models.PublishedStory, error) {
+	end := s.start("GetPublishedStory", attribute.String("gallery.slug", slug))
+	v, err := s.Storage.GetPublishedStory(slug)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) GetPublishedStoryByRoom(roomID string) (*models.PublishedStory, error) {
+	end := s.start("GetPublishedStoryByRoom", attribute.String("room.id", roomID))
+	v, err := s.Storage.GetPublishedStoryByRoom(roomID)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) ListPublishedStories() ([]*models.PublishedStory, error) {
+	end := s.start("ListPublishedStories")
+	v, err := s.Storage.ListPublishedStories()
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) CreateUser(user *models.User) error {
+	end := s.start("CreateUser", attribute.String("user.id", user.ID))
+	err := s.Storage.CreateUser(user)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) GetUser(userID string) (*models.User, error) {
+	end := s.start("GetUser", attribute.String("user.id", userID))
+	v, err := s.Storage.GetUser(userID)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) GetUserByUsername(username string) (*models.User, error) {
+	end := s.start("GetUserByUsername")
+	v, err := s.Storage.GetUserByUsername(username)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) SaveSession(session *models.Session) error {
+	end := s.start("SaveSession")
+	err := s.Storage.SaveSession(session)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) GetSession(tokenHash string) (*models.Session, error) {
+	end := s.start("GetSession")
+	v, err := s.Storage.GetSession(tokenHash)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) DeleteSession(tokenHash string) error {
+	end := s.start("DeleteSession")
+	err := s.Storage.DeleteSession(tokenHash)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) DeleteExpiredSessions(now time.Time) (int, error) {
+	end := s.start("DeleteExpiredSessions")
+	v, err := s.Storage.DeleteExpiredSessions(now)
+	end(err)
+	return v, err
+}
+
+func (s *TracedStorage) SaveWebhook(sub *models.WebhookSubscription) error {
+	end := s.start("SaveWebhook", attribute.String("webhook.id", sub.ID))
+	err := s.Storage.SaveWebhook(sub)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) DeleteWebhook(id string) error {
+	end := s.start("DeleteWebhook", attribute.String("webhook.id", id))
+	err := s.Storage.DeleteWebhook(id)
+	end(err)
+	return err
+}
+
+func (s *TracedStorage) ListWebhooks() ([]*models.WebhookSubscription, error) {
+	end := s.start("ListWebhooks")
+	v, err := s.Storage.ListWebhooks()
+	end(err)
+	return v, err
+}
+
+// Ping is not traced: readiness probes would bury the traces worth reading.
+func (s *TracedStorage) Ping() error {
+	return s.Storage.Ping()
+}
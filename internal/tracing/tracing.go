@@ -0,0 +1,89 @@
+// internal/tracing/tracing.go
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/logging"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ScopeName names the server's tracers.
+const ScopeName = "storytelling-backend"
+
+// Propagator reads and writes W3C trace context and baggage headers.
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// New creates the tracer provider for cfg; the stdout exporter writes to w.
+// With the "none" exporter nothing is recorded. Call shutdown to flush the
+// spans still buffered.
+func New(cfg config.TracingConfig, w io.Writer) (provider trace.TracerProvider, shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case "otlp":
+		exporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpointURL(cfg.Endpoint)))
+	default:
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+// endpointURL adds the OTLP traces path to a collector URL that has none.
+func endpointURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return endpoint
+	}
+	u.Path = "/v1/traces"
+	return u.String()
+}
+
+// Instrument traces the requests next serves for route, which should be the
+// route's pattern rather than the request path. It continues traces started
+// by the client and tags the request's logger with the trace_id.
+func Instrument(provider trace.TracerProvider, route string, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(logTraceID(next), route,
+		otelhttp.WithTracerProvider(provider),
+		otelhttp.WithPropagators(Propagator),
+		otelhttp.WithSpanNameFormatter(func(route string, r *http.Request) string {
+			return r.Method + " " + route
+		}),
+	)
+}
+
+func logTraceID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsSampled() {
+			logger := logging.FromContext(r.Context()).With("trace_id", sc.TraceID().String())
+			r = r.WithContext(logging.WithLogger(r.Context(), logger))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
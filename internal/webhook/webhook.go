@@ -0,0 +1,534 @@
+// internal/webhook/webhook.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/pkg/utils"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// EventRoomCreated is emitted by the room manager; the other event types come
+// from the room itself (see models.EventGameStarted and friends).
+const EventRoomCreated = "room.created"
+
+// Headers set on every delivery.
+const (
+	SignatureHeader = "X-Storytelling-Signature" // "sha256=" + hex HMAC-SHA256 of the body
+	EventHeader     = "X-Storytelling-Event"
+	DeliveryHeader  = "X-Storytelling-Delivery"
+)
+
+const (
+	deliveryLogSize = 500
+	deadLetterSize  = 500
+	queueSize       = 1000
+	workers         = 4
+)
+
+var (
+	ErrInvalidURL           = errors.New("webhook url must be an absolute http or https URL")
+	ErrForbiddenTarget      = errors.New("webhook url resolves to a loopback, private or link-local address")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeadLetterNotFound   = errors.New("dead letter not found")
+)
+
+// Event is the JSON body POSTed to subscribers.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	RoomID     string      `json:"room_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// Subscription receives events for one room, or for every room when RoomID is empty.
+type Subscription = models.WebhookSubscription
+
+// Store keeps subscriptions across restarts; storage.Storage is one.
+type Store interface {
+	SaveWebhook(sub *models.WebhookSubscription) error
+	DeleteWebhook(id string) error
+	ListWebhooks() ([]*models.WebhookSubscription, error)
+}
+
+// wants reports whether s subscribes to e.
+func wants(s *Subscription, e *Event) bool {
+	if s.RoomID != "" && s.RoomID != e.RoomID {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Attempt is one try at delivering an event, as kept in the delivery log.
+type Attempt struct {
+	DeliveryID     string    `json:"delivery_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Success        bool      `json:"success"`
+	At             time.Time `json:"at"`
+}
+
+// DeadLetter is a delivery that failed on every attempt.
+type DeadLetter struct {
+	DeliveryID     string    `json:"delivery_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Event          *Event    `json:"event"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	FailedAt       time.Time `json:"failed_at"`
+}
+
+type delivery struct {
+	id      string
+	sub     *Subscription
+	event   *Event
+	body    []byte
+	attempt int
+}
+
+// Options tunes delivery. Zero values fall back to the defaults.
+type Options struct {
+	Client      *http.Client  // default: NewClient with a 10 second timeout
+	MaxAttempts int           // default: 6
+	BaseBackoff time.Duration // delay before the first retry, doubled each time; default: 1s
+	MaxBackoff  time.Duration // default: 5m
+	Logger      *slog.Logger  // default: slog.Default()
+	Store       Store         // keeps subscriptions across restarts; default: none
+}
+
+// Dispatcher fans room events out to webhook subscriptions in the background.
+type Dispatcher struct {
+	opts Options
+
+	mutex       sync.Mutex
+	subs        map[string]*Subscription
+	log         []Attempt
+	deadLetters []DeadLetter
+
+	queue    chan *delivery
+	stop     chan struct{}
+	stopOnce sync.Once
+	pending  sync.WaitGroup // deliveries queued or waiting to retry
+	workers  sync.WaitGroup
+}
+
+// NewDispatcher creates a Dispatcher and starts its delivery workers.
+func NewDispatcher(opts Options) *Dispatcher {
+	if opts.Client == nil {
+		opts.Client = NewClient(10*time.Second, false)
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 6
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	d := &Dispatcher{
+		opts:  opts,
+		subs:  make(map[string]*Subscription),
+		queue: make(chan *delivery, queueSize),
+		stop:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Subscribe registers a webhook. A secret is generated if none is given; it is
+// returned once here and used to sign every payload.
+func (d *Dispatcher) Subscribe(rawURL, secret, roomID string, events []string) (*Subscription, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", ErrInvalidURL
+	}
+	if secret == "" {
+		secret = utils.GenerateToken(24)
+	}
+	sub := &Subscription{
+		ID:        "wh-" + utils.GenerateToken(6),
+		URL:       rawURL,
+		Secret:    secret,
+		RoomID:    roomID,
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.opts.Store != nil {
+		if err := d.opts.Store.SaveWebhook(sub); err != nil {
+			return nil, "", err
+		}
+	}
+	d.subs[sub.ID] = sub
+	return sub, secret, nil
+}
+
+// Unsubscribe removes a subscription. Deliveries already queued still go out.
+func (d *Dispatcher) Unsubscribe(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if _, ok := d.subs[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	if d.opts.Store != nil {
+		if err := d.opts.Store.DeleteWebhook(id); err != nil {
+			return err
+		}
+	}
+	delete(d.subs, id)
+	return nil
+}
+
+// Restore loads the subscriptions kept in the store, if there is one.
+func (d *Dispatcher) Restore() error {
+	if d.opts.Store == nil {
+		return nil
+	}
+	subs, err := d.opts.Store.ListWebhooks()
+	if err != nil {
+		return err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, sub := range subs {
+		d.subs[sub.ID] = sub
+	}
+	return nil
+}
+
+// Subscription returns a subscription by ID.
+func (d *Dispatcher) Subscription(id string) (*Subscription, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	sub, ok := d.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+// Subscriptions lists subscriptions, optionally only those for one room.
+func (d *Dispatcher) Subscriptions(roomID string) []Subscription {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	subs := []Subscription{}
+	for _, s := range d.subs {
+		if roomID == "" || s.RoomID == roomID {
+			subs = append(subs, *s)
+		}
+	}
+	return subs
+}
+
+// Emit queues an event for every interested subscription. It never blocks; if
+// the queue is full the delivery goes straight to the dead-letter list.
+func (d *Dispatcher) Emit(eventType, roomID string, data interface{}) {
+	event := &Event{
+		ID:         "evt-" + utils.GenerateToken(8),
+		Type:       eventType,
+		RoomID:     roomID,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.opts.Logger.Error("Error encoding webhook event", "event", eventType, "room_id", roomID, "error", err)
+		return
+	}
+
+	d.mutex.Lock()
+	var targets []*Subscription
+	for _, s := range d.subs {
+		if wants(s, event) {
+			targets = append(targets, s)
+		}
+	}
+	d.mutex.Unlock()
+
+	for _, sub := range targets {
+		d.enqueue(&delivery{id: "dlv-" + utils.GenerateToken(8), sub: sub, event: event, body: body})
+	}
+}
+
+// Deliveries returns the most recent delivery attempts, newest first,
+// optionally for one subscription.
+func (d *Dispatcher) Deliveries(subscriptionID string) []Attempt {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	attempts := []Attempt{}
+	for i := len(d.log) - 1; i >= 0; i-- {
+		if subscriptionID == "" || d.log[i].SubscriptionID == subscriptionID {
+			attempts = append(attempts, d.log[i])
+		}
+	}
+	return attempts
+}
+
+// DeadLetters returns deliveries that exhausted their retries.
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]DeadLetter{}, d.deadLetters...)
+}
+
+// RetryDeadLetter takes a delivery off the dead-letter list and tries it again
+// with a fresh set of attempts.
+func (d *Dispatcher) RetryDeadLetter(deliveryID string) error {
+	d.mutex.Lock()
+	var dl *DeadLetter
+	for i := range d.deadLetters {
+		if d.deadLetters[i].DeliveryID == deliveryID {
+			found := d.deadLetters[i]
+			dl = &found
+			d.deadLetters = append(d.deadLetters[:i], d.deadLetters[i+1:]...)
+			break
+		}
+	}
+	var sub *Subscription
+	if dl != nil {
+		sub = d.subs[dl.SubscriptionID]
+	}
+	d.mutex.Unlock()
+
+	if dl == nil {
+		return ErrDeadLetterNotFound
+	}
+	if sub == nil {
+		return ErrSubscriptionNotFound
+	}
+	body, err := json.Marshal(dl.Event)
+	if err != nil {
+		return err
+	}
+	d.enqueue(&delivery{id: dl.DeliveryID, sub: sub, event: dl.Event, body: body})
+	return nil
+}
+
+// Stop stops accepting retries, waits up to timeout for queued deliveries to
+// finish, then shuts the workers down. Calls after the first return at once.
+func (d *Dispatcher) Stop(timeout time.Duration) {
+	d.stopOnce.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			d.pending.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			d.opts.Logger.Warn("Webhook dispatcher stopped with deliveries still pending")
+		}
+		close(d.stop)
+		d.workers.Wait()
+	})
+}
+
+func (d *Dispatcher) enqueue(dl *delivery) {
+	d.pending.Add(1)
+	select {
+	case d.queue <- dl:
+	default:
+		d.pending.Done()
+		d.deadLetter(dl, "delivery queue full")
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case dl := <-d.queue:
+			d.deliver(dl)
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery. Failed deliveries are retried after
+// a backoff and stay counted as pending until they succeed or are dead-lettered.
+func (d *Dispatcher) deliver(dl *delivery) {
+	dl.attempt++
+	status, err := d.post(dl)
+
+	attempt := Attempt{
+		DeliveryID:     dl.id,
+		SubscriptionID: dl.sub.ID,
+		EventID:        dl.event.ID,
+		EventType:      dl.event.Type,
+		Attempt:        dl.attempt,
+		StatusCode:     status,
+		Success:        err == nil,
+		At:             time.Now(),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	d.record(attempt)
+
+	switch {
+	case err == nil:
+		d.pending.Done()
+	case dl.attempt >= d.opts.MaxAttempts:
+		d.deadLetter(dl, err.Error())
+		d.pending.Done()
+	default:
+		time.AfterFunc(d.backoff(dl.attempt), func() {
+			select {
+			case <-d.stop:
+				d.deadLetter(dl, "dispatcher stopped before retry")
+				d.pending.Done()
+			case d.queue <- dl:
+			}
+		})
+	}
+}
+
+func (d *Dispatcher) post(dl *delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, dl.sub.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, dl.event.Type)
+	req.Header.Set(DeliveryHeader, dl.id)
+	req.Header.Set(SignatureHeader, Sign(dl.sub.Secret, dl.body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before retrying after the given attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.opts.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > d.opts.MaxBackoff {
+		delay = d.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) record(a Attempt) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.log = append(d.log, a)
+	if len(d.log) > deliveryLogSize {
+		d.log = d.log[len(d.log)-deliveryLogSize:]
+	}
+}
+
+func (d *Dispatcher) deadLetter(dl *delivery, reason string) {
+	d.opts.Logger.Warn("Webhook delivery failed permanently", "delivery_id", dl.id, "webhook_id", dl.sub.ID,
+		"room_id", dl.event.RoomID, "url", dl.sub.URL, "reason", reason)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.deadLetters) >= deadLetterSize {
+		d.deadLetters = append(d.deadLetters[:0], d.deadLetters[1:]...)
+	}
+	d.deadLetters = append(d.deadLetters, DeadLetter{
+		DeliveryID:     dl.id,
+		SubscriptionID: dl.sub.ID,
+		Event:          dl.event,
+		Attempts:       dl.attempt,
+		LastError:      reason,
+		FailedAt:       time.Now(),
+	})
+}
+
+// NewClient returns the HTTP client deliveries are made with. Unless
+// allowPrivate is set it refuses to connect to loopback, private, link-local
+// and other non-public addresses. The check runs on the address actually
+// dialed, after DNS resolution and on every redirect, so a public name that
+// resolves to an internal address is refused too. Proxies from the
+// environment are ignored for the same reason.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// refusePrivate is a net.Dialer Control hook that fails connections to
+// addresses that are not publicly routable.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+	}
+	return nil
+}
+
+// sharedAddressSpace is carrier-grade NAT space (RFC 6598), which is not
+// publicly routable either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Sign returns the signature header value for body: "sha256=" followed by the
+// hex HMAC-SHA256 of the body keyed with the subscription secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header against body; receivers can use it to
+// authenticate deliveries.
+func Verify(secret string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
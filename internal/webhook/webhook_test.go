@@ -0,0 +1,221 @@
+// internal/webhook/webhook_test.go
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"storytelling-backend/internal/storage"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDispatcher(t *testing.T, opts Options) *Dispatcher {
+	t.Helper()
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	d := NewDispatcher(opts)
+	t.Cleanup(func() { d.Stop(time.Second) })
+	return d
+}
+
+func TestStopTwice(t *testing.T) {
+	d := newTestDispatcher(t, Options{})
+	d.Stop(time.Second)
+	d.Stop(time.Second) // must not close the stop channel again
+}
+
+func TestSignAndVerify(t *testing.T) {
+	// The HMAC-SHA256 example from Wikipedia.
+	body := []byte("The quick brown fox jumps over the lazy dog")
+	const want = "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if sig := Sign("key", body); sig != want {
+		t.Fatalf("Sign = %q, want %q", sig, want)
+	}
+	if !Verify("key", body, want) {
+		t.Error("Verify rejects a good signature")
+	}
+	for name, ok := range map[string]bool{
+		"another secret": Verify("other", body, want),
+		"a changed body": Verify("key", []byte("The quick brown fox"), want),
+		"no prefix":      Verify("key", body, want[len("sha256="):]),
+		"no signature":   Verify("key", body, ""),
+		"another scheme": Verify("key", body, "sha1="+want[len("sha256="):]),
+	} {
+		if ok {
+			t.Errorf("Verify accepts a signature with %s", name)
+		}
+	}
+}
+
+// TestDeliveryRetries has the receiver fail twice before accepting, and checks
+// each attempt is signed and logged.
+func TestDeliveryRetries(t *testing.T) {
+	var calls atomic.Int32
+	var badSignatures atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("secret", body, r.Header.Get(SignatureHeader)) || r.Header.Get(EventHeader) != "game.started" {
+			badSignatures.Add(1)
+		}
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(t, Options{Client: srv.Client(), BaseBackoff: time.Millisecond, MaxAttempts: 5})
+	sub, _, err := d.Subscribe(srv.URL, "secret", "TALES", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Emit("game.started", "TALES", nil)
+	d.Emit("game.started", "OTHER", nil) // not for this subscription
+	d.Stop(5 * time.Second)
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("receiver called %d times, want 3", got)
+	}
+	if badSignatures.Load() != 0 {
+		t.Error("a delivery was not signed with the subscription's secret")
+	}
+	attempts := d.Deliveries(sub.ID)
+	if len(attempts) != 3 || !attempts[0].Success || attempts[0].Attempt != 3 || attempts[2].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("delivery log = %+v, want two failures then a success", attempts)
+	}
+	if dead := d.DeadLetters(); len(dead) != 0 {
+		t.Errorf("dead letters = %+v, want none", dead)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(t, Options{Client: srv.Client(), BaseBackoff: time.Millisecond, MaxAttempts: 2})
+	if _, _, err := d.Subscribe(srv.URL, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	d.Emit("game.aborted", "TALES", nil)
+	d.Stop(5 * time.Second)
+
+	dead := d.DeadLetters()
+	if len(dead) != 1 || dead[0].Attempts != 2 || dead[0].Event.Type != "game.aborted" {
+		t.Fatalf("dead letters = %+v, want the event after 2 attempts", dead)
+	}
+	if err := d.RetryDeadLetter("no-such-delivery"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("RetryDeadLetter of an unknown delivery = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+}
+
+func TestDeadLetterCap(t *testing.T) {
+	d := newTestDispatcher(t, Options{})
+	sub := &Subscription{ID: "wh-test"}
+	for i := range deadLetterSize + 10 {
+		d.deadLetter(&delivery{id: fmt.Sprint(i), sub: sub, event: &Event{}}, "failed")
+	}
+	dead := d.DeadLetters()
+	if len(dead) != deadLetterSize || dead[0].DeliveryID != "10" {
+		t.Errorf("kept %d dead letters starting at %q, want the newest %d", len(dead), dead[0].DeliveryID, deadLetterSize)
+	}
+}
+
+func TestRefusePrivateTargets(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"127.0.0.1:80", true},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:443", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"100.64.0.1:80", true},
+		{"0.0.0.0:80", true},
+		{"[::1]:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+	}
+	for _, tt := range tests {
+		err := refusePrivate("tcp", tt.address, nil)
+		if refused := errors.Is(err, ErrForbiddenTarget); refused != tt.refused {
+			t.Errorf("refusePrivate(%s) = %v, want refused: %v", tt.address, err, tt.refused)
+		}
+	}
+
+	// The default client refuses a receiver on this machine, even by name.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	client := NewClient(time.Second, false)
+	for _, url := range []string{srv.URL, "http://localhost:" + port} {
+		resp, err := client.Post(url, "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrForbiddenTarget) {
+			t.Errorf("POST %s = %v, want %v", url, err, ErrForbiddenTarget)
+		}
+	}
+	// Operators can allow receivers on their own network.
+	resp, err := NewClient(time.Second, true).Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST with private targets allowed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestSubscriptionsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	open := func() (*Dispatcher, *storage.MemoryStorage) {
+		store := storage.NewMemoryStorage()
+		if err := store.PersistDataTo(path); err != nil {
+			t.Fatal(err)
+		}
+		d := newTestDispatcher(t, Options{Store: store})
+		if err := d.Restore(); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		return d, store
+	}
+
+	d, store := open()
+	kept, _, err := d.Subscribe("https://hooks.example/kept", "kept-secret", "TALES", []string{"story.completed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, _, err := d.Subscribe("https://hooks.example/dropped", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Unsubscribe(dropped.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	restarted, _ := open()
+	got, err := restarted.Subscription(kept.ID)
+	if err != nil {
+		t.Fatalf("subscription lost in the restart: %v", err)
+	}
+	if got.URL != kept.URL || got.Secret != "kept-secret" || got.RoomID != "TALES" || len(got.Events) != 1 {
+		t.Errorf("restored subscription = %+v, want %+v", got, kept)
+	}
+	if _, err := restarted.Subscription(dropped.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("deleted subscription came back: %v", err)
+	}
+}
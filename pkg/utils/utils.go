@@ -2,25 +2,58 @@
 package utils
 
 import (
-	"fmt"
-	"log"
-	"time"
-
-	"golang.org/x/exp/rand"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
 )
 
-func LogInfo(message string) {
-	log.Println("INFO: " + message)
+// RoomCodeAlphabet holds the characters room codes are made of: upper-case
+// letters and digits without the look-alikes 0, O, 1, I, L and Q, so a code
+// read out loud or copied by hand cannot be mistaken for another.
+const RoomCodeAlphabet = "ABCDEFGHJKMNPRSTUVWXYZ23456789"
+
+// GenerateRoomCode returns a random room code of length characters, each
+// drawn uniformly from RoomCodeAlphabet, such as "K7ZP3MWA". It uses crypto
+// randomness so codes cannot be predicted from earlier ones.
+func GenerateRoomCode(length int) string {
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = RoomCodeAlphabet[randomInt(len(RoomCodeAlphabet))]
+	}
+	return string(code)
 }
 
-func LogError(err error) {
+// InRoomCodeAlphabet reports whether every character of code, in upper case,
+// is in RoomCodeAlphabet.
+func InRoomCodeAlphabet(code string) bool {
+	for _, c := range code {
+		if !strings.ContainsRune(RoomCodeAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeRoomCode returns code as rooms are keyed: trimmed and in upper
+// case, so codes typed by hand find their room.
+func NormalizeRoomCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func randomInt(n int) int {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
 	if err != nil {
-		log.Println("ERROR: " + err.Error())
+		panic("crypto/rand failed: " + err.Error())
 	}
+	return int(i.Int64())
 }
 
-// GenerateRoomID creates a unique random room ID
-func GenerateRoomID() string {
-	rand.Seed(uint64(time.Now().UnixNano()))
-	return fmt.Sprintf("rm-%d-%d", rand.Intn(1000), time.Now().UnixMicro()%1000)
+// GenerateToken returns n bytes of crypto randomness, hex encoded.
+func GenerateToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
 }
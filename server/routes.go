@@ -0,0 +1,136 @@
+// server/routes.go
+package server
+
+import (
+	"net/http"
+	"storytelling-backend/gen/storytelling/v1/storytellingv1connect"
+	"storytelling-backend/internal/api"
+	"storytelling-backend/internal/cors"
+	"storytelling-backend/internal/metrics"
+	"storytelling-backend/internal/tracing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Route defines the structure for a route in the application.
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+}
+
+// newRouter builds the router serving h, with policy applied to every route
+// and every route's requests counted in stats and traced with provider.
+func newRouter(h *api.Handlers, policy *cors.Policy, stats *metrics.Metrics, provider trace.TracerProvider) *mux.Router {
+	instrument := func(route string, next http.Handler) http.Handler {
+		return tracing.Instrument(provider, route, stats.Instrument(route, next))
+	}
+	router := mux.NewRouter()
+	if stats != nil {
+		router.Handle("/metrics", stats.Handler()).Methods("GET")
+	}
+	// Probes are neither rate limited nor traced, so the orchestrator polling
+	// them cannot be throttled and does not bury the traces worth reading.
+	router.HandleFunc("/healthz", h.HealthHandler).Methods("GET")
+	router.HandleFunc("/readyz", h.ReadyHandler).Methods("GET")
+	routes := []Route{
+		// Deprecated verb-style aliases of the /api/v1 routes below.
+		{"POST", "/create-room", deprecated("/api/v1/rooms", h.CreateRoomHandler)},
+		{"POST", "/join-room", deprecated("/api/v1/rooms/{room_id}/players", h.JoinRoomHandler)},
+		{"POST", "/start-game/{room_id}", deprecated("/api/v1/rooms/{room_id}/start", h.StartGameHandler)},
+		{"POST", "/submit-line", deprecated("/api/v1/rooms/{room_id}/lines", h.SubmitLineHandler)},
+		{"GET", "/get-story", deprecated("/api/v1/rooms/{room_id}/story", h.GetStoryHandler)},
+		{"GET", "/get-room", deprecated("/api/v1/rooms/{room_id}", h.GetRoomHandler)},
+		{"GET", "/list-rooms", deprecated("/api/v1/rooms", h.ListRoomsHandler)},
+		{"GET", "/ws", h.WebSocketHandler},
+		{"GET", "/rooms/{room_id}/events/stream", h.EventStreamHandler},
+		{"POST", "/rooms/{room_id}/actions", h.RoomActionHandler},
+		{"POST", "/rooms/{room_id}/invites", h.CreateInviteHandler},
+		{"GET", "/rooms/{room_id}/join-requests", h.JoinRequestsHandler},
+		{"GET", "/rooms/{room_id}/join-requests/{request_id}", h.JoinRequestHandler},
+		{"POST", "/rooms/{room_id}/join-requests/{request_id}", h.DecideJoinHandler},
+		{"POST", "/register", h.RegisterHandler},
+		{"POST", "/login", h.LoginHandler},
+		{"POST", "/logout", h.LogoutHandler},
+		{"GET", "/me", h.MeHandler},
+		{"GET", "/users/{user_id}/stories", h.UserStoriesHandler},
+		{"GET", "/users/{user_id}/stats", h.UserStatsHandler},
+		{"POST", "/stories/{room_id}/publish", h.PublishStoryHandler},
+		{"POST", "/stories/{room_id}/consent", h.ConsentHandler},
+		{"GET", "/stories/{room_id}/publication", h.PublicationHandler},
+		{"GET", "/search", h.SearchHandler},
+		{"POST", "/webhooks", h.CreateWebhookHandler},
+		{"GET", "/webhooks", h.ListWebhooksHandler},
+		{"GET", "/webhooks/deliveries", h.WebhookDeliveriesHandler},
+		{"GET", "/webhooks/dead-letters", h.DeadLettersHandler},
+		{"POST", "/webhooks/dead-letters/{id}/retry", h.RetryDeadLetterHandler},
+		{"DELETE", "/webhooks/{id}", h.DeleteWebhookHandler},
+		{"GET", "/admin/rooms", h.AdminRoomsHandler},
+		{"GET", "/admin/rooms/{room_id}", h.AdminRoomHandler},
+		{"DELETE", "/admin/rooms/{room_id}", h.AdminDeleteRoomHandler},
+		{"GET", "/admin/rooms/{room_id}/events", h.AdminRoomEventsHandler},
+		{"POST", "/admin/rooms/{room_id}/end", h.AdminEndRoomHandler},
+		{"DELETE", "/admin/rooms/{room_id}/players/{player_name}", h.AdminDisconnectPlayerHandler},
+		{"POST", "/admin/announcements", h.AdminAnnounceHandler},
+		{"GET", "/admin/stories", h.AdminStoriesHandler},
+		{"GET", "/gallery", h.GalleryHandler},
+		{"GET", "/gallery/search", h.GallerySearchHandler},
+		{"GET", "/gallery/{slug}", h.GalleryStoryHandler},
+		{"POST", "/gallery/{slug}/like", h.LikeStoryHandler},
+		{"DELETE", "/gallery/{slug}/like", h.LikeStoryHandler},
+	}
+
+	handleRoutes(router, policy, instrument, routes, h.RateLimit)
+
+	// The versioned resource API; requests are validated against its OpenAPI document.
+	v1Routes := []Route{
+		{"GET", "/api/v1/openapi.json", api.OpenAPIHandler},
+		{"GET", "/api/v1/rooms", h.ListRoomsV1Handler},
+		{"POST", "/api/v1/rooms", h.CreateRoomV1Handler},
+		{"GET", "/api/v1/rooms/{room_id}", h.GetRoomV1Handler},
+		{"POST", "/api/v1/rooms/{room_id}/players", h.JoinRoomV1Handler},
+		{"POST", "/api/v1/rooms/{room_id}/start", h.StartGameV1Handler},
+		{"POST", "/api/v1/rooms/{room_id}/lines", h.SubmitLineV1Handler},
+		{"GET", "/api/v1/rooms/{room_id}/story", h.GetStoryV1Handler},
+		{"GET", "/api/v1/rooms/{room_id}/record", h.GetStoryRecordV1Handler},
+	}
+	handleRoutes(router, policy, instrument, v1Routes, func(next http.Handler) http.Handler {
+		return h.RateLimit(api.ValidateRequest(next))
+	})
+
+	// gRPC, gRPC-Web and Connect clients are served alongside the REST routes.
+	path, handler := storytellingv1connect.NewStoryServiceHandler(h.StoryService())
+	router.PathPrefix(path).Handler(instrument(path, policy.Handler(path, []string{"GET", "POST"}, h.RateLimit(handler))))
+	return router
+}
+
+// handleRoutes registers routes behind the CORS policy and wrap, measured by
+// instrument. Each path also answers OPTIONS, so that preflights list every
+// method served there.
+func handleRoutes(router *mux.Router, policy *cors.Policy, instrument func(string, http.Handler) http.Handler, routes []Route, wrap func(http.Handler) http.Handler) {
+	var paths []string
+	methods := make(map[string][]string)
+	for _, route := range routes {
+		if _, ok := methods[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		methods[route.Path] = append(methods[route.Path], route.Method)
+	}
+	for _, route := range routes {
+		handler := policy.Handler(route.Path, methods[route.Path], wrap(route.Handler))
+		router.Handle(route.Path, instrument(route.Path, handler)).Methods(route.Method)
+	}
+	for _, path := range paths {
+		router.Handle(path, policy.Handler(path, methods[path], http.NotFoundHandler())).Methods(http.MethodOptions)
+	}
+}
+
+// deprecated marks a legacy route, pointing clients at the route replacing it.
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+		next(w, r)
+	}
+}
@@ -0,0 +1,258 @@
+// server/server.go
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"storytelling-backend/config"
+	"storytelling-backend/internal/api"
+	"storytelling-backend/internal/auth"
+	"storytelling-backend/internal/cors"
+	"storytelling-backend/internal/gallery"
+	"storytelling-backend/internal/game"
+	"storytelling-backend/internal/logging"
+	"storytelling-backend/internal/metrics"
+	"storytelling-backend/internal/models"
+	"storytelling-backend/internal/ratelimit"
+	"storytelling-backend/internal/search"
+	"storytelling-backend/internal/storage"
+	"storytelling-backend/internal/tracing"
+	"storytelling-backend/internal/webhook"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sessionPurgeInterval is how often expired login sessions are removed from
+// storage.
+const sessionPurgeInterval = time.Hour
+
+// Options supply parts of a Server instead of building them from the
+// configuration. Zero fields fall back to the defaults.
+type Options struct {
+	// Storage holds stories and accounts; default: the configured backend.
+	// Shutdown and Close do not close a storage given here.
+	Storage storage.Storage
+	// Rooms manages the rooms; default: a RoomManager over Storage that
+	// restores the rooms saved by the last Shutdown. A RoomManager given here
+	// keeps its own clock, ID generator and services, and is not restored.
+	Rooms *game.RoomManager
+	// Clock stamps lines, stories, sessions and gallery entries; default: time.Now.
+	Clock models.Clock
+	// NewRoomID generates room IDs; default: codes of game.room_code_length letters.
+	NewRoomID func() string
+	// Logger receives the server's logs; default: slog.Default(). Request
+	// handlers log through it with each line tagged with the request ID.
+	Logger *slog.Logger
+	// TracerProvider records the server's spans; default: the exporter set
+	// by the tracing configuration, writing stdout spans to os.Stdout.
+	// Shutdown does not shut down a provider given here.
+	TracerProvider trace.TracerProvider
+	// ChatModerators inspect every chat message before it is posted, in
+	// order; default: none. Ignored when Rooms is given.
+	ChatModerators []models.ChatModerator
+}
+
+// Server is a complete game server: the rooms and every service around them,
+// and the routes serving them. Servers share nothing, so several can run in
+// one process.
+type Server struct {
+	config   *config.Config
+	storage  storage.Storage
+	rooms    *game.RoomManager
+	accounts *auth.AccountManager
+	webhooks *webhook.Dispatcher
+	handler  http.Handler
+	logger   *slog.Logger
+
+	// shutdownTracing flushes the spans the server's own tracer provider buffers.
+	shutdownTracing func(context.Context) error
+	// closeStorage closes the storage the server opened itself.
+	closeStorage func() error
+
+	// stopPurge ends the periodic purge of expired sessions.
+	stopPurge chan struct{}
+	stopOnce  sync.Once
+	drainOnce sync.Once
+}
+
+// New builds a Server from cfg, which must already be valid.
+func New(cfg *config.Config, opts Options) (*Server, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	provider, shutdownTracing := opts.TracerProvider, func(context.Context) error { return nil }
+	if provider == nil {
+		var err error
+		if provider, shutdownTracing, err = tracing.New(cfg.Tracing, os.Stdout); err != nil {
+			return nil, err
+		}
+	}
+	tracer := provider.Tracer(tracing.ScopeName)
+	store, closeStorage := opts.Storage, func() error { return nil }
+	if store == nil {
+		var err error
+		if store, err = storage.Open(cfg.Storage); err != nil {
+			return nil, err
+		}
+		closeStorage = store.Close
+	}
+	store = storage.Traced(store, tracer)
+	var stats *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		stats = metrics.New()
+	}
+	index := search.NewIndex(cfg.Game.SearchMaxLines)
+	limits := ratelimit.NewGuard(cfg.Limits)
+	webhooks := webhook.NewDispatcher(webhook.Options{
+		Client:      webhook.NewClient(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateTargets),
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseBackoff: cfg.Webhooks.BaseBackoff,
+		MaxBackoff:  cfg.Webhooks.MaxBackoff,
+		Logger:      logger,
+		Store:       store,
+	})
+	if err := webhooks.Restore(); err != nil {
+		webhooks.Stop(0)
+		closeStorage()
+		return nil, err
+	}
+	rooms := opts.Rooms
+	if rooms == nil {
+		rooms = game.NewRoomManager(store, cfg.Game, game.Services{
+			Index:          index,
+			Webhooks:       webhooks,
+			Limits:         limits,
+			Metrics:        stats,
+			Logger:         logger,
+			Tracer:         tracer,
+			Clock:          opts.Clock,
+			NewRoomID:      opts.NewRoomID,
+			ChatModerators: opts.ChatModerators,
+		})
+		if err := rooms.RestoreRooms(); err != nil {
+			webhooks.Stop(0)
+			closeStorage()
+			return nil, err
+		}
+	}
+	accounts := auth.NewAccountManager(store, cfg.Auth, opts.Clock)
+	policy := cors.NewPolicy(cfg.CORS)
+	stats.WatchRooms(rooms.Stats)
+	stats.CounterFunc("cors_rejected_requests_total", "Cross-origin requests refused by the origin policy.",
+		func() float64 { return float64(policy.Stats().RejectedRequests) })
+	stats.CounterFunc("cors_rejected_upgrades_total", "WebSocket upgrades refused by the origin policy.",
+		func() float64 { return float64(policy.Stats().RejectedUpgrades) })
+
+	handlers := api.NewHandlers(api.Services{
+		Rooms:    rooms,
+		Accounts: accounts,
+		Gallery:  gallery.NewGallery(store, opts.Clock),
+		Search:   index,
+		Webhooks: webhooks,
+		Limits:   limits,
+		Origins:  policy,
+		Metrics:  stats,
+	})
+	s := &Server{
+		config:   cfg,
+		storage:  store,
+		rooms:    rooms,
+		accounts: accounts,
+		webhooks: webhooks,
+		handler:  logging.Middleware(logger, newRouter(handlers, policy, stats, provider)),
+		logger:   logger,
+
+		shutdownTracing: shutdownTracing,
+		closeStorage:    closeStorage,
+		stopPurge:       make(chan struct{}),
+	}
+	go s.purgeSessions()
+	return s, nil
+}
+
+// purgeSessions removes expired sessions every sessionPurgeInterval until
+// the server stops.
+func (s *Server) purgeSessions() {
+	ticker := time.NewTicker(sessionPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopPurge:
+			return
+		case <-ticker.C:
+			n, err := s.accounts.PurgeExpiredSessions()
+			if err != nil {
+				s.logger.Error("purging expired sessions failed", "error", err)
+			} else if n > 0 {
+				s.logger.Info("purged expired sessions", "count", n)
+			}
+		}
+	}
+}
+
+// stopBackground ends the server's own background work.
+func (s *Server) stopBackground() {
+	s.stopOnce.Do(func() { close(s.stopPurge) })
+}
+
+// Handler returns the handler serving every route.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Config returns the configuration the server was built with.
+func (s *Server) Config() *config.Config {
+	return s.config
+}
+
+// Rooms returns the server's room manager.
+func (s *Server) Rooms() *game.RoomManager {
+	return s.rooms
+}
+
+// Close stops background work, giving queued webhook deliveries and
+// buffered spans up to the webhook timeout to finish, and closes the storage
+// the server opened. The handler must not be used afterwards.
+func (s *Server) Close() {
+	s.stopBackground()
+	s.webhooks.Stop(s.config.Webhooks.Timeout)
+	if err := s.closeStorage(); err != nil {
+		s.logger.Error("closing storage failed", "error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Webhooks.Timeout)
+	defer cancel()
+	s.shutdownTracing(ctx)
+}
+
+// Drain starts a shutdown: no new rooms are created and every client is told
+// the server is restarting. It is safe to call more than once, and suits
+// http.Server.RegisterOnShutdown.
+func (s *Server) Drain() {
+	s.drainOnce.Do(func() {
+		s.rooms.StopAccepting()
+		s.rooms.NotifyRestart()
+	})
+}
+
+// Shutdown drains the server, saves its unfinished rooms to storage for the
+// next server to restore, gives queued webhook deliveries and buffered spans
+// until ctx's deadline to finish, and closes the storage the server opened.
+// Call it after http.Server.Shutdown, once requests have finished. The
+// handler must not be used afterwards.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
+	s.stopBackground()
+	err := s.rooms.SaveRooms()
+	timeout := s.config.Webhooks.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	s.webhooks.Stop(timeout)
+	return errors.Join(err, s.closeStorage(), s.shutdownTracing(ctx), ctx.Err())
+}
@@ -0,0 +1,80 @@
+// server/server_test.go
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"storytelling-backend/config"
+	"testing"
+	"time"
+)
+
+// newTestServer builds a server that keeps nothing on disk and logs nowhere.
+func newTestServer(t *testing.T, edit func(cfg *config.Config)) *Server {
+	t.Helper()
+	cfg := config.Default()
+	if edit != nil {
+		edit(&cfg)
+	}
+	srv, err := New(&cfg, Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return srv
+}
+
+func TestCloseAfterShutdown(t *testing.T) {
+	srv := newTestServer(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	srv.Close() // used to panic closing the webhook dispatcher a second time
+}
+
+// Shutdown closes the storage the server opened, writing the data file
+// before the next server reads it.
+func TestShutdownSavesDataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storytelling-data.json")
+	useDataFile := func(cfg *config.Config) { cfg.Storage.DataFile = path }
+	srv := newTestServer(t, useDataFile)
+	if _, err := srv.accounts.Register("alice", "correct horse", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	restarted := newTestServer(t, useDataFile)
+	defer restarted.Close()
+	if _, _, err := restarted.accounts.Login("alice", "correct horse"); err != nil {
+		t.Errorf("login after a restart: %v", err)
+	}
+}
+
+func TestPurgeExpiredSessions(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	srv, err := New(&cfg, Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), Clock: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if _, err := srv.accounts.Register("alice", "correct horse", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := srv.accounts.Login("alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := srv.accounts.PurgeExpiredSessions(); n != 0 || err != nil {
+		t.Errorf("purge of a fresh session = %d, %v; want 0", n, err)
+	}
+	now = now.Add(srv.config.Auth.SessionDuration + time.Second)
+	if n, err := srv.accounts.PurgeExpiredSessions(); n != 1 || err != nil {
+		t.Errorf("purge of an expired session = %d, %v; want 1", n, err)
+	}
+}